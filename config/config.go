@@ -2,31 +2,69 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/urfave/cli/v2"
 	"leguru.net/m/v2/logger"
 )
 
-type Config struct {
-	WantHelp           bool
-	ConfigFile         string
-	DataFolder         string `json:"DataFolder"`
+// DefaultCoordinatorName is the name of the coordinator described by the SerialPort options
+const DefaultCoordinatorName = "default"
+
+// CoordinatorConfig describes a coordinator managed by the hub in addition to the default one
+type CoordinatorConfig struct {
+	Name               string `json:"Name"`
 	SerialPortName     string `json:"SerialPortName"`
 	SerialPortBaudRate int    `json:"SerialPortBaudRate"`
 	SerialIsEsp8266    bool   `json:"SerialIsEsp8266"`
-	SerialShouldRetry  bool   `json:"SerialShouldRetry"`
 	SerialResetOnInit  bool   `json:"SerialResetOnInit"`
-	VerboseLevel       int    `json:"VerboseLevel"`
-	TargetNode         int    `json:"TargetNode"`
-	DebugNodeAddr      string `json:"DebugNodeAddr"`
-	RestBindAddress    string `json:"RestBindAddress"`
-	RpcBindAddress     string `json:"RpcBindAddress"`
-	BindAddress        string `json:"BindAddress"`
-	BindPort           int    `json:"BindPort"`
-	BasePortOffset     int    `json:"BasePortOffset"`
-	SizeOfPortsPool    int    `json:"SizeOfPortsPool"`
-	EnableZeroconf     bool   `json:"EnableZeroconf"`
+	StandbyPortName    string `json:"StandbyPortName,omitempty"`
+}
+
+type Config struct {
+	WantHelp           bool
+	ConfigFile         string
+	DataFolder         string              `json:"DataFolder"`
+	SerialPortName     string              `json:"SerialPortName"`
+	SerialPortBaudRate int                 `json:"SerialPortBaudRate"`
+	SerialIsEsp8266    bool                `json:"SerialIsEsp8266"`
+	SerialShouldRetry  bool                `json:"SerialShouldRetry"`
+	SerialResetOnInit  bool                `json:"SerialResetOnInit"`
+	SerialPortDetected string              `json:"SerialPortDetected"`
+	StandbyPortName    string              `json:"StandbyPortName"`
+	FailbackPolicy     string              `json:"FailbackPolicy"`
+	FailbackDelay      int                 `json:"FailbackDelay"`
+	VerboseLevel       int                 `json:"VerboseLevel"`
+	TargetNode         int                 `json:"TargetNode"`
+	DebugNodeAddr      string              `json:"DebugNodeAddr"`
+	RestBindAddress    string              `json:"RestBindAddress"`
+	RpcBindAddress     string              `json:"RpcBindAddress"`
+	BindAddress        string              `json:"BindAddress"`
+	BindPort           int                 `json:"BindPort"`
+	BasePortOffset     int                 `json:"BasePortOffset"`
+	SizeOfPortsPool    int                 `json:"SizeOfPortsPool"`
+	EnableZeroconf     bool                `json:"EnableZeroconf"`
+	WatchdogInterval   int                 `json:"WatchdogInterval"`
+	PoliteBroadcast    bool                `json:"PoliteBroadcast"`
+	AirtimeBudgets     string              `json:"AirtimeBudgets"`
+	RoutingPolicy      string              `json:"RoutingPolicy"`
+	Coordinators       []CoordinatorConfig `json:"Coordinators,omitempty"`
+	RelayBindAddress   string              `json:"-"`
+	RelaySecret        string              `json:"RelaySecret"`
+	RelayTlsCert       string              `json:"-"`
+	RelayTlsKey        string              `json:"-"`
+	RelayTlsCa         string              `json:"RelayTlsCa"`
+	RelayMode          bool                `json:"-"`
+	SimulateTopology   string              `json:"-"`
+	CaptureFile        string              `json:"-"`
+	RecordFile         string              `json:"-"`
+	ReplayFile         string              `json:"-"`
+
+	// Absolute path of the config file, the working directory can change later
+	path string
 }
 
 func NewConfig() (*Config, error) {
@@ -46,6 +84,12 @@ func NewConfig() (*Config, error) {
 		SerialShouldRetry:  true,
 		SerialResetOnInit:  false,
 		EnableZeroconf:     false,
+		WatchdogInterval:   30,
+		FailbackPolicy:     "manual",
+		FailbackDelay:      60,
+		AirtimeBudgets:     "bulk=50,background=20",
+		RoutingPolicy:      "cost",
+		RelayBindAddress:   ":4050",
 		DataFolder:         "",
 	}
 
@@ -56,7 +100,7 @@ func NewConfig() (*Config, error) {
 			&cli.StringFlag{
 				Name:        "port",
 				Value:       config.SerialPortName,
-				Usage:       "Serial port name",
+				Usage:       "Serial port name, auto to search the coordinator, or tcp://host:port and rfc2217://host:port for a network attached coordinator",
 				Aliases:     []string{"p"},
 				Destination: &config.SerialPortName,
 			},
@@ -138,6 +182,84 @@ func NewConfig() (*Config, error) {
 				Usage:       "Enable zeroconf",
 				Destination: &config.EnableZeroconf,
 			},
+			&cli.BoolFlag{
+				Name:        "enable_polite_broadcast",
+				Value:       config.PoliteBroadcast,
+				Usage:       "Allow the requests flooded to the whole network, they take a lot of airtime",
+				Destination: &config.PoliteBroadcast,
+			},
+			&cli.StringFlag{
+				Name:        "airtime_budgets",
+				Value:       config.AirtimeBudgets,
+				Usage:       "Percent of the airtime that every traffic class (interactive, control, bulk, background) can use, like bulk=50,background=20",
+				Destination: &config.AirtimeBudgets,
+			},
+			&cli.StringFlag{
+				Name:        "routing",
+				Value:       config.RoutingPolicy,
+				Usage:       "Policy of the paths to the nodes: cost, hops or bottleneck with the penalty, maxhops and minquality options, like hops,penalty=0.3,maxhops=4",
+				Destination: &config.RoutingPolicy,
+			},
+			&cli.StringFlag{
+				Name:        "standby_port",
+				Value:       config.StandbyPortName,
+				Usage:       "Port of a second coordinator kept ready to replace the first one when it fails",
+				Destination: &config.StandbyPortName,
+			},
+			&cli.StringFlag{
+				Name:        "failback",
+				Value:       config.FailbackPolicy,
+				Usage:       "Return to the first coordinator after a failover: manual or auto",
+				Destination: &config.FailbackPolicy,
+			},
+			&cli.IntFlag{
+				Name:        "failback_delay",
+				Value:       config.FailbackDelay,
+				Usage:       "Seconds the first coordinator must answer before the auto failback",
+				Destination: &config.FailbackDelay,
+			},
+			&cli.IntFlag{
+				Name:        "watchdog_interval",
+				Value:       config.WatchdogInterval,
+				Usage:       "Seconds between two checks of the coordinator, 0 disables the watchdog",
+				Destination: &config.WatchdogInterval,
+			},
+			&cli.StringFlag{
+				Name:        "simulate",
+				Value:       config.SimulateTopology,
+				Usage:       "Use a simulated coordinator with the network described by the GraphML topology file",
+				Destination: &config.SimulateTopology,
+			},
+			&cli.StringFlag{
+				Name:        "capture",
+				Value:       config.CaptureFile,
+				Usage:       "Write the frames exchanged with the coordinator to a pcapng file",
+				Destination: &config.CaptureFile,
+			},
+			&cli.StringFlag{
+				Name:        "record",
+				Value:       config.RecordFile,
+				Usage:       "Record the byte stream exchanged with the coordinator to a file",
+				Destination: &config.RecordFile,
+			},
+			&cli.StringFlag{
+				Name:        "replay",
+				Value:       config.ReplayFile,
+				Usage:       "Use a file saved with --record in place of the coordinator",
+				Destination: &config.ReplayFile,
+			},
+			&cli.StringFlag{
+				Name:        "relay_secret",
+				Value:       config.RelaySecret,
+				Usage:       "Secret shared between the hub and the relay of the coordinator port",
+				Destination: &config.RelaySecret,
+			},
+			&cli.StringFlag{
+				Name:        "relay_ca",
+				Value:       config.RelayTlsCa,
+				Usage:       "Certificate used to check a relays:// coordinator port, the system ones are used when empty",
+				Destination: &config.RelayTlsCa,
+			},
 			&cli.StringFlag{
 				Name:        "data_folder",
 				Value:       config.DataFolder,
@@ -149,12 +271,44 @@ func NewConfig() (*Config, error) {
 			config.WantHelp = false
 			return nil
 		},
+		Commands: []*cli.Command{
+			{
+				Name:  "relay",
+				Usage: "Serve the coordinator port to a hub running on another machine",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "listen",
+						Value:       config.RelayBindAddress,
+						Usage:       "Bind address of the relay",
+						Destination: &config.RelayBindAddress,
+					},
+					&cli.StringFlag{
+						Name:        "tls_cert",
+						Value:       config.RelayTlsCert,
+						Usage:       "Certificate of the relay, enables TLS together with --tls_key",
+						Destination: &config.RelayTlsCert,
+					},
+					&cli.StringFlag{
+						Name:        "tls_key",
+						Value:       config.RelayTlsKey,
+						Usage:       "Private key of the relay certificate",
+						Destination: &config.RelayTlsKey,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					config.RelayMode = true
+					return nil
+				},
+			},
+		},
 	}
 
 	if err = app.Run(os.Args); err != nil {
 		logger.Log().Fatal(err)
 	}
 
+	config.path, _ = filepath.Abs(config.ConfigFile)
+
 	if _, err = os.Stat(config.ConfigFile); err == nil {
 		// If the config file exists, read it
 		var data []byte
@@ -178,3 +332,57 @@ func NewConfig() (*Config, error) {
 
 	return &config, err
 }
+
+// AllCoordinators returns the default coordinator followed by the ones of the Coordinators list
+func (c *Config) AllCoordinators() ([]CoordinatorConfig, error) {
+	coordinators := []CoordinatorConfig{{
+		Name:               DefaultCoordinatorName,
+		SerialPortName:     c.SerialPortName,
+		SerialPortBaudRate: c.SerialPortBaudRate,
+		SerialIsEsp8266:    c.SerialIsEsp8266,
+		SerialResetOnInit:  c.SerialResetOnInit,
+		StandbyPortName:    c.StandbyPortName,
+	}}
+
+	names := map[string]bool{DefaultCoordinatorName: true}
+	for _, coordinator := range c.Coordinators {
+		if coordinator.Name == "" || names[coordinator.Name] {
+			return nil, fmt.Errorf("coordinator name %q is empty or already used", coordinator.Name)
+		}
+		if coordinator.SerialPortName == "" {
+			return nil, fmt.Errorf("coordinator %s has no serial port", coordinator.Name)
+		}
+		if coordinator.SerialPortBaudRate == 0 {
+			coordinator.SerialPortBaudRate = c.SerialPortBaudRate
+		}
+		names[coordinator.Name] = true
+		coordinators = append(coordinators, coordinator)
+	}
+
+	for _, coordinator := range coordinators {
+		if coordinator.StandbyPortName != "" && coordinator.StandbyPortName == coordinator.SerialPortName {
+			return nil, fmt.Errorf("coordinator %s uses the same port as standby", coordinator.Name)
+		}
+	}
+	return coordinators, nil
+}
+
+// Persist changes a single value of the config file, the other values are kept as they are in the file
+func (c *Config) Persist(key string, value any) error {
+	values := make(map[string]any)
+	data, err := os.ReadFile(c.path)
+	if err == nil {
+		if err = json.Unmarshal(data, &values); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	values[key] = value
+	data, err = json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
@@ -0,0 +1,196 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"leguru.net/m/v2/utils"
+)
+
+// NetworkEventType is the kind of change described by a NetworkEvent
+type NetworkEventType int
+
+const (
+	NodeAdded NetworkEventType = iota
+	NodeRemoved
+	NodeUpdated
+	EdgeAdded
+	EdgeRemoved
+	EdgeWeightChanged
+	LocalNodeChanged
+)
+
+var networkEventTypeNames = []string{"NodeAdded", "NodeRemoved", "NodeUpdated", "EdgeAdded", "EdgeRemoved", "EdgeWeightChanged", "LocalNodeChanged"}
+
+func (t NetworkEventType) String() string {
+	if int(t) < len(networkEventTypeNames) {
+		return networkEventTypeNames[t]
+	}
+	return "Unknown"
+}
+
+// NetworkEvent is a single change of a network. Node is the node of the node events and the new local
+// node of LocalNodeChanged, From and To are the ends of the edge of the edge events.
+type NetworkEvent struct {
+	Type NetworkEventType
+	Node int64
+	// The old local node of LocalNodeChanged
+	Previous int64
+	From     int64
+	To       int64
+	// The weight of the edge, OldWeight is set by EdgeWeightChanged and EdgeRemoved
+	Weight    float64
+	OldWeight float64
+	// The device fields changed by NodeUpdated, they are named like the graphml keys
+	Fields []string
+	// The version of the network after the transaction of the event. The events are delivered in
+	// version order and every delivered transaction has the next version, a jump means missed events
+	Version uint64
+}
+
+// Changed tells if a NodeUpdated event changed one of the given fields
+func (e NetworkEvent) Changed(fields ...string) bool {
+	for _, field := range fields {
+		if slices.Contains(e.Fields, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e NetworkEvent) String() string {
+	switch e.Type {
+	case NodeAdded, NodeRemoved:
+		return fmt.Sprintf("%s %s", e.Type, utils.FmtNodeId(e.Node))
+	case NodeUpdated:
+		return fmt.Sprintf("%s %s [%s]", e.Type, utils.FmtNodeId(e.Node), strings.Join(e.Fields, ","))
+	case EdgeAdded:
+		return fmt.Sprintf("%s %s>%s %.2f", e.Type, utils.FmtNodeId(e.From), utils.FmtNodeId(e.To), e.Weight)
+	case EdgeRemoved:
+		return fmt.Sprintf("%s %s>%s", e.Type, utils.FmtNodeId(e.From), utils.FmtNodeId(e.To))
+	case EdgeWeightChanged:
+		return fmt.Sprintf("%s %s>%s %.2f>%.2f", e.Type, utils.FmtNodeId(e.From), utils.FmtNodeId(e.To), e.OldWeight, e.Weight)
+	case LocalNodeChanged:
+		return fmt.Sprintf("%s %s>%s", e.Type, utils.FmtNodeId(e.Previous), utils.FmtNodeId(e.Node))
+	}
+	return e.Type.String()
+}
+
+// changedFields returns the names of the fields that differ between two copies of a device
+func (d deviceState) changedFields(other deviceState) []string {
+	fields := make([]string, 0)
+	if d.inuse != other.inuse {
+		fields = append(fields, "inuse")
+	}
+	if d.deepSleep != other.deepSleep {
+		fields = append(fields, "deepsleep")
+	}
+	if d.discovered != other.discovered {
+		fields = append(fields, "discovered")
+	}
+	if d.tag != other.tag {
+		fields = append(fields, "tag")
+	}
+	if d.nodeType != other.nodeType {
+		fields = append(fields, "nodetype")
+	}
+	if d.name != other.name {
+		fields = append(fields, "name")
+	}
+	if d.friendlyName != other.friendlyName {
+		fields = append(fields, "friendlyname")
+	}
+	if d.firmware != other.firmware {
+		fields = append(fields, "firmware")
+	}
+	if d.libVersion != other.libVersion {
+		fields = append(fields, "libvers")
+	}
+	if !d.compileTime.Equal(other.compileTime) {
+		fields = append(fields, "comptime")
+	}
+	if !d.lastSeen.Equal(other.lastSeen) {
+		fields = append(fields, "lastseen")
+	}
+	if d.routing != other.routing {
+		fields = append(fields, "routing")
+	}
+	return fields
+}
+
+// AddNetworkEventCallback registers a callback called for every change of the network, the events of a
+// transaction are published when it is complete from the goroutine that made the changes. The callbacks
+// are called one transaction at a time and must not change the network.
+func (g *Network) AddNetworkEventCallback(cb func(network *Network, event NetworkEvent)) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.networkEventCallbacks = append(g.networkEventCallbacks, cb)
+}
+
+// PublishChanges publishes the events that turn the old network in this one, used when a network
+// replaces another like at the end of a discovery
+func (g *Network) PublishChanges(old *Network) {
+	current, previous := g.Snapshot(), old.Snapshot()
+	events := make([]NetworkEvent, 0)
+
+	oldEdges := previous.WeightedEdges()
+	for oldEdges.Next() {
+		edge := oldEdges.WeightedEdge()
+		if !current.HasEdgeFromTo(edge.From().ID(), edge.To().ID()) {
+			events = append(events, NetworkEvent{Type: EdgeRemoved, From: edge.From().ID(), To: edge.To().ID(), OldWeight: edge.Weight()})
+		}
+	}
+
+	oldNodes := previous.Nodes()
+	for oldNodes.Next() {
+		if !current.NodeIdExists(oldNodes.Node().ID()) {
+			events = append(events, NetworkEvent{Type: NodeRemoved, Node: oldNodes.Node().ID()})
+		}
+	}
+
+	nodes := current.Nodes()
+	for nodes.Next() {
+		node := nodes.Node().(NodeDevice)
+		oldNode, err := previous.GetNodeDevice(node.ID())
+		if err != nil {
+			events = append(events, NetworkEvent{Type: NodeAdded, Node: node.ID()})
+		} else if fields := oldNode.Device().state().changedFields(node.Device().state()); len(fields) > 0 {
+			events = append(events, NetworkEvent{Type: NodeUpdated, Node: node.ID(), Fields: fields})
+		}
+	}
+
+	edges := current.WeightedEdges()
+	for edges.Next() {
+		edge := edges.WeightedEdge()
+		fromId, toId := edge.From().ID(), edge.To().ID()
+		if !previous.HasEdgeFromTo(fromId, toId) {
+			events = append(events, NetworkEvent{Type: EdgeAdded, From: fromId, To: toId, Weight: edge.Weight()})
+		} else if oldWeight := previous.WeightedEdge(fromId, toId).Weight(); oldWeight != edge.Weight() {
+			events = append(events, NetworkEvent{Type: EdgeWeightChanged, From: fromId, To: toId, Weight: edge.Weight(), OldWeight: oldWeight})
+		}
+	}
+
+	if previous.localDeviceId != current.localDeviceId {
+		events = append(events, NetworkEvent{Type: LocalNodeChanged, Node: current.localDeviceId, Previous: previous.localDeviceId})
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	// The changes are published as a transaction of their own
+	g.lock.Lock()
+	g.version++
+	version := g.version
+	callbacks := slices.Clone(g.networkEventCallbacks)
+	g.deliveryLock.Lock()
+	defer g.deliveryLock.Unlock()
+	g.lock.Unlock()
+	for _, event := range events {
+		event.Version = version
+		for _, cb := range callbacks {
+			cb(g, event)
+		}
+	}
+}
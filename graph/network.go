@@ -1,22 +1,31 @@
 package graph
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/sirupsen/logrus"
 	"gonum.org/v1/gonum/graph"
 	"gonum.org/v1/gonum/graph/iterator"
-	"gonum.org/v1/gonum/graph/path"
 	"gonum.org/v1/gonum/graph/simple"
 	"leguru.net/m/v2/logger"
 	"leguru.net/m/v2/meshmesh/pb"
 	"leguru.net/m/v2/utils"
 )
 
+// Errors returned by the lookups and the changes of the network, they can be tested with errors.Is
+var (
+	ErrNodeNotFound = errors.New("node not found")
+	ErrNodeExists   = errors.New("node already exists")
+	ErrNodeInactive = errors.New("node is not active")
+	ErrNoRoute      = errors.New("no path found")
+)
+
 type NodeType int
 
 const (
@@ -54,6 +63,7 @@ const (
 	NETWORK_ID_MAIN      = 0
 	NETWORK_ID_STARPATH  = 1
 	NETWORK_ID_DISCOVERY = 2
+	NETWORK_ID_SIMULATOR = 3
 )
 
 const (
@@ -62,7 +72,8 @@ const (
 	compileTimeFormatLong  = "2006-01-02 15:04:05 -0700"
 )
 
-type Device struct {
+// deviceState holds the fields of a device, it is copied to compare and to snapshot a device
+type deviceState struct {
 	inuse        bool
 	deepSleep    bool
 	discovered   bool
@@ -74,133 +85,207 @@ type Device struct {
 	libVersion   string
 	compileTime  time.Time
 	lastSeen     time.Time
+	routing      RoutingPolicy
+}
+
+// Device are the properties of a node, they are safe for concurrent use. The devices of a network
+// must be changed with Network.UpdateNode to publish the change.
+type Device struct {
+	lock sync.RWMutex
+	deviceState
+}
+
+func (d *Device) state() deviceState {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.deviceState
+}
+
+func (d *Device) clone() *Device {
+	return &Device{deviceState: d.state()}
 }
 
 func (d *Device) InUse() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.inuse
 }
 
 func (d *Device) SetInUse(inuse bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.inuse = inuse
 }
 
 func (d *Device) DeepSleep() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.deepSleep
 }
 
 func (d *Device) SetDeepSleep(deepSleep bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.deepSleep = deepSleep
 }
 
 func (d *Device) Discovered() bool {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.discovered
 }
 
 func (d *Device) SetDiscovered(discovered bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.discovered = discovered
 }
 
 func (d *Device) Tag() string {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.tag
 }
 
 func (d *Device) SetTag(tag string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.tag = tag
 }
 
 func (d *Device) NodeType() NodeType {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.nodeType
 }
 
-func (d *Device) NodeTypeString() string {
-	return EnumNodeTypeToString(d.nodeType)
-}
-
 func (d *Device) SetNodeType(nodeType NodeType) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.nodeType = nodeType
 }
 
+func (d *Device) NodeTypeString() string {
+	return EnumNodeTypeToString(d.NodeType())
+}
+
 func (d *Device) SetNodeTypeString(nodeType string) {
-	d.nodeType = stringNodeTypeToEnum(nodeType)
+	d.SetNodeType(stringNodeTypeToEnum(nodeType))
 }
 
 func (d *Device) Name() string {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.name
 }
 
 func (d *Device) SetName(name string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.name = name
 }
 
 func (d *Device) FriendlyName() string {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.friendlyName
 }
 
 func (d *Device) SetFriendlyName(friendlyName string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.friendlyName = friendlyName
 }
 
 func (d *Device) Firmware() string {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.firmware
 }
 
 func (d *Device) SetFirmware(firmware string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.firmware = firmware
 }
 
 func (d *Device) CompileTime() time.Time {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.compileTime
 }
 
+func (d *Device) SetCompileTime(compileTime time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.compileTime = compileTime
+}
+
 func (d *Device) CompileTimeString() string {
-	if d.compileTime.IsZero() {
+	compileTime := d.CompileTime()
+	if compileTime.IsZero() {
 		return ""
 	}
-	return d.compileTime.Format(compileTimeFormat)
-}
-
-func (d *Device) SetCompileTime(compileTime time.Time) {
-	d.compileTime = compileTime
+	return compileTime.Format(compileTimeFormat)
 }
 
 func (d *Device) SetCompileTimeString(compileTime string) {
-	var err error
-
-	d.compileTime, err = time.Parse(compileTimeFormatLong, compileTime)
+	parsed, err := time.Parse(compileTimeFormatLong, compileTime)
 	if err != nil {
-		d.compileTime, err = time.Parse(compileTimeFormat, compileTime)
+		parsed, err = time.Parse(compileTimeFormat, compileTime)
 		if err != nil {
 			sep := strings.Index(compileTime, ",")
 			if sep != -1 {
 				compileTime = compileTime[:sep]
 			}
-			d.compileTime, err = time.Parse(compileTimeFormatShort, compileTime)
+			parsed, err = time.Parse(compileTimeFormatShort, compileTime)
 			if err != nil {
-				d.compileTime = time.Time{}
+				parsed = time.Time{}
 			}
 		}
 	}
-
+	d.SetCompileTime(parsed)
 }
 
 func (d *Device) LibVersion() string {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.libVersion
 }
 
 func (d *Device) SetLibVersion(libVersion string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.libVersion = libVersion
 }
 
 func (d *Device) LastSeen() time.Time {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
 	return d.lastSeen
 }
 
 func (d *Device) SetLastSeen(lastSeen time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
 	d.lastSeen = lastSeen
 }
 
+// RoutingPolicy is the policy of the paths to the node, RoutingDefault when it uses the default one
+func (d *Device) RoutingPolicy() RoutingPolicy {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.routing
+}
+
+func (d *Device) SetRoutingPolicy(routing RoutingPolicy) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.routing = routing
+}
+
 func NewDevice(inuse bool, tag string) *Device {
-	return &Device{inuse: inuse, tag: tag}
+	return &Device{deviceState: deviceState{inuse: inuse, tag: tag}}
 }
 
 type NodeDevice struct {
@@ -217,71 +302,227 @@ func (n NodeDevice) Device() *Device {
 }
 
 func (n NodeDevice) DeviceTagOrFormattedId() string {
-	if n.device != nil && n.device.tag != "" {
-		return n.device.tag
+	if n.device != nil {
+		if tag := n.device.Tag(); tag != "" {
+			return tag
+		}
 	}
 	return utils.FmtNodeId(n.id)
 }
 
+// CopyDevice returns the node with a copy of its device
 func (n NodeDevice) CopyDevice() NodeDevice {
-	return NodeDevice{id: n.id, device: n.device}
+	if n.device == nil {
+		return n
+	}
+	return NodeDevice{id: n.id, device: n.device.clone()}
 }
 
 func NewNodeDevice(id int64, inuse bool, tag string) NodeDevice {
 	return NodeDevice{id: id, device: NewDevice(inuse, tag)}
 }
 
-// Network: is a weighted directed graph of NodeDevices
-var mainNetwork *Network
-var mainNetworkLock sync.Mutex
+// Network: is a weighted directed graph of NodeDevices, safe for concurrent use. The reads lock the graph
+// for every call, Snapshot returns a consistent copy for the readers that walk the whole graph.
+// The changes are made in a transaction with Update, or with the methods that wrap a single change.
+// The nodes returned by the reads have a copy of their device, the devices are changed with UpdateNode.
+type Network struct {
+	lock                    sync.RWMutex
+	graph                   *simple.WeightedDirectedGraph
+	localDeviceId           int64
+	networkChangedCallbacks []func(network *Network, noBackup bool)
+	networkEventCallbacks   []func(network *Network, event NetworkEvent)
+	networkId               int
+	// Incremented by every transaction that changes the graph and by PublishChanges, topology
+	// only by the changes of the nodes, the edges and the local device
+	version  uint64
+	topology uint64
+	snapshot atomic.Pointer[NetworkSnapshot]
+	// Taken before the write lock of a transaction is released and held while its events are
+	// delivered, so that the callbacks see the transactions in version order
+	deliveryLock sync.Mutex
+}
 
-func GetMainNetwork() *Network {
-	mainNetworkLock.Lock()
-	defer mainNetworkLock.Unlock()
-	return mainNetwork
+// orderedNodes copies the nodes of an iterator, so that the graph can change while the caller iterates
+func orderedNodes(nodes graph.Nodes) graph.Nodes {
+	return iterator.NewOrderedNodes(graph.NodesOf(nodes))
 }
 
-// SetMainNetwork sets the current main network instance pointer and notify all callbacks.
-// It acquires a lock to ensure thread-safe access to the global mainNetwork variable.
-func SetMainNetwork(network *Network) {
-	mainNetworkLock.Lock()
-	mainNetwork = network
-	mainNetwork.networkId = NETWORK_ID_MAIN
-	mainNetworkLock.Unlock()
-	network.NotifyNetworkChanged(false)
+// detached returns the node with a copy of its device, a change of the copy doesn't reach the network
+func detached(node graph.Node) graph.Node {
+	if dev, ok := node.(NodeDevice); ok {
+		return dev.CopyDevice()
+	}
+	return node
 }
 
-type Network struct {
-	simple.WeightedDirectedGraph
-	localDeviceId           int64
-	networkChangedCallbacks []func(network *Network, noBackup bool)
-	networkId               int
+// detachedNodes copies the nodes of an iterator with a copy of their devices
+func detachedNodes(nodes graph.Nodes) graph.Nodes {
+	copied := make([]graph.Node, 0, nodes.Len())
+	for nodes.Next() {
+		copied = append(copied, detached(nodes.Node()))
+	}
+	return iterator.NewOrderedNodes(copied)
+}
+
+// detachedEdge returns the edge between the copies of its nodes, nil for a missing edge
+func detachedEdge(edge graph.WeightedEdge) graph.WeightedEdge {
+	if edge == nil {
+		return nil
+	}
+	return simple.WeightedEdge{F: detached(edge.From()), T: detached(edge.To()), W: edge.Weight()}
+}
+
+func (g *Network) Node(id int64) graph.Node {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	if node := g.graph.Node(id); node != nil {
+		return detached(node)
+	}
+	return nil
+}
+
+func (g *Network) Nodes() graph.Nodes {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return detachedNodes(g.graph.Nodes())
+}
+
+func (g *Network) From(id int64) graph.Nodes {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return detachedNodes(g.graph.From(id))
+}
+
+func (g *Network) To(id int64) graph.Nodes {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return detachedNodes(g.graph.To(id))
+}
+
+func (g *Network) HasEdgeBetween(xid, yid int64) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.graph.HasEdgeBetween(xid, yid)
+}
+
+func (g *Network) HasEdgeFromTo(uid, vid int64) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.graph.HasEdgeFromTo(uid, vid)
+}
+
+func (g *Network) Edge(uid, vid int64) graph.Edge {
+	if edge := g.WeightedEdge(uid, vid); edge != nil {
+		return edge
+	}
+	return nil
+}
+
+func (g *Network) WeightedEdge(uid, vid int64) graph.WeightedEdge {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return detachedEdge(g.graph.WeightedEdge(uid, vid))
+}
+
+func (g *Network) Weight(xid, yid int64) (float64, bool) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	return g.graph.Weight(xid, yid)
+}
+
+func (g *Network) Edges() graph.Edges {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	edges := g.graph.WeightedEdges()
+	copied := make([]graph.Edge, 0, edges.Len())
+	for edges.Next() {
+		copied = append(copied, detachedEdge(edges.WeightedEdge()))
+	}
+	return iterator.NewOrderedEdges(copied)
+}
+
+func (g *Network) WeightedEdges() graph.WeightedEdges {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	edges := g.graph.WeightedEdges()
+	copied := make([]graph.WeightedEdge, 0, edges.Len())
+	for edges.Next() {
+		copied = append(copied, detachedEdge(edges.WeightedEdge()))
+	}
+	return iterator.NewOrderedWeightedEdges(copied)
+}
+
+func (g *Network) NewWeightedEdge(from, to graph.Node, weight float64) graph.WeightedEdge {
+	return g.graph.NewWeightedEdge(from, to, weight)
 }
 
 func (g *Network) EdgesTo(nodeId int64) graph.Edges {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	nodes := g.graph.To(nodeId)
+	copied := make([]graph.Edge, 0, nodes.Len())
+	for nodes.Next() {
+		copied = append(copied, detachedEdge(g.graph.WeightedEdge(nodes.Node().ID(), nodeId)))
+	}
+	return iterator.NewOrderedEdges(copied)
+}
+
+func edgesTo(g *simple.WeightedDirectedGraph, nodeId int64) graph.Edges {
 	foundEdges := make([]graph.Edge, 0)
 
-	edges := g.Edges()
-	for edges.Next() {
-		edge := edges.Edge()
-		if edge.To().ID() == nodeId {
-			foundEdges = append(foundEdges, edge)
-		}
+	nodes := g.To(nodeId)
+	for nodes.Next() {
+		foundEdges = append(foundEdges, g.Edge(nodes.Node().ID(), nodeId))
 	}
 
 	return iterator.NewOrderedEdges(foundEdges)
 }
 
 func (g *Network) NetworkId() int {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
 	return g.networkId
 }
 
+// AddNetworkChangedCallback registers a callback called once at the end of every transaction that changed
+// the network, the single changes are published to the callbacks of AddNetworkEventCallback
 func (g *Network) AddNetworkChangedCallback(cb func(network *Network, noBackup bool)) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
 	g.networkChangedCallbacks = append(g.networkChangedCallbacks, cb)
 }
 
+// MoveCallbacks gives the id and the callbacks of the network to its replacement
+func (g *Network) MoveCallbacks(to *Network) {
+	g.lock.Lock()
+	networkId := g.networkId
+	changedCallbacks, eventCallbacks := g.networkChangedCallbacks, g.networkEventCallbacks
+	g.networkChangedCallbacks = nil
+	g.networkEventCallbacks = nil
+	g.lock.Unlock()
+
+	to.lock.Lock()
+	defer to.lock.Unlock()
+	to.networkId = networkId
+	to.networkChangedCallbacks = append(to.networkChangedCallbacks, changedCallbacks...)
+	to.networkEventCallbacks = append(to.networkEventCallbacks, eventCallbacks...)
+}
+
+// NotifyNetworkChanged calls the network changed callbacks, the transactions call them when they are complete
 func (g *Network) NotifyNetworkChanged(noBackup bool) {
-	for _, cb := range g.networkChangedCallbacks {
+	g.deliveryLock.Lock()
+	defer g.deliveryLock.Unlock()
+	g.notifyNetworkChanged(noBackup)
+}
+
+// notifyNetworkChanged calls the network changed callbacks with the delivery lock held
+func (g *Network) notifyNetworkChanged(noBackup bool) {
+	g.lock.RLock()
+	callbacks := slices.Clone(g.networkChangedCallbacks)
+	g.lock.RUnlock()
+
+	for _, cb := range callbacks {
 		cb(g, noBackup)
 	}
 }
@@ -291,141 +532,87 @@ func (g *Network) LocalDeviceIdChanged(nodeId int64, nodeInfo *pb.NodeInfo) {
 		return
 	}
 
-	if g.localDeviceId != nodeId {
-		g.localDeviceId = nodeId
-		if nodeId > 0 && !g.NodeIdExists(nodeId) {
-			g.AddNode(NewNodeDevice(nodeId, true, "local"))
-			logger.WithField("device", utils.FmtNodeId(nodeId)).Warn("Local device not found in graph, adding it. Will be an isolated node")
+	g.Update(func(tx *NetworkTx) error {
+		if tx.LocalDeviceId() != nodeId {
+			if nodeId > 0 && !tx.NodeIdExists(nodeId) {
+				tx.AddNode(NewNodeDevice(nodeId, true, "local"))
+				logger.WithField("device", utils.FmtNodeId(nodeId)).Warn("Local device not found in graph, adding it. Will be an isolated node")
+			}
+			tx.SetLocalDeviceId(nodeId)
 		}
-	}
 
-	if nodeInfo != nil {
-		dev, err := g.GetNodeDevice(nodeId)
-		if err != nil {
-			logger.WithField("device", utils.FmtNodeId(nodeId)).Error("Failed to get local device")
-			return
+		if nodeInfo != nil {
+			err := tx.UpdateNode(nodeId, func(device *Device) {
+				device.SetNodeType(NodeType(nodeInfo.NodeType))
+				device.SetFriendlyName(nodeInfo.FriendlyName)
+				device.SetFirmware(nodeInfo.FirmwareVersion)
+				device.SetLibVersion(nodeInfo.LibVersion)
+				device.SetCompileTimeString(nodeInfo.CompileTime)
+			})
+			if err != nil {
+				logger.WithField("device", utils.FmtNodeId(nodeId)).Error("Failed to get local device")
+				return err
+			}
 		}
-		dev.Device().SetNodeType(NodeType(nodeInfo.NodeType))
-		dev.Device().SetFriendlyName(nodeInfo.FriendlyName)
-		dev.Device().SetFirmware(nodeInfo.FirmwareVersion)
-		dev.Device().SetLibVersion(nodeInfo.LibVersion)
-		dev.Device().SetCompileTimeString(nodeInfo.CompileTime)
-	}
-	g.NotifyNetworkChanged(false)
+		return nil
+	})
 }
 
 func (g *Network) LocalDeviceId() int64 {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
 	return g.localDeviceId
 }
 
 func (g *Network) IsLocalDevice(node graph.Node) bool {
-	return g.localDeviceId == node.ID()
+	return g.LocalDeviceId() == node.ID()
 }
 
-func (g *Network) GetNodeDevice(id int64) (NodeDevice, error) {
+func getNodeDevice(g graph.Graph, id int64) (NodeDevice, error) {
 	if node, ok := g.Node(id).(NodeDevice); ok {
 		return node, nil
 	}
-	return NodeDevice{}, fmt.Errorf("node 0x%06X not found in network graph", id)
+	return NodeDevice{}, fmt.Errorf("%w: 0x%06X is not in the network graph", ErrNodeNotFound, id)
 }
 
-func (g *Network) AddNodeWithId(id int64, inuse bool, tag string, seen bool) {
-	g.AddNode(NewNodeDevice(id, inuse, tag))
+// GetNodeDevice returns the node with a copy of its device, see UpdateNode to change it
+func (g *Network) GetNodeDevice(id int64) (NodeDevice, error) {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+	node, err := getNodeDevice(g.graph, id)
+	return node.CopyDevice(), err
 }
 
 func (g *Network) NodeIdExists(id int64) bool {
 	return g.Node(id) != nil
 }
 
-func (g *Network) ChangeEdgeWeight(fromId int64, toId int64, weightFrom float64, weightTo float64) {
-	fromNode, err := g.GetNodeDevice(fromId)
-	if err != nil {
-		fromNode = NewNodeDevice(fromId, false, "")
-		g.AddNode(fromNode)
-	}
-
-	toNode, err := g.GetNodeDevice(toId)
-	if err != nil {
-		toNode = NewNodeDevice(toId, true, "")
-		g.AddNode(toNode)
-	}
-
-	if !g.HasEdgeFromTo(fromId, toId) {
-		g.SetWeightedEdge(g.NewWeightedEdge(fromNode, toNode, weightTo))
-	} else {
-		edgeTo := g.WeightedEdge(fromId, toId).(simple.WeightedEdge)
-		edgeTo.W = weightTo
-		g.SetWeightedEdge(edgeTo)
-	}
-}
-
-// GetPath returns the shortest path from the local device to the target device, along with the total path weight.
-//
-// Parameters:
-//   - to: The target Device to find a path to
-//
-// Returns:
-//   - []int64: Array of node IDs representing the path from local device to target
-//   - float64: Total weight/cost of the path
-//   - error: Error if no path exists or target device is not active
-//
-// The path returned will be the shortest path based on edge weights using Dijkstra's algorithm.
-// Returns an error if:
-// - The target device is not marked as in use/active
-// - No valid path exists between the local device and target
-// The weight returned is currently always 0 (not implemented).
-
+// GetPath returns the shortest path from the local device to the target device, it is computed on a snapshot
+// of the network, see NetworkSnapshot.GetPath
 func (g *Network) GetPath(to NodeDevice) ([]int64, float64, error) {
-	if !to.Device().InUse() {
-		return nil, 0, fmt.Errorf("node is 0x%06X is not active", to.ID())
-	}
-	allShortest := path.DijkstraAllPaths(g)
-	allBetween, weight := allShortest.AllBetween(g.localDeviceId, to.ID())
-	if len(allBetween) == 0 {
-		return nil, 0, fmt.Errorf("no path found between 0x%06X and 0x%06X", g.localDeviceId, to.ID())
-	}
-	logrus.WithFields(logrus.Fields{"length": len(allBetween[0]), "weight": weight}).
-		Debug(fmt.Sprintf("Get path from 0x%06X to 0x%06X", g.localDeviceId, to.ID()))
-
-	nodes := allBetween[0]
-	path := make([]int64, len(nodes))
-	for i, item := range nodes {
-		item := item.(NodeDevice)
-		path[i] = item.ID()
-	}
+	return g.Snapshot().GetPath(to)
+}
 
-	return path, 0, nil
+// GetPaths returns the shortest path to the target device followed by its backups, see NetworkSnapshot.GetPaths
+func (g *Network) GetPaths(to NodeDevice, k int) ([]NetworkPath, error) {
+	return g.Snapshot().GetPaths(to, k)
 }
 
 func (g *Network) SaveToFile(filename string) error {
 	utils.BackupFile(filename, "backup")
-	return g.writeGraph(filename)
+	return g.Snapshot().writeGraph(filename)
 }
 
+// CopyNetwork returns a new network with a copy of the nodes and the edges, without the callbacks
 func (g *Network) CopyNetwork() *Network {
-	network := Network{}
-	network.WeightedDirectedGraph = *simple.NewWeightedDirectedGraph(0, math.Inf(1))
-	network.networkId = g.networkId
-	network.localDeviceId = g.localDeviceId
-
-	nodes := g.Nodes()
-	for nodes.Next() {
-		dev := nodes.Node().(NodeDevice)
-		network.AddNode(dev.CopyDevice())
-	}
-
-	edges := g.Edges()
-	for edges.Next() {
-		edge := edges.Edge().(simple.WeightedEdge)
-		network.SetWeightedEdge(g.NewWeightedEdge(edge.From(), edge.To(), edge.Weight()))
-	}
-
-	return &network
+	snapshot := g.Snapshot()
+	network := &Network{graph: snapshot.copyGraph(), networkId: snapshot.networkId, localDeviceId: snapshot.localDeviceId}
+	return network
 }
 
 func NewNetwork(localDeviceId int64, networkId int) *Network {
 	network := Network{localDeviceId: localDeviceId, networkId: networkId}
-	network.WeightedDirectedGraph = *simple.NewWeightedDirectedGraph(0, math.Inf(1))
+	network.graph = simple.NewWeightedDirectedGraph(0, math.Inf(1))
 	if localDeviceId > 0 {
 		network.AddNode(NewNodeDevice(localDeviceId, true, "local"))
 	}
@@ -434,7 +621,7 @@ func NewNetwork(localDeviceId int64, networkId int) *Network {
 
 func NewNeworkFromFile(filename string, localDeviceId int64, networkId int) (*Network, error) {
 	network := Network{localDeviceId: localDeviceId, networkId: networkId}
-	network.WeightedDirectedGraph = *simple.NewWeightedDirectedGraph(0, math.Inf(1))
+	network.graph = simple.NewWeightedDirectedGraph(0, math.Inf(1))
 	err := network.readGraph(filename)
 	if err != nil {
 		return nil, err
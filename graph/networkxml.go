@@ -89,12 +89,19 @@ func (g *Network) readGraph(filename string) error {
 				dev.Device().SetLibVersion(parseString(attrs, "libvers"))
 				dev.Device().SetDeepSleep(parseBool(attrs, "deepsleep"))
 				dev.Device().SetNodeTypeString(parseString(attrs, "nodetype"))
+				routing, err := ParseRoutingPolicy(parseString(attrs, "routing"))
+				if err != nil {
+					logger.Log().WithFields(logrus.Fields{"node": n.ID, "err": err}).Warn("invalid routing policy ignored")
+				}
+				dev.Device().SetRoutingPolicy(routing)
 
 				if dev.Device().Name() == "" {
 					dev.Device().SetName(n.Description)
 				}
 
-				g.AddNode(dev)
+				if err := g.AddNode(dev); err != nil {
+					return err
+				}
 			}
 
 			for _, e := range gr.Edges {
@@ -133,7 +140,7 @@ func (g *Network) readGraph(filename string) error {
 	return nil
 }
 
-func (g *Network) writeGraph(filename string) error {
+func (s *NetworkSnapshot) writeGraph(filename string) error {
 	gml := graphml.NewGraphML("meshmesh network")
 
 	gml.RegisterKey(graphml.KeyForNode, "inuse", "is node in use", reflect.Bool, true)
@@ -147,6 +154,7 @@ func (g *Network) writeGraph(filename string) error {
 	gml.RegisterKey(graphml.KeyForNode, "libvers", "the mesh library version", reflect.String, "")
 	gml.RegisterKey(graphml.KeyForNode, "comptime", "the firmware compile time", reflect.String, "")
 	gml.RegisterKey(graphml.KeyForNode, "lastseen", "the node last seen time", reflect.String, "")
+	gml.RegisterKey(graphml.KeyForNode, "routing", "the routing policy of the paths to the node", reflect.String, "")
 	gml.RegisterKey(graphml.KeyForEdge, "weight", "the node firmware revision", reflect.Float32, 0.0)
 	gml.RegisterKey(graphml.KeyForEdge, "weight2", "the node firmware revision", reflect.Float32, 0.0)
 
@@ -155,7 +163,7 @@ func (g *Network) writeGraph(filename string) error {
 		return err
 	}
 
-	nodes := g.Nodes()
+	nodes := s.Nodes()
 	for nodes.Next() {
 		node := nodes.Node().(NodeDevice)
 
@@ -170,12 +178,13 @@ func (g *Network) writeGraph(filename string) error {
 			"libvers":      node.Device().LibVersion(),
 			"comptime":     formatTime(node.Device().CompileTime()),
 			"lastseen":     formatTime(node.Device().LastSeen()),
+			"routing":      node.Device().RoutingPolicy().String(),
 		}
 
 		gr.AddNode(attributes, utils.FmtNodeId(node.ID()), node.Device().Tag())
 	}
 
-	edges := g.WeightedEdges()
+	edges := s.WeightedEdges()
 	for edges.Next() {
 		edge := edges.WeightedEdge()
 		from := edge.From().(NodeDevice)
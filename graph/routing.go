@@ -0,0 +1,259 @@
+package graph
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RoutingStrategy is the way the cost of a path is measured
+type RoutingStrategy int
+
+const (
+	// RoutingDefault is the strategy of the nodes without a policy of their own, they use the default policy
+	RoutingDefault RoutingStrategy = iota
+	// RoutingCost is the sum of the costs of the links, computed from their RSSI
+	RoutingCost
+	// RoutingHops adds HopPenalty to the cost of every link, a few good hops win over many marginal ones
+	RoutingHops
+	// RoutingBottleneck uses the path with the best worst link, the sum of the costs breaks the ties
+	RoutingBottleneck
+)
+
+// DefaultHopPenalty is the cost added to every hop by RoutingHops when the policy doesn't set it
+const DefaultHopPenalty = 0.25
+
+var routingStrategyNames = []string{"default", "cost", "hops", "bottleneck"}
+
+func (s RoutingStrategy) String() string {
+	if int(s) < len(routingStrategyNames) {
+		return routingStrategyNames[s]
+	}
+	return "unknown"
+}
+
+// RoutingPolicy selects the paths from the coordinator to a node. The cost of a link goes from 0,
+// the best signal, to 1, the worst one, the quality of a link is 1 less its cost.
+type RoutingPolicy struct {
+	Strategy RoutingStrategy
+	// Cost added to every hop by RoutingHops
+	HopPenalty float64
+	// Longest path allowed, 0 for no limit
+	MaxHops int
+	// The links with a lower quality are never used, 0 uses every link
+	MinLinkQuality float64
+}
+
+// String returns the policy in the format read by ParseRoutingPolicy, empty for the default policy
+func (p RoutingPolicy) String() string {
+	if p == (RoutingPolicy{}) {
+		return ""
+	}
+	items := []string{p.Strategy.String()}
+	if p.Strategy == RoutingHops {
+		items = append(items, "penalty="+strconv.FormatFloat(p.HopPenalty, 'g', -1, 64))
+	}
+	if p.MaxHops > 0 {
+		items = append(items, "maxhops="+strconv.Itoa(p.MaxHops))
+	}
+	if p.MinLinkQuality > 0 {
+		items = append(items, "minquality="+strconv.FormatFloat(p.MinLinkQuality, 'g', -1, 64))
+	}
+	return strings.Join(items, ",")
+}
+
+// ParseRoutingPolicy reads a strategy name followed by options separated by commas, like
+// "hops,penalty=0.3,maxhops=4,minquality=0.2". An empty string is the default policy.
+func ParseRoutingPolicy(spec string) (RoutingPolicy, error) {
+	policy := RoutingPolicy{}
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return policy, nil
+	}
+
+	for i, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		key, value, found := strings.Cut(item, "=")
+		if !found {
+			strategy := slices.Index(routingStrategyNames, strings.ToLower(item))
+			if i > 0 || strategy < 0 {
+				return policy, fmt.Errorf("invalid routing strategy: %s", item)
+			}
+			policy.Strategy = RoutingStrategy(strategy)
+			continue
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "penalty":
+			policy.HopPenalty, err = strconv.ParseFloat(value, 64)
+			if err == nil && policy.HopPenalty < 0 {
+				err = fmt.Errorf("negative hop penalty")
+			}
+		case "maxhops":
+			policy.MaxHops, err = strconv.Atoi(value)
+			if err == nil && policy.MaxHops < 0 {
+				err = fmt.Errorf("negative hop limit")
+			}
+		case "minquality":
+			policy.MinLinkQuality, err = strconv.ParseFloat(value, 64)
+			if err == nil && (policy.MinLinkQuality < 0 || policy.MinLinkQuality > 1) {
+				err = fmt.Errorf("link quality out of the 0-1 range")
+			}
+		default:
+			err = fmt.Errorf("unknown option")
+		}
+		if err != nil {
+			return policy, fmt.Errorf("invalid routing option %s: %w", item, err)
+		}
+	}
+
+	if policy.Strategy == RoutingHops && policy.HopPenalty == 0 {
+		policy.HopPenalty = DefaultHopPenalty
+	}
+	return policy, nil
+}
+
+var (
+	defaultRoutingLock   sync.RWMutex
+	defaultRoutingPolicy = RoutingPolicy{Strategy: RoutingCost}
+)
+
+// SetDefaultRoutingPolicy sets the policy of the nodes without a policy of their own
+func SetDefaultRoutingPolicy(policy RoutingPolicy) {
+	defaultRoutingLock.Lock()
+	defer defaultRoutingLock.Unlock()
+	if policy.Strategy == RoutingDefault {
+		policy.Strategy = RoutingCost
+	}
+	defaultRoutingPolicy = policy
+}
+
+func DefaultRoutingPolicy() RoutingPolicy {
+	defaultRoutingLock.RLock()
+	defer defaultRoutingLock.RUnlock()
+	return defaultRoutingPolicy
+}
+
+// resolve returns the default policy in place of RoutingDefault
+func (p RoutingPolicy) resolve() RoutingPolicy {
+	if p.Strategy == RoutingDefault {
+		return DefaultRoutingPolicy()
+	}
+	return p
+}
+
+// routeLink is a link usable by a routing policy
+type routeLink struct {
+	to     int64
+	weight float64
+}
+
+// route is the best path found to a node, from the local device, with its cost
+type route struct {
+	nodes []int64
+	cost  float64
+}
+
+// routingLinks returns the links of the graph the policy can use, without the skipped ones
+func (s *NetworkSnapshot) routingLinks(policy RoutingPolicy, skip func(from, to int64) bool) map[int64][]routeLink {
+	links := make(map[int64][]routeLink)
+	edges := s.graph.WeightedEdges()
+	for edges.Next() {
+		edge := edges.WeightedEdge()
+		from, to := edge.From().ID(), edge.To().ID()
+		if 1-edge.Weight() < policy.MinLinkQuality || (skip != nil && skip(from, to)) {
+			continue
+		}
+		links[from] = append(links[from], routeLink{to: to, weight: edge.Weight()})
+	}
+	for _, l := range links {
+		slices.SortFunc(l, func(a, b routeLink) int { return cmp.Compare(a.to, b.to) })
+	}
+	return links
+}
+
+// bestRoutes returns the best route to every node reached from the source with the links
+func bestRoutes(links map[int64][]routeLink, source int64, policy RoutingPolicy) map[int64]route {
+	switch policy.Strategy {
+	case RoutingHops:
+		return layeredRoutes(links, source, policy.MaxHops, func(cost, weight float64) float64 {
+			return cost + weight + policy.HopPenalty
+		})
+	case RoutingBottleneck:
+		return bottleneckRoutes(links, source, policy.MaxHops)
+	}
+	return layeredRoutes(links, source, policy.MaxHops, func(cost, weight float64) float64 {
+		return cost + weight
+	})
+}
+
+// layeredRoutes finds the cheapest routes with at most maxHops links, 0 for no limit. Every round adds a
+// hop to the routes improved by the previous one, until no route improves or the limit is reached.
+func layeredRoutes(links map[int64][]routeLink, source int64, maxHops int, extend func(cost, weight float64) float64) map[int64]route {
+	best := map[int64]route{source: {nodes: []int64{source}}}
+	frontier := []int64{source}
+	for hop := 1; len(frontier) > 0 && (maxHops == 0 || hop <= maxHops); hop++ {
+		next := make(map[int64]route)
+		for _, from := range frontier {
+			r := best[from]
+			for _, link := range links[from] {
+				cost := extend(r.cost, link.weight)
+				if b, ok := best[link.to]; ok && b.cost <= cost {
+					continue
+				}
+				if n, ok := next[link.to]; ok && n.cost <= cost {
+					continue
+				}
+				next[link.to] = route{nodes: append(slices.Clone(r.nodes), link.to), cost: cost}
+			}
+		}
+
+		frontier = frontier[:0]
+		for id, r := range next {
+			best[id] = r
+			frontier = append(frontier, id)
+		}
+		slices.Sort(frontier)
+	}
+	return best
+}
+
+// bottleneckRoutes finds the routes with the best worst link, then the cheapest route of every node
+// among the links not worse than its worst link
+func bottleneckRoutes(links map[int64][]routeLink, source int64, maxHops int) map[int64]route {
+	widest := layeredRoutes(links, source, maxHops, math.Max)
+
+	bottlenecks := make([]float64, 0)
+	for _, r := range widest {
+		if !slices.Contains(bottlenecks, r.cost) {
+			bottlenecks = append(bottlenecks, r.cost)
+		}
+	}
+	slices.Sort(bottlenecks)
+
+	result := make(map[int64]route, len(widest))
+	for _, bottleneck := range bottlenecks {
+		narrowed := make(map[int64][]routeLink, len(links))
+		for from, l := range links {
+			for _, link := range l {
+				if link.weight <= bottleneck {
+					narrowed[from] = append(narrowed[from], link)
+				}
+			}
+		}
+		cheapest := layeredRoutes(narrowed, source, maxHops, func(cost, weight float64) float64 {
+			return cost + weight
+		})
+		for id, r := range widest {
+			if r.cost == bottleneck {
+				result[id] = route{nodes: cheapest[id].nodes, cost: bottleneck}
+			}
+		}
+	}
+	return result
+}
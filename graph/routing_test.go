@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// newRoutingNetwork builds a network from node 1 with:
+//   - a chain of six good links to node 7 and two marginal links through node 8
+//   - a weak direct link to node 9
+//   - two paths to node 12 with the same worst link, the one through node 14 is cheaper
+func newRoutingNetwork(t *testing.T) *Network {
+	t.Helper()
+	network := NewNetwork(1, NETWORK_ID_MAIN)
+	network.Update(func(tx *NetworkTx) error {
+		for id := int64(1); id < 7; id++ {
+			tx.ChangeEdgeWeight(id, id+1, 0.1, 0.1)
+		}
+		tx.ChangeEdgeWeight(1, 8, 0.35, 0.35)
+		tx.ChangeEdgeWeight(8, 7, 0.35, 0.35)
+		tx.ChangeEdgeWeight(1, 9, 0.9, 0.9)
+		tx.ChangeEdgeWeight(1, 13, 0.5, 0.5)
+		tx.ChangeEdgeWeight(13, 12, 0.4, 0.4)
+		tx.ChangeEdgeWeight(1, 14, 0.5, 0.5)
+		tx.ChangeEdgeWeight(14, 12, 0.2, 0.2)
+		return nil
+	})
+	return network
+}
+
+func TestRoutingPolicyPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		target int64
+		path   []int64
+		cost   float64
+	}{
+		{"cost uses the good links", "cost", 7, []int64{1, 2, 3, 4, 5, 6, 7}, 0.6},
+		{"hops beat marginal multi-hop paths", "hops", 7, []int64{1, 8, 7}, 1.2},
+		{"small penalty keeps the good links", "hops,penalty=0.01", 7, []int64{1, 2, 3, 4, 5, 6, 7}, 0.66},
+		{"bottleneck uses the best worst link", "bottleneck", 7, []int64{1, 2, 3, 4, 5, 6, 7}, 0.1},
+		{"bottleneck tie broken by the cost", "bottleneck", 12, []int64{1, 14, 12}, 0.5},
+		{"maxhops limits the path", "cost,maxhops=2", 7, []int64{1, 8, 7}, 0.7},
+		{"maxhops leaves no path", "cost,maxhops=1", 7, nil, 0},
+		{"minquality leaves out the marginal links", "hops,minquality=0.7", 7, []int64{1, 2, 3, 4, 5, 6, 7}, 2.1},
+		{"minquality leaves out the only link", "cost,minquality=0.2", 9, nil, 0},
+		{"weak direct link", "cost", 9, []int64{1, 9}, 0.9},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy, err := ParseRoutingPolicy(test.policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			network := newRoutingNetwork(t)
+			network.UpdateNode(test.target, func(device *Device) { device.SetRoutingPolicy(policy) })
+			target, err := network.GetNodeDevice(test.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			path, cost, err := network.GetPath(target)
+			if test.path == nil {
+				if !errors.Is(err, ErrNoRoute) {
+					t.Fatalf("got %v %v, want %v", path, err, ErrNoRoute)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(path, test.path) || math.Abs(cost-test.cost) > 1e-9 {
+				t.Errorf("got %v %.2f, want %v %.2f", path, cost, test.path, test.cost)
+			}
+		})
+	}
+}
+
+func TestRoutingPolicyString(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", ""},
+		{"cost", "cost"},
+		{"hops", "hops,penalty=0.25"},
+		{"HOPS, penalty=0.3, maxhops=4, minquality=0.2", "hops,penalty=0.3,maxhops=4,minquality=0.2"},
+		{"bottleneck,maxhops=3", "bottleneck,maxhops=3"},
+		{"cost,minquality=1", "cost,minquality=1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			policy, err := ParseRoutingPolicy(test.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if policy.String() != test.want {
+				t.Errorf("got %q, want %q", policy.String(), test.want)
+			}
+			parsed, err := ParseRoutingPolicy(policy.String())
+			if err != nil || parsed != policy {
+				t.Errorf("round trip got %+v %v, want %+v", parsed, err, policy)
+			}
+		})
+	}
+
+	for _, spec := range []string{"bogus", "cost,hops", "hops,maxhops=-1", "cost,minquality=2", "cost,penalty=x", "cost,speed=1"} {
+		if _, err := ParseRoutingPolicy(spec); err == nil {
+			t.Errorf("%q: no error", spec)
+		}
+	}
+}
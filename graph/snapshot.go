@@ -0,0 +1,240 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// NetworkSnapshot is an immutable copy of a network, it can be read by many goroutines without locks.
+// The devices of the snapshot are copies too and must not be changed.
+type NetworkSnapshot struct {
+	graph         *simple.WeightedDirectedGraph
+	localDeviceId int64
+	networkId     int
+	version       uint64
+	topology      uint64
+	paths         *shortestPaths
+}
+
+// shortestPaths are the best routes from the local device for every routing policy, computed on the
+// first lookup with the policy. They are shared by the snapshots of the same topology.
+type shortestPaths struct {
+	lock   sync.Mutex
+	routes map[RoutingPolicy]map[int64]route
+}
+
+// Snapshot returns a copy of the network, the copy is shared by the readers until the next change
+func (g *Network) Snapshot() *NetworkSnapshot {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if snapshot := g.snapshot.Load(); snapshot != nil && snapshot.version == g.version {
+		return snapshot
+	}
+	snapshot := &NetworkSnapshot{
+		graph:         copyGraph(g.graph),
+		localDeviceId: g.localDeviceId,
+		networkId:     g.networkId,
+		version:       g.version,
+		topology:      g.topology,
+	}
+	// The changes of the devices alone do not move the paths
+	if previous := g.snapshot.Load(); previous != nil && previous.topology == g.topology {
+		snapshot.paths = previous.paths
+	} else {
+		snapshot.paths = &shortestPaths{routes: make(map[RoutingPolicy]map[int64]route)}
+	}
+	g.snapshot.Store(snapshot)
+	return snapshot
+}
+
+// copyGraph copies the nodes, with a copy of their devices, and the edges of a graph
+func copyGraph(g *simple.WeightedDirectedGraph) *simple.WeightedDirectedGraph {
+	copied := simple.NewWeightedDirectedGraph(0, math.Inf(1))
+
+	nodes := g.Nodes()
+	for nodes.Next() {
+		dev := nodes.Node().(NodeDevice)
+		copied.AddNode(NodeDevice{id: dev.id, device: dev.device.clone()})
+	}
+
+	edges := g.WeightedEdges()
+	for edges.Next() {
+		edge := edges.WeightedEdge()
+		from, to := edge.From().ID(), edge.To().ID()
+		copied.SetWeightedEdge(copied.NewWeightedEdge(copied.Node(from), copied.Node(to), edge.Weight()))
+	}
+	return copied
+}
+
+func (s *NetworkSnapshot) copyGraph() *simple.WeightedDirectedGraph {
+	return copyGraph(s.graph)
+}
+
+// shortestPaths returns the best routes from the local device with the policy
+func (s *NetworkSnapshot) shortestPaths(policy RoutingPolicy) map[int64]route {
+	s.paths.lock.Lock()
+	defer s.paths.lock.Unlock()
+
+	routes, ok := s.paths.routes[policy]
+	if !ok {
+		routes = make(map[int64]route)
+		if s.graph.Node(s.localDeviceId) != nil {
+			routes = bestRoutes(s.routingLinks(policy, nil), s.localDeviceId, policy)
+		}
+		s.paths.routes[policy] = routes
+	}
+	return routes
+}
+
+// Version tells the changes of the network included in the snapshot, it grows with every transaction
+func (s *NetworkSnapshot) Version() uint64 {
+	return s.version
+}
+
+func (s *NetworkSnapshot) NetworkId() int {
+	return s.networkId
+}
+
+func (s *NetworkSnapshot) LocalDeviceId() int64 {
+	return s.localDeviceId
+}
+
+func (s *NetworkSnapshot) IsLocalDevice(node graph.Node) bool {
+	return s.localDeviceId == node.ID()
+}
+
+func (s *NetworkSnapshot) Node(id int64) graph.Node {
+	return s.graph.Node(id)
+}
+
+func (s *NetworkSnapshot) Nodes() graph.Nodes {
+	return s.graph.Nodes()
+}
+
+func (s *NetworkSnapshot) From(id int64) graph.Nodes {
+	return s.graph.From(id)
+}
+
+func (s *NetworkSnapshot) To(id int64) graph.Nodes {
+	return s.graph.To(id)
+}
+
+func (s *NetworkSnapshot) HasEdgeBetween(xid, yid int64) bool {
+	return s.graph.HasEdgeBetween(xid, yid)
+}
+
+func (s *NetworkSnapshot) HasEdgeFromTo(uid, vid int64) bool {
+	return s.graph.HasEdgeFromTo(uid, vid)
+}
+
+func (s *NetworkSnapshot) Edge(uid, vid int64) graph.Edge {
+	return s.graph.Edge(uid, vid)
+}
+
+func (s *NetworkSnapshot) WeightedEdge(uid, vid int64) graph.WeightedEdge {
+	return s.graph.WeightedEdge(uid, vid)
+}
+
+func (s *NetworkSnapshot) Weight(xid, yid int64) (float64, bool) {
+	return s.graph.Weight(xid, yid)
+}
+
+func (s *NetworkSnapshot) Edges() graph.Edges {
+	return s.graph.Edges()
+}
+
+func (s *NetworkSnapshot) WeightedEdges() graph.WeightedEdges {
+	return s.graph.WeightedEdges()
+}
+
+func (s *NetworkSnapshot) EdgesTo(nodeId int64) graph.Edges {
+	return edgesTo(s.graph, nodeId)
+}
+
+func (s *NetworkSnapshot) GetNodeDevice(id int64) (NodeDevice, error) {
+	return getNodeDevice(s.graph, id)
+}
+
+func (s *NetworkSnapshot) NodeIdExists(id int64) bool {
+	return s.graph.Node(id) != nil
+}
+
+// GetPath returns the best path from the local device to the target device, along with its cost.
+//
+// Parameters:
+//   - to: The target Device to find a path to
+//
+// Returns:
+//   - []int64: Array of node IDs representing the path from local device to target
+//   - float64: Cost of the path measured by the routing policy
+//   - error: Error if no path exists or target device is not active
+//
+// The path is chosen by the routing policy of the target device, or by the default one. The best
+// routes from the local device are computed once for every policy and reused until the nodes or
+// the links of the graph change.
+// Returns an error if:
+// - The target device is not marked as in use/active
+// - No valid path exists between the local device and target
+func (s *NetworkSnapshot) GetPath(to NodeDevice) ([]int64, float64, error) {
+	if !to.Device().InUse() {
+		return nil, 0, fmt.Errorf("%w: 0x%06X", ErrNodeInactive, to.ID())
+	}
+	r, ok := s.shortestPaths(to.Device().RoutingPolicy().resolve())[to.ID()]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w between 0x%06X and 0x%06X", ErrNoRoute, s.localDeviceId, to.ID())
+	}
+	logrus.WithFields(logrus.Fields{"length": len(r.nodes), "weight": r.cost}).
+		Debug(fmt.Sprintf("Get path from 0x%06X to 0x%06X", s.localDeviceId, to.ID()))
+
+	return slices.Clone(r.nodes), r.cost, nil
+}
+
+// NetworkPath is a path from the local device to another node with its cost
+type NetworkPath struct {
+	Nodes  []int64
+	Weight float64
+}
+
+// GetPaths returns up to k paths from the local device to the target device that do not share any
+// repeater, from the best one. The first path is the one of GetPath, every next one is the best path
+// of the routing policy without the repeaters of the previous paths, a direct link is used only once.
+func (s *NetworkSnapshot) GetPaths(to NodeDevice, k int) ([]NetworkPath, error) {
+	first, weight, err := s.GetPath(to)
+	if err != nil {
+		return nil, err
+	}
+	paths := []NetworkPath{{Nodes: first, Weight: weight}}
+	if len(first) < 2 {
+		return paths, nil
+	}
+
+	policy := to.Device().RoutingPolicy().resolve()
+	removedNodes := make(map[int64]bool)
+	removedLinks := make(map[[2]int64]bool)
+	for len(paths) < k {
+		last := paths[len(paths)-1].Nodes
+		if len(last) == 2 {
+			removedLinks[[2]int64{last[0], last[1]}] = true
+		}
+		for _, id := range last[1 : len(last)-1] {
+			removedNodes[id] = true
+		}
+
+		links := s.routingLinks(policy, func(from, to int64) bool {
+			return removedNodes[from] || removedNodes[to] || removedLinks[[2]int64{from, to}]
+		})
+		r, ok := bestRoutes(links, s.localDeviceId, policy)[to.ID()]
+		if !ok {
+			break
+		}
+		paths = append(paths, NetworkPath{Nodes: r.nodes, Weight: r.cost})
+	}
+	return paths, nil
+}
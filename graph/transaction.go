@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"fmt"
+	"slices"
+
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/simple"
+)
+
+// NetworkTx is a set of changes made to a network while holding its write lock, see Network.Update.
+// The reads of a transaction see its own changes.
+type NetworkTx struct {
+	network *Network
+	events  []NetworkEvent
+	// Tells the network changed callbacks that a backup of the network is not needed
+	NoBackup bool
+}
+
+func (tx *NetworkTx) publish(event NetworkEvent) {
+	tx.events = append(tx.events, event)
+}
+
+func (tx *NetworkTx) Node(id int64) graph.Node {
+	return tx.network.graph.Node(id)
+}
+
+// Nodes returns a copy of the nodes, the transaction can change the network during the iteration
+func (tx *NetworkTx) Nodes() graph.Nodes {
+	return orderedNodes(tx.network.graph.Nodes())
+}
+
+func (tx *NetworkTx) From(id int64) graph.Nodes {
+	return orderedNodes(tx.network.graph.From(id))
+}
+
+func (tx *NetworkTx) EdgesTo(nodeId int64) graph.Edges {
+	return edgesTo(tx.network.graph, nodeId)
+}
+
+func (tx *NetworkTx) HasEdgeFromTo(uid, vid int64) bool {
+	return tx.network.graph.HasEdgeFromTo(uid, vid)
+}
+
+func (tx *NetworkTx) WeightedEdge(uid, vid int64) graph.WeightedEdge {
+	return tx.network.graph.WeightedEdge(uid, vid)
+}
+
+func (tx *NetworkTx) GetNodeDevice(id int64) (NodeDevice, error) {
+	return getNodeDevice(tx.network.graph, id)
+}
+
+func (tx *NetworkTx) NodeIdExists(id int64) bool {
+	return tx.network.graph.Node(id) != nil
+}
+
+func (tx *NetworkTx) LocalDeviceId() int64 {
+	return tx.network.localDeviceId
+}
+
+// SetLocalDeviceId changes the node attached to the coordinator and publishes a LocalNodeChanged event
+func (tx *NetworkTx) SetLocalDeviceId(nodeId int64) {
+	previous := tx.network.localDeviceId
+	if previous == nodeId {
+		return
+	}
+	tx.network.localDeviceId = nodeId
+	tx.publish(NetworkEvent{Type: LocalNodeChanged, Node: nodeId, Previous: previous})
+}
+
+// AddNode adds a node to the network and publishes a NodeAdded event, it fails if the node exists
+func (tx *NetworkTx) AddNode(n graph.Node) error {
+	if tx.network.graph.Node(n.ID()) != nil {
+		return fmt.Errorf("%w: 0x%06X is already in the network graph", ErrNodeExists, n.ID())
+	}
+	// The device of the caller is not shared with the network
+	tx.network.graph.AddNode(detached(n))
+	tx.publish(NetworkEvent{Type: NodeAdded, Node: n.ID()})
+	return nil
+}
+
+// RemoveNode removes a node with its edges, the removal of the edges is published before the one of the node
+func (tx *NetworkTx) RemoveNode(id int64) {
+	g := tx.network.graph
+	if g.Node(id) == nil {
+		return
+	}
+
+	from := g.From(id)
+	for from.Next() {
+		to := from.Node().ID()
+		tx.publish(NetworkEvent{Type: EdgeRemoved, From: id, To: to, OldWeight: g.WeightedEdge(id, to).Weight()})
+	}
+	to := g.To(id)
+	for to.Next() {
+		from := to.Node().ID()
+		tx.publish(NetworkEvent{Type: EdgeRemoved, From: from, To: id, OldWeight: g.WeightedEdge(from, id).Weight()})
+	}
+	g.RemoveNode(id)
+	tx.publish(NetworkEvent{Type: NodeRemoved, Node: id})
+}
+
+// SetWeightedEdge adds an edge, or changes its weight, and publishes the change. The ends missing
+// from the network are added.
+func (tx *NetworkTx) SetWeightedEdge(e graph.WeightedEdge) {
+	g := tx.network.graph
+	fromId, toId := e.From().ID(), e.To().ID()
+	for _, n := range []graph.Node{e.From(), e.To()} {
+		if g.Node(n.ID()) == nil {
+			tx.AddNode(n)
+		}
+	}
+
+	if edge := g.WeightedEdge(fromId, toId); edge != nil {
+		if edge.Weight() != e.Weight() {
+			tx.publish(NetworkEvent{Type: EdgeWeightChanged, From: fromId, To: toId, Weight: e.Weight(), OldWeight: edge.Weight()})
+		}
+	} else {
+		tx.publish(NetworkEvent{Type: EdgeAdded, From: fromId, To: toId, Weight: e.Weight()})
+	}
+	// The edge refers to the nodes of the network, not to the copies given by the caller
+	g.SetWeightedEdge(g.NewWeightedEdge(g.Node(fromId), g.Node(toId), e.Weight()))
+}
+
+// RemoveEdge removes an edge and publishes an EdgeRemoved event
+func (tx *NetworkTx) RemoveEdge(fid, tid int64) {
+	edge := tx.network.graph.WeightedEdge(fid, tid)
+	if edge == nil {
+		return
+	}
+	tx.network.graph.RemoveEdge(fid, tid)
+	tx.publish(NetworkEvent{Type: EdgeRemoved, From: fid, To: tid, OldWeight: edge.Weight()})
+}
+
+// ChangeEdgeWeight sets the weight of the edge from fromId to toId, the missing nodes are added
+func (tx *NetworkTx) ChangeEdgeWeight(fromId int64, toId int64, weightFrom float64, weightTo float64) {
+	fromNode, err := tx.GetNodeDevice(fromId)
+	if err != nil {
+		fromNode = NewNodeDevice(fromId, false, "")
+		tx.AddNode(fromNode)
+	}
+
+	toNode, err := tx.GetNodeDevice(toId)
+	if err != nil {
+		toNode = NewNodeDevice(toId, true, "")
+		tx.AddNode(toNode)
+	}
+
+	tx.SetWeightedEdge(simple.WeightedEdge{F: fromNode, T: toNode, W: weightTo})
+}
+
+// UpdateNode changes the device of a node with fn and publishes a NodeUpdated event with the changed fields.
+// It is the only way to change the device of a node of the network, the reads return copies.
+func (tx *NetworkTx) UpdateNode(id int64, fn func(device *Device)) error {
+	node, err := tx.GetNodeDevice(id)
+	if err != nil {
+		return err
+	}
+
+	before := node.Device().state()
+	fn(node.Device())
+	if fields := before.changedFields(node.Device().state()); len(fields) > 0 {
+		tx.publish(NetworkEvent{Type: NodeUpdated, Node: id, Fields: fields})
+	}
+	return nil
+}
+
+// Update runs fn in a transaction. The events of the changes are published when the transaction is
+// complete, with the new version of the network, followed by a single call of the network changed
+// callbacks. The changes made before fn returns an error are kept.
+func (g *Network) Update(fn func(tx *NetworkTx) error) error {
+	g.lock.Lock()
+	tx := &NetworkTx{network: g}
+	err := fn(tx)
+	if len(tx.events) > 0 {
+		g.version++
+	}
+	if slices.ContainsFunc(tx.events, func(event NetworkEvent) bool { return event.Type != NodeUpdated }) {
+		g.topology++
+	}
+	if len(tx.events) == 0 {
+		g.lock.Unlock()
+		return err
+	}
+	version := g.version
+	eventCallbacks := slices.Clone(g.networkEventCallbacks)
+	// The next transaction waits for these events to be delivered before delivering its own
+	g.deliveryLock.Lock()
+	defer g.deliveryLock.Unlock()
+	g.lock.Unlock()
+
+	for _, event := range tx.events {
+		event.Version = version
+		for _, cb := range eventCallbacks {
+			cb(g, event)
+		}
+	}
+	g.notifyNetworkChanged(tx.NoBackup)
+	return err
+}
+
+// AddNode adds a node in a transaction of its own, it fails if the node exists
+func (g *Network) AddNode(n graph.Node) error {
+	return g.Update(func(tx *NetworkTx) error {
+		return tx.AddNode(n)
+	})
+}
+
+func (g *Network) AddNodeWithId(id int64, inuse bool, tag string, seen bool) error {
+	return g.AddNode(NewNodeDevice(id, inuse, tag))
+}
+
+// RemoveNode removes a node and its edges in a transaction of its own
+func (g *Network) RemoveNode(id int64) {
+	g.Update(func(tx *NetworkTx) error {
+		tx.RemoveNode(id)
+		return nil
+	})
+}
+
+// SetWeightedEdge adds or changes an edge in a transaction of its own
+func (g *Network) SetWeightedEdge(e graph.WeightedEdge) {
+	g.Update(func(tx *NetworkTx) error {
+		tx.SetWeightedEdge(e)
+		return nil
+	})
+}
+
+// RemoveEdge removes an edge in a transaction of its own
+func (g *Network) RemoveEdge(fid, tid int64) {
+	g.Update(func(tx *NetworkTx) error {
+		tx.RemoveEdge(fid, tid)
+		return nil
+	})
+}
+
+// ChangeEdgeWeight sets the weight of an edge in a transaction of its own
+func (g *Network) ChangeEdgeWeight(fromId int64, toId int64, weightFrom float64, weightTo float64) {
+	g.Update(func(tx *NetworkTx) error {
+		tx.ChangeEdgeWeight(fromId, toId, weightFrom, weightTo)
+		return nil
+	})
+}
+
+// UpdateNode changes the device of a node in a transaction of its own
+func (g *Network) UpdateNode(id int64, fn func(device *Device)) error {
+	return g.Update(func(tx *NetworkTx) error {
+		return tx.UpdateNode(id, fn)
+	})
+}
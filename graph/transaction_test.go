@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNetworkTxEvents(t *testing.T) {
+	network := NewNetwork(1, NETWORK_ID_MAIN)
+	var events []NetworkEvent
+	network.AddNetworkEventCallback(func(_ *Network, event NetworkEvent) {
+		events = append(events, event)
+	})
+	changed := 0
+	network.AddNetworkChangedCallback(func(*Network, bool) { changed++ })
+
+	// The steps run in order on the same network, every one in a transaction of its own
+	steps := []struct {
+		name   string
+		update func(tx *NetworkTx)
+		want   []NetworkEvent
+	}{
+		{"add node", func(tx *NetworkTx) { tx.AddNode(NewNodeDevice(2, true, "two")) },
+			[]NetworkEvent{{Type: NodeAdded, Node: 2}}},
+		{"add edge to a new node", func(tx *NetworkTx) { tx.ChangeEdgeWeight(2, 3, 0.3, 0.3) },
+			[]NetworkEvent{{Type: NodeAdded, Node: 3}, {Type: EdgeAdded, From: 2, To: 3, Weight: 0.3}}},
+		{"change edge weight", func(tx *NetworkTx) { tx.ChangeEdgeWeight(2, 3, 0.3, 0.5) },
+			[]NetworkEvent{{Type: EdgeWeightChanged, From: 2, To: 3, Weight: 0.5, OldWeight: 0.3}}},
+		{"same edge weight", func(tx *NetworkTx) { tx.ChangeEdgeWeight(2, 3, 0.5, 0.5) },
+			nil},
+		{"update node", func(tx *NetworkTx) {
+			tx.UpdateNode(2, func(device *Device) {
+				device.SetTag("deux")
+				device.SetInUse(false)
+			})
+		}, []NetworkEvent{{Type: NodeUpdated, Node: 2, Fields: []string{"inuse", "tag"}}}},
+		{"unchanged node", func(tx *NetworkTx) { tx.UpdateNode(2, func(device *Device) { device.SetTag("deux") }) },
+			nil},
+		{"change local node", func(tx *NetworkTx) { tx.SetLocalDeviceId(2) },
+			[]NetworkEvent{{Type: LocalNodeChanged, Node: 2, Previous: 1}}},
+		{"remove node", func(tx *NetworkTx) { tx.RemoveNode(2) },
+			[]NetworkEvent{{Type: EdgeRemoved, From: 2, To: 3, OldWeight: 0.5}, {Type: NodeRemoved, Node: 2}}},
+	}
+
+	version := network.Snapshot().Version()
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			events, changed = nil, 0
+			network.Update(func(tx *NetworkTx) error {
+				step.update(tx)
+				return nil
+			})
+
+			if step.want == nil {
+				if len(events) != 0 || changed != 0 {
+					t.Fatalf("got %v and %d changed calls, want none", events, changed)
+				}
+				return
+			}
+			version++
+			for i := range events {
+				if events[i].Version != version {
+					t.Errorf("event %d: version %d, want %d", i, events[i].Version, version)
+				}
+				events[i].Version = 0
+			}
+			if !reflect.DeepEqual(events, step.want) {
+				t.Errorf("got %+v, want %+v", events, step.want)
+			}
+			if changed != 1 {
+				t.Errorf("%d changed calls, want 1", changed)
+			}
+		})
+	}
+}
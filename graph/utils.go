@@ -17,7 +17,7 @@ func FmtDeviceIdHass(device NodeDevice) string {
 	return utils.FmtNodeIdHass(device.ID())
 }
 
-func FmtNodePath(network *Network, device NodeDevice) string {
+func FmtNodePath(network *NetworkSnapshot, device NodeDevice) string {
 	var _path string
 	path, _, err := network.GetPath(device)
 	if err == nil {
@@ -26,7 +26,8 @@ func FmtNodePath(network *Network, device NodeDevice) string {
 	return _path
 }
 
-func PrintTable(network *Network) {
+func PrintTable(live *Network) {
+	network := live.Snapshot()
 	if !network.NodeIdExists(network.localDeviceId) {
 		logger.WithField("node", utils.FmtNodeId(network.localDeviceId)).Fatal("Local node does not exists in grpah")
 	}
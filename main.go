@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"os"
 	"os/signal"
 	"runtime/debug"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -62,7 +66,8 @@ func getBuildInfo() {
 	}
 }
 
-func initSerialPort(config *config.Config) *meshmesh.SerialConnection {
+// initSerialPort opens the port of a coordinator, the port detection is used only by the default coordinator
+func initSerialPort(config *config.Config, port config.CoordinatorConfig, capture *meshmesh.FrameCapture, recorder *meshmesh.StreamRecorder) *meshmesh.SerialConnection {
 	var err error
 	var serialPort *meshmesh.SerialConnection = nil
 
@@ -74,9 +79,23 @@ func initSerialPort(config *config.Config) *meshmesh.SerialConnection {
 
 		if time.Since(lastStart) > 5*time.Second {
 			lastStart = time.Now()
-			serialPort, err = meshmesh.NewSerial(config.SerialPortName, config.SerialPortBaudRate, config.SerialIsEsp8266, config.SerialResetOnInit, false)
+			portName := port.SerialPortName
+			if portName == meshmesh.AutoPortName {
+				portName, err = meshmesh.DetectCoordinatorPort(port.SerialPortBaudRate, config.SerialPortDetected)
+				if err != nil {
+					logger.WithFields(logger.Fields{"error": err}).Warn("Coordinator port detection failed")
+					continue
+				}
+				if portName != config.SerialPortDetected {
+					config.SerialPortDetected = portName
+					if err := config.Persist("SerialPortDetected", portName); err != nil {
+						logger.WithFields(logger.Fields{"error": err}).Warn("Can't save the detected port in the config file")
+					}
+				}
+			}
+			serialPort, err = meshmesh.NewSerial(portName, port.SerialPortBaudRate, port.SerialIsEsp8266, port.SerialResetOnInit, false, capture, recorder)
 			if err != nil {
-				logger.WithFields(logger.Fields{"error": err}).Warn("Serial port error: ")
+				logger.WithFields(logger.Fields{"coordinator": port.Name, "error": err}).Warn("Serial port error: ")
 			} else {
 				if serialPort.IsConnected() {
 					break
@@ -119,42 +138,52 @@ func initConfig() *config.Config {
 	return c
 }
 
-func mainNetworkChangedCallback(network *gra.Network, noBackup bool) {
-	//setupMdns()
-	if !noBackup {
-		network.SaveToFile(graphFilename)
+// coordinatorGraphFilenames returns the files of the main and star path graphs of a coordinator
+func coordinatorGraphFilenames(name string) (string, string) {
+	if name == config.DefaultCoordinatorName {
+		return graphFilename, starPathGraphFilename
 	}
+	return "meshmesh-" + name + ".graphml", "starpath-" + name + ".graphml"
 }
 
-func starPathNetworkChangedCallback(network *gra.Network, noBackup bool) {
-	if !noBackup {
-		network.SaveToFile(starPathGraphFilename)
-	}
+// addNetworkSaver logs the changes of the network and saves the graph in the file when a set of
+// changes is complete, the file is not written again if nothing changed since the last save
+func addNetworkSaver(network *gra.Network, filename string) {
+	var dirty atomic.Bool
+	network.AddNetworkEventCallback(func(network *gra.Network, event gra.NetworkEvent) {
+		logger.WithFields(logger.Fields{"network": network.NetworkId(), "version": event.Version, "event": event.String()}).Debug("Network changed")
+		dirty.Store(true)
+	})
+	network.AddNetworkChangedCallback(func(network *gra.Network, noBackup bool) {
+		if !noBackup && dirty.Swap(false) {
+			network.SaveToFile(filename)
+		}
+	})
 }
 
-func initNetwork(localNodeId int64) *gra.Network {
+func initNetwork(filename string, localNodeId int64) *gra.Network {
 	var network *gra.Network
-	if _, err := os.Stat(graphFilename); err == nil {
-		network, err = gra.NewNeworkFromFile(graphFilename, localNodeId, gra.NETWORK_ID_MAIN)
+	if _, err := os.Stat(filename); err == nil {
+		network, err = gra.NewNeworkFromFile(filename, localNodeId, gra.NETWORK_ID_MAIN)
 		if err != nil {
 			logger.Log().Fatal("Graph read error: ", err)
 		}
 	} else {
 		network = gra.NewNetwork(localNodeId, gra.NETWORK_ID_MAIN)
-		network.SaveToFile(graphFilename)
+		network.SaveToFile(filename)
 	}
 	return network
 }
 
 /* Initialize debug node TODO not implemented yet */
-func initDebugNode(config *config.Config) {
+func initDebugNode(config *config.Config, coordinators *meshmesh.Coordinators) {
 	if len(config.DebugNodeAddr) > 0 {
 		_debugNodeId, err := utils.ParseNodeId(config.DebugNodeAddr)
 		if err != nil {
 			logger.WithField("err", err).Fatal("Invalid debug node id")
 			return
 		}
-		debugNodeId, err = gra.GetMainNetwork().GetNodeDevice(_debugNodeId)
+		debugNodeId, err = coordinators.ForNode(meshmesh.MeshNodeId(_debugNodeId)).Network().GetNodeDevice(_debugNodeId)
 		if err != nil {
 			logger.WithField("id", utils.FmtNodeId(_debugNodeId)).Fatal("Debug node not found in graph")
 			return
@@ -163,31 +192,170 @@ func initDebugNode(config *config.Config) {
 	}
 }
 
-func handleDiscAssociateReply(v *meshmesh.DiscAssociateApiReply, serialPort *meshmesh.SerialConnection) {
-	network := gra.GetMainNetwork()
+func handleDiscAssociateReply(v *meshmesh.DiscAssociateApiReply, coordinator *meshmesh.Coordinator) {
+	network := coordinator.Network()
 	logger.WithFields(logger.Fields{"server": utils.FmtNodeId(int64(v.Server)), "source": utils.FmtNodeId(int64(v.Source))}).Debug("DiscAssociateReply received")
-	source, err := network.GetNodeDevice(int64(v.Source))
+	var source gra.NodeDevice
+	// The network is saved by its changed callback at the end of the transaction
+	network.Update(func(tx *gra.NetworkTx) error {
+		var err error
+		source, err = tx.GetNodeDevice(int64(v.Source))
+		if err != nil {
+			source = gra.NewNodeDevice(int64(v.Source), true, "")
+			tx.AddNode(source)
+		}
+		for i := range 3 {
+			if v.NodeId[i] > 0 {
+				node, err := tx.GetNodeDevice(int64(v.NodeId[i]))
+				if err != nil {
+					tx.ChangeEdgeWeight(node.ID(), source.ID(), meshmesh.Rssi2weight(v.Rssi[i]), meshmesh.Rssi2weight(v.Rssi[i]))
+					logger.WithFields(logger.Fields{"id": utils.FmtNodeId(int64(v.NodeId[i])), "rssi": v.Rssi[i]}).Debug("DiscAssociateReply received")
+				}
+			}
+		}
+		return nil
+	})
+	ctx := meshmesh.WithTrafficClass(context.Background(), meshmesh.BackgroundTraffic)
+	coordinator.Serial().SendReceiveApiProtContext(ctx, meshmesh.NodeIdApiRequest{}, meshmesh.UnicastProtocol, meshmesh.MeshNodeId(source.ID()), nil)
+	// ***** TODO: Update network graph with new node
+}
+
+// setPortCallbacks binds the events of a port of the coordinator, they are handled only while the port is the active one
+func setPortCallbacks(coordinator *meshmesh.Coordinator, serialPort *meshmesh.SerialConnection) {
+	/* Serial coordinator node id changed callback */
+	serialPort.SetLocalNodeIdChangedCb(func(meshNodeId meshmesh.MeshNodeId, nodeInfo *pb.NodeInfo) {
+		if coordinator.IsActive(serialPort) {
+			coordinator.Network().LocalDeviceIdChanged(int64(meshNodeId), nodeInfo)
+		}
+	})
+	// Handle DiscAssociateReply received from other nodes
+	serialPort.DiscAssociateFn = func(v *meshmesh.DiscAssociateApiReply, _ *meshmesh.SerialConnection) {
+		if coordinator.IsActive(serialPort) {
+			handleDiscAssociateReply(v, coordinator)
+		}
+	}
+}
+
+// startWatchdog starts the watchdog of a port, it returns nil when the watchdog is disabled
+func startWatchdog(config *config.Config, serialPort *meshmesh.SerialConnection) *meshmesh.CoordinatorWatchdog {
+	if config.WatchdogInterval <= 0 {
+		return nil
+	}
+	watchdog := meshmesh.NewCoordinatorWatchdog(serialPort, time.Duration(config.WatchdogInterval)*time.Second)
+	watchdog.Start()
+	return watchdog
+}
+
+// initStandbyPort opens the standby port of a coordinator. A standby coordinator that doesn't answer
+// is not fatal, the port is kept and opened again by its watchdog.
+func initStandbyPort(config *config.Config, port config.CoordinatorConfig, coordinator *meshmesh.Coordinator, budgets map[meshmesh.TrafficClass]int) {
+	if port.StandbyPortName == meshmesh.AutoPortName {
+		logger.WithField("coordinator", port.Name).Fatal("The port detection can't be used by the standby port")
+	}
+
+	logger.WithFields(logger.Fields{"coordinator": port.Name, "portName": port.StandbyPortName}).Debug("Opening standby port")
+	serialPort, err := meshmesh.NewSerial(port.StandbyPortName, port.SerialPortBaudRate, port.SerialIsEsp8266, port.SerialResetOnInit, false, nil, nil)
 	if err != nil {
-		source = gra.NewNodeDevice(int64(v.Source), true, "")
-		network.AddNode(source)
+		logger.WithFields(logger.Fields{"coordinator": port.Name, "error": err}).Warn("Standby port error: ")
 	}
-	for i := range 3 {
-		if v.NodeId[i] > 0 {
-			node, err := network.GetNodeDevice(int64(v.NodeId[i]))
-			if err != nil {
-				network.ChangeEdgeWeight(node.ID(), source.ID(), meshmesh.Rssi2weight(v.Rssi[i]), meshmesh.Rssi2weight(v.Rssi[i]))
-				logger.WithFields(logger.Fields{"id": utils.FmtNodeId(int64(v.NodeId[i])), "rssi": v.Rssi[i]}).Debug("DiscAssociateReply received")
-			}
+
+	serialPort.EnablePoliteBroadcast(config.PoliteBroadcast)
+	serialPort.SetAirtimeBudgets(budgets)
+	setPortCallbacks(coordinator, serialPort)
+	coordinator.SetStandby(serialPort, startWatchdog(config, serialPort))
+}
+
+// initCoordinator opens the port of a coordinator and loads its main and star path networks
+func initCoordinator(config *config.Config, port config.CoordinatorConfig, capture *meshmesh.FrameCapture, recorder *meshmesh.StreamRecorder, budgets map[meshmesh.TrafficClass]int) *meshmesh.Coordinator {
+	logger.WithFields(logger.Fields{"coordinator": port.Name, "portName": port.SerialPortName, "baudRate": port.SerialPortBaudRate}).Debug("Opening serial port")
+
+	serialPort := initSerialPort(config, port, capture, recorder)
+	if serialPort == nil {
+		logger.WithField("coordinator", port.Name).Fatal("Failed to initialize serial port")
+		return nil
+	}
+
+	serialPort.EnablePoliteBroadcast(config.PoliteBroadcast)
+	serialPort.SetAirtimeBudgets(budgets)
+
+	// Init main network graph
+	graphFile, starPathGraphFile := coordinatorGraphFilenames(port.Name)
+	coordinator := meshmesh.NewCoordinator(port.Name, serialPort, initNetwork(graphFile, int64(serialPort.LocalNode.Load())))
+	addNetworkSaver(coordinator.Network(), graphFile)
+	// Init star path network grpah
+	coordinator.StarPath = meshmesh.NewStarPath(serialPort, starPathGraphFile)
+	addNetworkSaver(coordinator.StarNetwork(), starPathGraphFile)
+
+	setPortCallbacks(coordinator, serialPort)
+
+	coordinator.ConnectedPath = meshmesh.NewConnectedPath2Serial(serialPort)
+	// Initialize Esphome to HomeAssistant Server
+	coordinator.Servers = meshmesh.NewMultiSocketServer(coordinator, meshmesh.ServerApiConfig{
+		BindAddress:     config.BindAddress,
+		BindPort:        config.BindPort,
+		BasePortOffset:  config.BasePortOffset,
+		SizeOfPortsPool: config.SizeOfPortsPool,
+	})
+
+	// Start the coordinator watchdog, it takes care of reopening the port
+	coordinator.SetWatchdog(startWatchdog(config, serialPort))
+
+	if port.StandbyPortName != "" {
+		initStandbyPort(config, port, coordinator, budgets)
+	}
+
+	gra.PrintTable(coordinator.Network())
+	return coordinator
+}
+
+// runRelay serves the coordinator port to a hub running on another machine until the program is terminated
+func runRelay(config *config.Config) {
+	var tlsConfig *tls.Config
+	if config.RelayTlsCert != "" || config.RelayTlsKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.RelayTlsCert, config.RelayTlsKey)
+		if err != nil {
+			logger.WithError(err).Fatal("Can't load the relay certificate")
 		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
 	}
-	network.SaveToFile(graphFilename)
-	serialPort.SendReceiveApiProt(meshmesh.NodeIdApiRequest{}, meshmesh.UnicastProtocol, meshmesh.MeshNodeId(source.ID()), nil)
-	// ***** TODO: Update network graph with new node
+	if config.RelaySecret == "" {
+		logger.Log().Fatal("The relay needs a secret, set it with --relay_secret")
+	}
+
+	portName := config.SerialPortName
+	if portName == meshmesh.AutoPortName {
+		var err error
+		portName, err = meshmesh.DetectCoordinatorPort(config.SerialPortBaudRate, config.SerialPortDetected)
+		if err != nil {
+			logger.WithError(err).Fatal("Coordinator port detection failed")
+		}
+	}
+
+	relay := meshmesh.NewRelayServer(portName, config.SerialPortBaudRate, config.RelaySecret)
+	if err := relay.Start(config.RelayBindAddress, tlsConfig); err != nil {
+		logger.WithError(err).Fatal("Can't start the relay")
+	}
+	for !quitProgram {
+		time.Sleep(1 * time.Second)
+	}
+	relay.Stop()
 }
 
-/* Serial coordinator node id changed callback */
-func localNodeIdChangedCallback(meshNodeId meshmesh.MeshNodeId, nodeInfo *pb.NodeInfo) {
-	gra.GetMainNetwork().LocalDeviceIdChanged(int64(meshNodeId), nodeInfo)
+// relayClientConfig returns the credentials used by the coordinator ports served by a relay
+func relayClientConfig(config *config.Config) meshmesh.RelayClientConfig {
+	relayConfig := meshmesh.RelayClientConfig{Secret: config.RelaySecret}
+	if config.RelayTlsCa != "" {
+		pem, err := os.ReadFile(config.RelayTlsCa)
+		if err != nil {
+			logger.WithError(err).Fatal("Can't read the relay certificate")
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			logger.WithField("file", config.RelayTlsCa).Fatal("Invalid relay certificate")
+		}
+		relayConfig.TLS = &tls.Config{RootCAs: roots}
+	}
+	return relayConfig
 }
 
 // @title           Meshmesh API
@@ -217,50 +385,96 @@ func main() {
 		os.Chdir(config.DataFolder)
 	}
 
-	logger.WithFields(logger.Fields{"portName": config.SerialPortName, "baudRate": config.SerialPortBaudRate}).Debug("Opening serial port")
-
-	serialPort := initSerialPort(config)
-	if serialPort == nil {
-		logger.Fatal("Failed to initialize serial port")
+	if config.RelayMode {
+		runRelay(config)
 		return
 	}
+	meshmesh.SetRelayClientConfig(relayClientConfig(config))
 
-	serialPort.SetLocalNodeIdChangedCb(localNodeIdChangedCallback)
+	if config.SimulateTopology != "" {
+		config.SerialPortName = "sim://" + config.SimulateTopology
+	}
+	if config.ReplayFile != "" {
+		config.SerialPortName = "replay://" + config.ReplayFile
+	}
 
-	// Init main network graph
-	gra.SetMainNetwork(initNetwork(int64(serialPort.LocalNode)))
-	gra.GetMainNetwork().AddNetworkChangedCallback(mainNetworkChangedCallback)
-	// Init star path network grpah
-	starPath := meshmesh.NewStarPath(serialPort, starPathGraphFilename)
-	starPath.GetNetwork().AddNetworkChangedCallback(starPathNetworkChangedCallback)
+	ports, err := config.AllCoordinators()
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid coordinators: ")
+	}
+
+	budgets, err := meshmesh.ParseAirtimeBudgets(config.AirtimeBudgets)
+	if err != nil {
+		logger.WithFields(logger.Fields{"budgets": config.AirtimeBudgets, "error": err}).Fatal("Invalid airtime budgets")
+	}
+
+	routing, err := gra.ParseRoutingPolicy(config.RoutingPolicy)
+	if err != nil {
+		logger.WithFields(logger.Fields{"routing": config.RoutingPolicy, "error": err}).Fatal("Invalid routing policy")
+	}
+	gra.SetDefaultRoutingPolicy(routing)
+
+	failback, err := meshmesh.ParseFailbackPolicy(config.FailbackPolicy)
+	if err != nil {
+		logger.WithError(err).Fatal("Invalid failback policy: ")
+	}
+
+	// The capture and the recording are made only on the port of the default coordinator
+	var capture *meshmesh.FrameCapture
+	if config.CaptureFile != "" {
+		capture, err = meshmesh.NewFrameCapture(config.CaptureFile)
+		if err != nil {
+			logger.WithFields(logger.Fields{"file": config.CaptureFile, "error": err}).Fatal("Can't create capture file")
+		}
+		// Closed after the coordinators are stopped
+		defer capture.Close()
+	}
+
+	var recorder *meshmesh.StreamRecorder
+	if config.RecordFile != "" {
+		recorder, err = meshmesh.NewStreamRecorder(config.RecordFile)
+		if err != nil {
+			logger.WithFields(logger.Fields{"file": config.RecordFile, "error": err}).Fatal("Can't create record file")
+		}
+		defer recorder.Close()
+	}
+
+	coordinatorsList := make([]*meshmesh.Coordinator, 0, len(ports))
+	starNetworks := make([]*gra.Network, 0, len(ports))
+	for i, port := range ports {
+		if i > 0 {
+			if port.SerialPortName == meshmesh.AutoPortName {
+				logger.WithField("coordinator", port.Name).Fatal("The port detection can be used only by the default coordinator")
+			}
+			capture, recorder = nil, nil
+		}
+		coordinator := initCoordinator(config, port, capture, recorder, budgets)
+		if coordinator == nil {
+			return
+		}
+		defer coordinator.Stop()
+		if port.StandbyPortName != "" {
+			coordinator.StartFailover(failback, time.Duration(config.FailbackDelay)*time.Second)
+		}
+		coordinatorsList = append(coordinatorsList, coordinator)
+		starNetworks = append(starNetworks, coordinator.StarNetwork())
+	}
+	coordinators := meshmesh.NewCoordinators(coordinatorsList...)
 
 	// Zeroconf responder setup
 	zeroconf := NewZeroconfResponder()
-	zeroconf.Start(starPath.GetNetwork())
+	zeroconf.Start(starNetworks...)
 
 	// Init node for spcific debug
-	initDebugNode(config)
-	gra.PrintTable(gra.GetMainNetwork())
-	// Handle DiscAssociateReply received from other nodes
-	serialPort.DiscAssociateFn = handleDiscAssociateReply
-
-	connectedPath2Serial := meshmesh.NewConnectedPath2Serial(serialPort)
-	// Initialize Esphome to HomeAssistant Server
-	multiSocketServer := meshmesh.NewMultiSocketServer(connectedPath2Serial, meshmesh.ServerApiConfig{
-		BindAddress:     config.BindAddress,
-		BindPort:        config.BindPort,
-		BasePortOffset:  config.BasePortOffset,
-		SizeOfPortsPool: config.SizeOfPortsPool,
-	})
-	multiSocketServer.StarPathProtocol(starPath)
+	initDebugNode(config, coordinators)
 
 	// Start RPC Server
 	rpcServer := rpc.NewRpcServer(config.RpcBindAddress)
-	rpcServer.Start(fmt.Sprintf("%s - %s", programName, programDescription), fmt.Sprintf("%s - %s", vcsHash, vcsTime.Format(time.RFC3339)), serialPort)
+	rpcServer.Start(fmt.Sprintf("%s - %s", programName, programDescription), fmt.Sprintf("%s - %s", vcsHash, vcsTime.Format(time.RFC3339)), coordinators)
 	defer rpcServer.Stop()
 
 	// Start rest server
-	restHandler := rest.NewHandler(serialPort, multiSocketServer, starPath)
+	restHandler := rest.NewHandler(coordinators)
 	rest.SetHelloResponseData(programName, programDescription, programRevision)
 	rest.StartRestServer(rest.NewRouter(restHandler), config.RestBindAddress)
 
@@ -270,17 +484,29 @@ func main() {
 		if quitProgram {
 			break
 		}
-		if !serialPort.IsConnected() {
-			if config.SerialShouldRetry {
-				serialPort.TryReconnect()
-			} else {
-				break
+		for _, coordinator := range coordinators.All() {
+			// The hub can go on while the standby port is open
+			connected := false
+			for _, port := range coordinator.AllPorts() {
+				if port.Serial.IsConnected() {
+					connected = true
+				} else if config.SerialShouldRetry && port.Watchdog == nil {
+					port.Serial.TryReconnect()
+				}
+			}
+			if !connected && !config.SerialShouldRetry {
+				quitProgram = true
 			}
 		}
+		if quitProgram {
+			break
+		}
 		if time.Since(lastStatsTime) > 1*time.Minute {
 			lastStatsTime = time.Now()
 			//if (len(as.Connections)> 0 ) {  //
-			multiSocketServer.PrintStats()
+			for _, coordinator := range coordinators.All() {
+				coordinator.Servers.PrintStats()
+			}
 			//}
 		}
 	}
@@ -22,6 +22,11 @@ func (client *ApiConnection) Socket2Serial(buffer *bytes.Buffer, connectedPath *
 	buffer.Reset()
 }
 
+// TrafficClass returns the interactive class, the ESPHome API connections are served first
+func (client *ApiConnection) TrafficClass() TrafficClass {
+	return InteractiveTraffic
+}
+
 func NewApiConnection() *ApiConnection {
 	return &ApiConnection{}
 }
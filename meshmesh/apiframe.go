@@ -5,22 +5,23 @@ import (
 	"errors"
 	"fmt"
 
-	"github.com/go-restruct/restruct"
-	"google.golang.org/protobuf/proto"
 	"leguru.net/m/v2/graph"
-	pb "leguru.net/m/v2/meshmesh/pb"
 )
 
 type MeshNodeId uint32
 
 type MeshProtocol byte
 
+// BroadcastAddress is the target of the requests sent with the broadcast protocol
+const BroadcastAddress MeshNodeId = 0xFFFFFFFF
+
 const (
 	AutoProtocol MeshProtocol = iota
 	DirectProtocol
 	BradcastProtocol
 	UnicastProtocol
 	MultipathProtocol
+	PoliteBroadcastProtocol
 )
 
 const (
@@ -261,7 +262,14 @@ type UnicastRequest struct {
 	Payload []byte     `struct:"[]byte"`
 }
 
-//const connectedUnicastReply uint8 = 115
+const connectedUnicastReply uint8 = 115
+
+// UnicastReply wraps the reply of a remote node with its address
+type UnicastReply struct {
+	Id      uint8      `struct:"uint8"`
+	Source  MeshNodeId `struct:"uint32"`
+	Payload []byte     `struct:"[]byte"`
+}
 
 const multipathRequest uint8 = 118
 
@@ -273,6 +281,49 @@ type MultiPathRequest struct {
 	Payload []byte     `struct:"[]byte"`
 }
 
+const multipathReply uint8 = 119
+
+// MultiPathReply wraps the reply of a remote node reached with a multipath request
+type MultiPathReply struct {
+	Id      uint8      `struct:"uint8"`
+	Source  MeshNodeId `struct:"uint32"`
+	Payload []byte     `struct:"[]byte"`
+}
+
+const broadcastRequest uint8 = 112
+
+// BroadcastRequest sends the payload to all the nodes in radio range of the coordinator
+type BroadcastRequest struct {
+	Id      uint8  `struct:"uint8"`
+	Payload []byte `struct:"[]byte"`
+}
+
+const broadcastReply uint8 = 113
+
+// BroadcastReply wraps the reply of a node to a broadcast request with its address
+type BroadcastReply struct {
+	Id      uint8      `struct:"uint8"`
+	Source  MeshNodeId `struct:"uint32"`
+	Payload []byte     `struct:"[]byte"`
+}
+
+const politeBroadcastRequest uint8 = 120
+
+// PoliteBroadcastRequest floods the payload to all the nodes of the network
+type PoliteBroadcastRequest struct {
+	Id      uint8  `struct:"uint8"`
+	Payload []byte `struct:"[]byte"`
+}
+
+const politeBroadcastReply uint8 = 121
+
+// PoliteBroadcastReply wraps the reply of a node to a polite broadcast request with its address
+type PoliteBroadcastReply struct {
+	Id      uint8      `struct:"uint8"`
+	Source  MeshNodeId `struct:"uint32"`
+	Payload []byte     `struct:"[]byte"`
+}
+
 const meshmeshProtocolConnectedPath uint8 = 7
 
 const connectedPathApiRequest uint8 = 122
@@ -465,37 +516,34 @@ type ApiFrame struct {
 }
 
 func (frame *ApiFrame) awaitedReplyBytes(index uint16) (uint8, uint8, error) {
-	var wantType uint8 = frame.data[index]&0xFE + 1
-	var wantSubtype uint8 = 0
-
-	switch wantType {
-	case discoveryApiReply:
-		wantSubtype = frame.data[index+1]&0xFE + 1
-	case flashOperationApiReply:
-		wantSubtype = frame.data[index+1]&0xFE + 1
-	}
-
+	wantType, wantSubtype := registry.awaitedReply(frame.data[index:])
 	return wantType, wantSubtype, nil
 }
 
 func (frame *ApiFrame) AwaitedReply() (uint8, uint8, error) {
 	if len(frame.data) == 0 {
-		return 0, 0, errors.New("can't send an empty frame")
+		return 0, 0, fmt.Errorf("%w: can't send an empty frame", ErrInvalidRequest)
 	} else {
 		switch frame.data[0] {
+		case broadcastRequest, politeBroadcastRequest:
+			if len(frame.data) < 2 {
+				return 0, 0, fmt.Errorf("%w: invalid broadcast frame", ErrInvalidRequest)
+			} else {
+				return frame.awaitedReplyBytes(1)
+			}
 		case connectedUnicastRequest:
 			if len(frame.data) < 6 {
-				return 0, 0, errors.New("invalid unicast frame")
+				return 0, 0, fmt.Errorf("%w: invalid unicast frame", ErrInvalidRequest)
 			} else {
 				return frame.awaitedReplyBytes(5)
 			}
 		case multipathRequest:
 			if len(frame.data) < 6 {
-				return 0, 0, errors.New("invalid multipath frame")
+				return 0, 0, fmt.Errorf("%w: invalid multipath frame", ErrInvalidRequest)
 			} else {
 				pathLen := frame.data[5]
 				if len(frame.data) < 6+4*int(pathLen) {
-					return 0, 0, errors.New("invalid multipath frame")
+					return 0, 0, fmt.Errorf("%w: invalid multipath frame", ErrInvalidRequest)
 				} else {
 					return frame.awaitedReplyBytes(6 + 4*uint16(pathLen))
 				}
@@ -505,6 +553,59 @@ func (frame *ApiFrame) AwaitedReply() (uint8, uint8, error) {
 		}
 	}
 }
+
+// Target returns the mesh node addressed by an unicast or multipath frame, BroadcastAddress for a broadcast
+// or polite broadcast frame and 0 when the frame is for the local node.
+func (frame *ApiFrame) Target() MeshNodeId {
+	if len(frame.data) > 0 && (frame.data[0] == broadcastRequest || frame.data[0] == politeBroadcastRequest) {
+		return BroadcastAddress
+	}
+	if len(frame.data) < 5 {
+		return 0
+	}
+
+	switch frame.data[0] {
+	case connectedUnicastRequest, multipathRequest:
+		return MeshNodeId(binary.LittleEndian.Uint32(frame.data[1:5]))
+	default:
+		return 0
+	}
+}
+
+// Hops returns the number of radio hops needed to deliver the frame, 0 when it is handled by the coordinator
+func (frame *ApiFrame) Hops() int {
+	if len(frame.data) == 0 {
+		return 0
+	}
+
+	switch frame.data[0] {
+	case connectedUnicastRequest, broadcastRequest, politeBroadcastRequest:
+		return 1
+	case multipathRequest:
+		if len(frame.data) > 5 {
+			return int(frame.data[5]) + 1
+		}
+	}
+	return 0
+}
+
+// ReplySource returns the node that originated a reply and the reply itself without the envelope.
+// The source is 0 when the reply doesn't tell who sent it.
+func (frame *ApiFrame) ReplySource() (MeshNodeId, *ApiFrame) {
+	if len(frame.data) < 5 {
+		return 0, frame
+	}
+
+	switch frame.data[0] {
+	case connectedUnicastReply, multipathReply, broadcastReply, politeBroadcastReply:
+		return MeshNodeId(binary.LittleEndian.Uint32(frame.data[1:5])), NewApiFrame(frame.data[5:], frame.escaped)
+	case nodeIdApiReply:
+		return MeshNodeId(binary.LittleEndian.Uint32(frame.data[1:5])), frame
+	default:
+		return 0, frame
+	}
+}
+
 func (frame *ApiFrame) AssertType(wantedType uint8, wantedSubtype uint8) bool {
 	if len(frame.data) == 0 || (frame.data[0] != wantedType) || (wantedSubtype > 0 && (len(frame.data) < 2 || frame.data[1] != wantedSubtype)) {
 		return false
@@ -553,237 +654,26 @@ func (frame *ApiFrame) Output() []byte {
 	return out
 }
 
+// Decode returns the struct of the frame registered with RegisterReply, the frames
+// without a registered type are returned as RawFrame
 func (frame *ApiFrame) Decode() (any, error) {
 	if !frame.escaped {
 		frame.Escape()
 	}
 
-	switch frame.data[0] {
-	case echoApiReply:
-		v := EchoApiReply{Id: 0, Echo: string(frame.data[1:])}
-		return v, nil
-	case firmRevApiReply:
-		v := FirmRevApiReply{Id: 0, Revision: frame.data[1:]}
-		return v, nil
-	case nodeIdApiReply:
-		v := NodeIdApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case nodeGetTagApiReply:
-		v := NodeGetTagApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case nodeSetTagApiReply:
-		v := NodeSetTagApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case nodeBindClearApiReply:
-		v := NodeBindClearApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case nodeSetChannelApiReply:
-		v := NodeSetChannelApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case nodeConfigApiReply:
-		v := NodeConfigApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case protoNodeInfoApiReply:
-		v := pb.NodeInfo{}
-		err := proto.Unmarshal(frame.data[1:], &v)
-		if err != nil {
-			return nil, err
-		}
-		return &v, nil
-	case nodeRebootApiReply:
-		v := NodeRebootApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case entitiesCountApiReply:
-		v := EntitiesCountApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case entityHashApiReply:
-		v := EntityHashApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case getEntityStateApiReply:
-		v := GetEntityStateApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case setEntityStateApiReply:
-		v := SetEntityStateApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		return v, nil
-	case logEventApiReply:
-		v := LogEventApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		if len(frame.data) > 7 {
-			v.Line = string(frame.data[7:])
-		}
-		return v, nil
-	case protoPresentationRxApiReply:
-		v := pb.NodePresentationRx{}
-		err := proto.Unmarshal(frame.data[1:], &v)
-		if err != nil {
-			return nil, err
-		}
-		return &v, nil
-	case connectedPathApiReply:
-		v := ConnectedPathApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		if len(frame.data) > 4 {
-			v.Data = frame.data[4:]
-		}
-		return v, nil
-	case discoveryApiReply:
-		v := DiscoveryApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		switch v.ApiId {
-		case discResetTableApiReply:
-			vv := DiscResetTableApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		case discTableSizeApiReply:
-			vv := DiscTableSizeApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		case discTableItemGetApiReply:
-			vv := DiscTableItemGetApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		case discStartDiscoverApiReply:
-			vv := DiscStartDiscoverApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		case discAssociateApiReply:
-			vv := DiscAssociateApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		}
-	case flashOperationApiReply:
-		v := FlashOperationApiReply{}
-		restruct.Unpack(frame.data, binary.LittleEndian, &v)
-		switch v.ApiId {
-		case flashGetMd5Api:
-			vv := FlashGetMd5ApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		case flashEraseApi:
-			vv := FlashEraseApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		case flashWriteApi:
-			vv := FlashWriteApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		case flashEBootApiRequest:
-			vv := FlashEBootApiReply{}
-			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-			return vv, nil
-		}
+	if len(frame.data) == 0 {
+		return nil, fmt.Errorf("%w: empty frame", ErrDecode)
 	}
-
-	return nil, fmt.Errorf("unknow api frame: %d", frame.data[0])
+	v, err := registry.decode(frame.data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+	return v, nil
 }
 
+// EncodeBuffer packs a request struct registered with RegisterRequest or a RawFrame
 func EncodeBuffer(cmd interface{}) ([]byte, error) {
-	var b []byte
-	var err error
-
-	switch v := cmd.(type) {
-	case EchoApiRequest:
-		v.Id = echoApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case FirmRevApiRequest:
-		v.Id = firmRevApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case NodeIdApiRequest:
-		v.Id = nodeIdApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case NodeGetTagApiRequest:
-		v.Id = nodeGetTagApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case NodeSetTagApiRequest:
-		v.Id = nodeSetTagApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case NodeBindClearApiRequest:
-		v.Id = nodeBindClearApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case NodeSetChannelApiRequest:
-		v.Id = nodeSetChannelApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case NodeConfigApiRequest:
-		v.Id = nodeConfigApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case ProtoNodeInfoApiRequest:
-		v.Id = protoNodeInfoApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case NodeRebootApiRequest:
-		v.Id = nodeRebootApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case EntitiesCountApiRequest:
-		v.Id = entitiesCountApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case EntityHashApiRequest:
-		v.Id = entityHashApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case GetEntityStateApiRequest:
-		v.Id = getEntityStateApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case SetEntityStateApiRequest:
-		v.Id = setEntityStateApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case ConnectedPathApiRequest:
-		v.Id = connectedPathApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case ConnectedPathApiRequest2:
-		v.Id = connectedPathApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case UnicastRequest:
-		v.Id = connectedUnicastRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case MultiPathRequest:
-		v.Id = multipathRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case DiscResetTableApiRequest:
-		v.Id = discoveryApiRequest
-		v.ApiId = discResetTableApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case DiscTableSizeApiRequest:
-		v.Id = discoveryApiRequest
-		v.ApiId = discTableSizeApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case DiscTableItemGetApiRequest:
-		v.Id = discoveryApiRequest
-		v.ApiId = discTableItemGetApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case DiscStartDiscoverApiRequest:
-		v.Id = discoveryApiRequest
-		v.ApiId = discStartDiscoverApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case FlashGetMd5ApiRequest:
-		v.Id = flashOperationApiRequest
-		v.ApiId = flashGetMd5Api
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case FlashEraseApiRequest:
-		v.Id = flashOperationApiRequest
-		v.ApiId = flashEraseApi
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case FlashWriteApiRequest:
-		v.Id = flashOperationApiRequest
-		v.ApiId = flashWriteApi
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	case FlashEBootApiRequest:
-		v.Id = flashOperationApiRequest
-		v.ApiId = flashEBootApiRequest
-		b, err = restruct.Pack(binary.LittleEndian, &v)
-	default:
-		err = errors.New("unknow type request")
-	}
-
-	return b, err
+	return registry.encode(cmd)
 }
 
 func (frame *ApiFrame) EncodeFrame(cmd interface{}) error {
@@ -791,7 +681,7 @@ func (frame *ApiFrame) EncodeFrame(cmd interface{}) error {
 
 	if err == nil {
 		if len(b) == 0 {
-			err = errors.New("can't encode requested stuct")
+			err = fmt.Errorf("%w: can't encode requested stuct", ErrInvalidRequest)
 		} else {
 			frame.data = b
 			//frame.escaped = true
@@ -817,6 +707,11 @@ func FindBestProtocol(target MeshNodeId, network *graph.Network) MeshProtocol {
 
 	path, _, err := network.GetPath(device)
 	if err != nil {
+		// A node of the graph without a route allowed by its policy is not tried with a unicast
+		// over a left out link, the multipath frame building reports the missing route
+		if errors.Is(err, ErrNoRoute) {
+			return MultipathProtocol
+		}
 		return UnicastProtocol
 	}
 
@@ -870,7 +765,7 @@ func NewApiFrameFromStruct(v interface{}, protocol MeshProtocol, target MeshNode
 	case MultipathProtocol:
 		// multipath protocol talk with the mesh network with hops
 		if network == nil {
-			return nil, errors.New("multipathProtocol requested, but network graph not initialized")
+			return nil, fmt.Errorf("%w: multipathProtocol requested, but network graph not initialized", ErrNoRoute)
 		}
 		device, err := network.GetNodeDevice(int64(target))
 		if err != nil {
@@ -881,7 +776,7 @@ func NewApiFrameFromStruct(v interface{}, protocol MeshProtocol, target MeshNode
 			return nil, err
 		}
 		if len(path) == 1 {
-			return nil, errors.New("requested target is the local node. Use directProtocol instead")
+			return nil, fmt.Errorf("%w: requested target is the local node. Use directProtocol instead", ErrInvalidRequest)
 		}
 		// Removed the local node and the target node from the path
 		_path := make([]uint32, len(path)-2)
@@ -899,8 +794,32 @@ func NewApiFrameFromStruct(v interface{}, protocol MeshProtocol, target MeshNode
 		if err != nil {
 			return nil, err
 		}
+	case BradcastProtocol:
+		// broadcast protocol talk with all the nodes in range of the coordinator
+		var err error
+		p := BroadcastRequest{Id: broadcastRequest}
+		p.Payload, err = EncodeBuffer(v)
+		if err != nil {
+			return nil, err
+		}
+		err = f.EncodeFrame(p)
+		if err != nil {
+			return nil, err
+		}
+	case PoliteBroadcastProtocol:
+		// polite broadcast protocol floods the whole mesh network
+		var err error
+		p := PoliteBroadcastRequest{Id: politeBroadcastRequest}
+		p.Payload, err = EncodeBuffer(v)
+		if err != nil {
+			return nil, err
+		}
+		err = f.EncodeFrame(p)
+		if err != nil {
+			return nil, err
+		}
 	default:
-		return nil, errors.New("unknow protocol requested")
+		return nil, fmt.Errorf("%w: unknow protocol requested", ErrInvalidRequest)
 	}
 
 	return f, nil
@@ -0,0 +1,137 @@
+package meshmesh
+
+//go:generate go run ../wireshark/dissectorgen -o ../wireshark/meshmesh.lua
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+	"time"
+)
+
+// pcapng block types and options
+const (
+	pcapngSectionHeaderBlock  uint32 = 0x0A0D0D0A
+	pcapngInterfaceBlock      uint32 = 0x00000001
+	pcapngEnhancedPacketBlock uint32 = 0x00000006
+	pcapngByteOrderMagic      uint32 = 0x1A2B3C4D
+	pcapngOptionEnd           uint16 = 0
+	pcapngOptionShbUserAppl   uint16 = 4
+	pcapngOptionIfName        uint16 = 2
+	pcapngOptionEpbFlags      uint16 = 2
+	pcapngLinkTypeUser0       uint16 = 147
+	pcapngEpbFlagCrcError     uint32 = 1 << 24
+	captureApplicationName           = "meshmeshgo"
+	captureInterfaceName             = "coordinator"
+	captureDirectionInbound   uint32 = 1
+	captureDirectionOutbound  uint32 = 2
+)
+
+// FrameCapture writes the unescaped api frames exchanged with the coordinator to a pcapng file.
+// The frames use the LINKTYPE_USER0 link type, the wireshark/meshmesh.lua plugin decodes them.
+type FrameCapture struct {
+	lock sync.Mutex
+	file *os.File
+}
+
+func (c *FrameCapture) option(b []byte, code uint16, value []byte) []byte {
+	b = binary.LittleEndian.AppendUint16(b, code)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(value)))
+	b = append(b, value...)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// block wraps the body with the block type and the total length
+func (c *FrameCapture) block(blockType uint32, body []byte) []byte {
+	length := uint32(12 + len(body))
+	b := make([]byte, 0, length)
+	b = binary.LittleEndian.AppendUint32(b, blockType)
+	b = binary.LittleEndian.AppendUint32(b, length)
+	b = append(b, body...)
+	return binary.LittleEndian.AppendUint32(b, length)
+}
+
+func (c *FrameCapture) writeHeader() error {
+	shb := binary.LittleEndian.AppendUint32(nil, pcapngByteOrderMagic)
+	shb = binary.LittleEndian.AppendUint16(shb, 1)
+	shb = binary.LittleEndian.AppendUint16(shb, 0)
+	// Section length not specified
+	shb = binary.LittleEndian.AppendUint64(shb, 0xFFFFFFFFFFFFFFFF)
+	shb = c.option(shb, pcapngOptionShbUserAppl, []byte(captureApplicationName))
+	shb = c.option(shb, pcapngOptionEnd, nil)
+
+	idb := binary.LittleEndian.AppendUint16(nil, pcapngLinkTypeUser0)
+	idb = binary.LittleEndian.AppendUint16(idb, 0)
+	idb = binary.LittleEndian.AppendUint32(idb, 0)
+	idb = c.option(idb, pcapngOptionIfName, []byte(captureInterfaceName))
+	idb = c.option(idb, pcapngOptionEnd, nil)
+
+	_, err := c.file.Write(append(c.block(pcapngSectionHeaderBlock, shb), c.block(pcapngInterfaceBlock, idb)...))
+	return err
+}
+
+func (c *FrameCapture) writeFrame(frame []byte, direction uint32, crcOk bool) {
+	// Timestamps with the default resolution of microseconds
+	ts := uint64(time.Now().UnixMicro())
+	flags := direction
+	if !crcOk {
+		flags |= pcapngEpbFlagCrcError
+	}
+
+	epb := binary.LittleEndian.AppendUint32(nil, 0)
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(ts>>32))
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(ts))
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(len(frame)))
+	epb = binary.LittleEndian.AppendUint32(epb, uint32(len(frame)))
+	epb = append(epb, frame...)
+	for len(epb)%4 != 0 {
+		epb = append(epb, 0)
+	}
+	epb = c.option(epb, pcapngOptionEpbFlags, binary.LittleEndian.AppendUint32(nil, flags))
+	epb = c.option(epb, pcapngOptionEnd, nil)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.file != nil {
+		c.file.Write(c.block(pcapngEnhancedPacketBlock, epb))
+	}
+}
+
+// WriteInbound records a frame received from the coordinator
+func (c *FrameCapture) WriteInbound(frame []byte, crcOk bool) {
+	c.writeFrame(frame, captureDirectionInbound, crcOk)
+}
+
+// WriteOutbound records a frame sent to the coordinator
+func (c *FrameCapture) WriteOutbound(frame []byte) {
+	c.writeFrame(frame, captureDirectionOutbound, true)
+}
+
+func (c *FrameCapture) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// NewFrameCapture creates a new pcapng file, an existing file is overwritten
+func NewFrameCapture(filename string) (*FrameCapture, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &FrameCapture{file: file}
+	if err := c.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return c, nil
+}
@@ -1,7 +1,7 @@
 package meshmesh
 
 import (
-	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -34,15 +34,23 @@ type ConnPathConnection struct {
 	connectionActiveCallback    func()
 	connectionInvalidCallback   func()
 	connState                   uint8
+	err                         error
 	handle                      uint16
 	sequence                    uint16
 	network                     *graph.Network
+	class                       TrafficClass
+	hops                        int
+	addr                        MeshNodeId
+	port                        uint16
+	// The shortest path to the node and its backups, pathIndex is the one in use
+	paths     []graph.NetworkPath
+	pathIndex int
 }
 
 func ParseAddress(address string) (MeshNodeId, error) {
 	fields := strings.Split(address, ".")
 	if len(fields) != 4 {
-		return 0, errors.New("invalid address string")
+		return 0, fmt.Errorf("%w: invalid address string", ErrInvalidRequest)
 	}
 
 	var err error
@@ -72,12 +80,12 @@ func (client *ConnPathConnection) getNextSequence() uint16 {
 }
 
 func (client *ConnPathConnection) SendData(data []byte) error {
-	err := client.serialProxy.sendFrame(connectedPathSendDataRequest, client.handle, client.getNextSequence(), data)
+	err := client.serialProxy.sendFrame(client.class, client.hops, connectedPathSendDataRequest, client.handle, client.getNextSequence(), data)
 	return err
 }
 
 func (client *ConnPathConnection) SendDataNack() error {
-	return client.serialProxy.sendFrame(connectedPathSendDataNackReply, client.handle, client.getNextSequence(), []byte{})
+	return client.serialProxy.sendFrame(client.class, client.hops, connectedPathSendDataNackReply, client.handle, client.getNextSequence(), []byte{})
 }
 
 func (client *ConnPathConnection) OpenConnectionAsync2(textaddr string, port uint16) error {
@@ -98,23 +106,59 @@ func (client *ConnPathConnection) OpenConnectionAsync(addr MeshNodeId, port uint
 	if err != nil {
 		return err
 	}
-	_path, _, err := network.GetPath(device)
+	paths, err := network.GetPaths(device, MaxConnectedPaths)
 	if err != nil {
 		return err
 	}
-	if len(_path) == 1 {
-		return errors.New("speak with local node is not yet supported")
+	if len(paths[0].Nodes) == 1 {
+		return fmt.Errorf("%w: speak with local node is not yet supported", ErrInvalidRequest)
 	}
 
-	_path = _path[1:]
+	client.addr = addr
+	client.port = port
+	client.paths = client.serialProxy.Routes.Order(addr, paths)
+	client.pathIndex = 0
+	return client.openPath()
+}
+
+// OpenNextPath restarts the handshake over the next backup path, it fails when all the paths were tried
+func (client *ConnPathConnection) OpenNextPath() error {
+	if client.pathIndex+1 >= len(client.paths) {
+		return fmt.Errorf("%w: no more paths to %s", ErrNoRoute, utils.FmtNodeId(int64(client.addr)))
+	}
+
+	// The abandoned path is closed and the handshake goes on with a new handle, so a late
+	// ack or nack of the previous path finds no connection and can't complete this one
+	client.serialProxy.sendFrame(client.class, client.hops, connectedPathDisconnectRequest, client.handle, client.getNextSequence(), []byte{})
+	client.serialProxy.RemovePacketReceivedCallback(client.handle)
+	client.handle = client.serialProxy.GetNextSerialHandle()
+	client.serialProxy.AddPacketReceivedCallback(client.handle, client.handleIncomingSerialPacket)
+
+	client.pathIndex++
+	logger.WithFields(logger.Fields{"addr": utils.FmtNodeId(int64(client.addr)), "handle": client.handle, "path": utils.FmtPath2Str(client.Path())}).
+		Info("ConnPathConnection: trying a backup path")
+	return client.openPath()
+}
+
+// Path returns the path in use, from the coordinator to the node
+func (client *ConnPathConnection) Path() []int64 {
+	if client.pathIndex >= len(client.paths) {
+		return nil
+	}
+	return client.paths[client.pathIndex].Nodes
+}
+
+// openPath sends the open connection request over the path in use
+func (client *ConnPathConnection) openPath() error {
+	_path := client.Path()[1:]
 	path := make([]int32, len(_path))
 	for i, item := range _path {
 		path[i] = int32(item)
 	}
 
+	client.hops = len(path)
 	client.connState = connPathConnectionStateHandshakeStarted
-	err = client.serialProxy.sendOpenConnectionRequest(client.handle, client.getNextSequence(), port, path)
-	return err
+	return client.serialProxy.sendOpenConnectionRequest(client.class, client.handle, client.getNextSequence(), client.port, path)
 }
 
 func (client *ConnPathConnection) Disconnect() {
@@ -125,8 +169,8 @@ func (client *ConnPathConnection) Disconnect() {
 	}
 
 	logger.WithFields(logger.Fields{"handle": client.handle, "connState": client.connState}).Debug("Sending Disconnect request")
-	client.serialProxy.sendFrame(connectedPathDisconnectRequest, client.handle, client.getNextSequence(), []byte{})
-	client.invalidateConnection()
+	client.serialProxy.sendFrame(client.class, client.hops, connectedPathDisconnectRequest, client.handle, client.getNextSequence(), []byte{})
+	client.invalidateConnection(nil)
 }
 
 func (client *ConnPathConnection) handleIncomingSendDataRequest(v *ConnectedPathApiReply) {
@@ -138,10 +182,11 @@ func (client *ConnPathConnection) handleIncomingSendDataRequest(v *ConnectedPath
 func (client *ConnPathConnection) handleIncomingOpenConnAck(_ *ConnectedPathApiReply) {
 	if client.connState != connPathConnectionStateHandshakeStarted {
 		logger.Error("ConnPathConnection.handleIncomingOpenConnAck received while not in handshake state")
-		client.invalidateConnection()
+		client.invalidateConnection(fmt.Errorf("%w: open connection ack out of the handshake", ErrDecode))
 	} else {
 		logger.WithField("handle", client.handle).Debug("ConnPathConnection.handleIncomingOpenConnAck: Accpeted connection")
 		client.connState = connPathConnectionStateActive
+		client.serialProxy.Routes.Succeeded(client.addr, client.Path())
 		if client.connectionActiveCallback != nil {
 			client.connectionActiveCallback()
 		}
@@ -150,7 +195,11 @@ func (client *ConnPathConnection) handleIncomingOpenConnAck(_ *ConnectedPathApiR
 
 func (client *ConnPathConnection) handleIncomingOpenConnNack(v *ConnectedPathApiReply) {
 	logger.WithFields(logger.Fields{"handle": v.Handle}).Error("nack during opening connection")
-	client.invalidateConnection()
+	if client.connState == connPathConnectionStateHandshakeStarted {
+		client.handshakeFailed(fmt.Errorf("%w: open connection nack", ErrConnectionNack))
+	} else {
+		client.invalidateConnection(fmt.Errorf("%w: open connection nack", ErrConnectionNack))
+	}
 }
 
 func (client *ConnPathConnection) handleIncomingSerialPacket(v *ConnectedPathApiReply) {
@@ -163,25 +212,47 @@ func (client *ConnPathConnection) handleIncomingSerialPacket(v *ConnectedPathApi
 		client.handleIncomingOpenConnNack(v)
 	case connectedPathSendDataNackReply:
 		logger.WithField("handle", v.Handle).Error("HandleIncomingReply: SendDataNack")
-		client.invalidateConnection()
+		client.invalidateConnection(fmt.Errorf("%w: send data nack", ErrConnectionNack))
 	case connectedPathDisconnectRequest:
 		logger.WithField("handle", v.Handle).Debug("HandleIncomingReply: DisconnectRequest")
-		client.invalidateConnection()
+		client.invalidateConnection(nil)
 	default:
 		logger.WithFields(logger.Fields{"handle": v.Handle, "reply": v.Command}).
 			Error("HandleIncomingReply: unknow command reply received", v.Command, v.Handle)
 	}
 }
 
-func (client *ConnPathConnection) invalidateConnection() {
+// invalidateConnection marks the connection as closed, err is nil when the connection was closed normally
+func (client *ConnPathConnection) invalidateConnection(err error) {
 	if client.connState != connPathConnectionStateInvalid {
 		client.connState = connPathConnectionStateInvalid
+		client.err = err
 		if client.connectionInvalidCallback != nil {
 			client.connectionInvalidCallback()
 		}
 	}
 }
 
+// handshakeFailed marks the handshake as failed, the connection can still be opened over another path
+// with OpenNextPath or be invalidated
+func (client *ConnPathConnection) handshakeFailed(err error) {
+	client.connState = connPathConnectionStateHandshakeFailed
+	client.err = err
+	if client.connectionInvalidCallback != nil {
+		client.connectionInvalidCallback()
+	}
+}
+
+// Err returns the reason why the connection was invalidated, nil if it was closed normally
+func (client *ConnPathConnection) Err() error {
+	return client.err
+}
+
+// SetTrafficClass sets the priority of the frames sent by the connection, interactive by default
+func (client *ConnPathConnection) SetTrafficClass(class TrafficClass) {
+	client.class = class
+}
+
 func (client *ConnPathConnection) SetSerialDataAvailableCallback(callback func(data []byte)) {
 	client.serialDataAvailableCallback = callback
 }
@@ -205,6 +276,7 @@ func NewConnPathConnection(serialProxy *ConnectedPath2Serial, network *graph.Net
 		network:     network,
 		handle:      serialProxy.GetNextSerialHandle(),
 		connState:   connPathConnectionStateInit,
+		class:       InteractiveTraffic,
 	}
 
 	serialProxy.AddPacketReceivedCallback(conn.handle, conn.handleIncomingSerialPacket)
@@ -2,6 +2,7 @@ package meshmesh
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"leguru.net/m/v2/logger"
 )
@@ -12,7 +13,11 @@ type ConnectedPathPacketReceivedCallback struct {
 }
 
 type ConnectedPath2Serial struct {
-	serial                 *SerialConnection
+	// The paths that opened the last connections to the nodes
+	Routes                 *ConnectedPathRoutes
+	serial                 atomic.Pointer[SerialConnection]
+	attachedSerials        map[*SerialConnection]bool
+	attachLock             sync.Mutex
 	packetReceivedCallback []ConnectedPathPacketReceivedCallback
 	packetCallbackMutex    sync.RWMutex
 }
@@ -38,8 +43,9 @@ func (conn *ConnectedPath2Serial) handleIncomingFrame(data any) {
 	logger.WithFields(logger.Fields{"handle": cp.Handle}).Error("No callback found for connectedpath packet")
 }
 
-func (conn *ConnectedPath2Serial) sendFrame(command uint8, handle uint16, sequence uint16, data []byte) error {
-	err := conn.serial.SendApi(ConnectedPathApiRequest{
+// sendFrame queues a connected path frame in the traffic class of the connection, hops is the length of its path
+func (conn *ConnectedPath2Serial) sendFrame(class TrafficClass, hops int, command uint8, handle uint16, sequence uint16, data []byte) error {
+	err := conn.serial.Load().sendApi(ConnectedPathApiRequest{
 		Protocol: meshmeshProtocolConnectedPath,
 		Command:  command,
 		Handle:   handle,
@@ -47,12 +53,12 @@ func (conn *ConnectedPath2Serial) sendFrame(command uint8, handle uint16, sequen
 		Sequence: sequence,
 		DataSize: uint16(len(data)),
 		Data:     data,
-	})
+	}, class, hops)
 	return err
 }
 
-func (conn *ConnectedPath2Serial) sendOpenConnectionRequest(handle uint16, sequence uint16, port uint16, path []int32) error {
-	err := conn.serial.SendApi(ConnectedPathApiRequest2{
+func (conn *ConnectedPath2Serial) sendOpenConnectionRequest(class TrafficClass, handle uint16, sequence uint16, port uint16, path []int32) error {
+	err := conn.serial.Load().sendApi(ConnectedPathApiRequest2{
 		Protocol: meshmeshProtocolConnectedPath,
 		Command:  connectedPathOpenConnectionRequest,
 		Handle:   handle,
@@ -62,25 +68,25 @@ func (conn *ConnectedPath2Serial) sendOpenConnectionRequest(handle uint16, seque
 		Port:     port,
 		PathLen:  uint8(len(path)),
 		Path:     path,
-	})
+	}, class, len(path))
 
 	return err
 }
 
 func (conn *ConnectedPath2Serial) IsSerialConnected() bool {
-	return conn.serial.IsConnected()
+	return conn.serial.Load().IsConnected()
 }
 
 func (client *ConnectedPath2Serial) ClearConnections() error {
-	return client.sendFrame(connectedPathClearConnections, 0, 0, []byte{})
+	return client.sendFrame(ControlTraffic, 0, connectedPathClearConnections, 0, 0, []byte{})
 }
 
 func (conn *ConnectedPath2Serial) IsEsp8266() bool {
-	return conn.serial.isEsp8266
+	return conn.serial.Load().isEsp8266.Load()
 }
 
 func (conn *ConnectedPath2Serial) TxOneByteMs() int {
-	return conn.serial.txOneByteMs
+	return conn.serial.Load().txOneByteMs
 }
 
 func (conn *ConnectedPath2Serial) AddPacketReceivedCallback(handle uint16, callback func(packet *ConnectedPathApiReply)) {
@@ -101,13 +107,27 @@ func (conn *ConnectedPath2Serial) RemovePacketReceivedCallback(handle uint16) {
 }
 
 func (conn *ConnectedPath2Serial) GetNextSerialHandle() uint16 {
-	return conn.serial.GetNextHandle()
+	return conn.serial.Load().GetNextHandle()
 }
 
-func NewConnectedPath2Serial(serial *SerialConnection) *ConnectedPath2Serial {
-	conn := &ConnectedPath2Serial{
-		serial: serial,
+// SetSerial moves the connected paths to another port of the coordinator, the frames
+// received from the previous port are ignored from now on.
+func (conn *ConnectedPath2Serial) SetSerial(serial *SerialConnection) {
+	conn.attachLock.Lock()
+	if !conn.attachedSerials[serial] {
+		conn.attachedSerials[serial] = true
+		serial.AddFrameReceivedCallback(connectedPathApiReply, 0, func(data any) {
+			if conn.serial.Load() == serial {
+				conn.handleIncomingFrame(data)
+			}
+		})
 	}
-	serial.AddFrameReceivedCallback(connectedPathApiReply, 0, conn.handleIncomingFrame)
+	conn.attachLock.Unlock()
+	conn.serial.Store(serial)
+}
+
+func NewConnectedPath2Serial(serial *SerialConnection) *ConnectedPath2Serial {
+	conn := &ConnectedPath2Serial{Routes: NewConnectedPathRoutes(), attachedSerials: make(map[*SerialConnection]bool)}
+	conn.SetSerial(serial)
 	return conn
 }
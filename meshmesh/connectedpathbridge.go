@@ -17,6 +17,8 @@ import (
 
 type ConnectionPathBridgeDriver interface {
 	Socket2Serial(buffer *bytes.Buffer, connectedPath *ConnPathConnection, stats *EspApiConnectionStats)
+	// TrafficClass is the priority of the data sent by the driver
+	TrafficClass() TrafficClass
 }
 
 // ConnectionPathBridge is a bridge between a socket and a connected path serial protocol
@@ -31,9 +33,11 @@ type ConnectionPathBridge struct {
 	reqAddress      MeshNodeId
 	reqPort         int
 	debugThisNode   bool
-	timeout         time.Time
-	clientClosed    func(client *ConnectionPathBridge)
-	driver          ConnectionPathBridgeDriver
+	// Serializes the handshake retries of the serial reader and of the timeout routine, guards timeout
+	handshakeLock sync.Mutex
+	timeout       time.Time
+	clientClosed  func(client *ConnectionPathBridge)
+	driver        ConnectionPathBridgeDriver
 }
 
 // Called when the serial data is available
@@ -73,6 +77,13 @@ func (c *ConnectionPathBridge) connectionActive() {
 
 // Called when the connection is mark as invalid beacouse an error
 func (c *ConnectionPathBridge) connectionInvalid() {
+	if c.connectedPath.connState == connPathConnectionStateHandshakeFailed && c.retryHandshake() {
+		return
+	}
+	if err := c.connectedPath.Err(); err != nil {
+		logger.WithFields(logger.Fields{"handle": c.connectedPath.handle, "meshid": utils.FmtNodeId(int64(c.reqAddress)), "err": err}).
+			Warn("ConnectionPathBridge: connection closed by an error")
+	}
 	c.close()
 }
 
@@ -95,6 +106,29 @@ func (c *ConnectionPathBridge) startHandshake(addr MeshNodeId, port int) error {
 	return err
 }
 
+// handshakeElapsed returns the time passed since the handshake started over the path in use
+func (c *ConnectionPathBridge) handshakeElapsed() time.Duration {
+	c.handshakeLock.Lock()
+	defer c.handshakeLock.Unlock()
+	return time.Since(c.timeout)
+}
+
+// retryHandshake restarts the handshake over the next backup path, false when no path is left
+func (c *ConnectionPathBridge) retryHandshake() bool {
+	c.handshakeLock.Lock()
+	defer c.handshakeLock.Unlock()
+
+	logger.WithFields(logger.Fields{"handle": c.connectedPath.handle, "meshid": utils.FmtNodeId(int64(c.reqAddress)),
+		"path": utils.FmtPath2Str(c.connectedPath.Path()), "err": c.connectedPath.Err()}).
+		Warn("ConnectionPathBridge: handshake failed")
+	if err := c.connectedPath.OpenNextPath(); err != nil {
+		logger.WithFields(logger.Fields{"handle": c.connectedPath.handle, "err": err}).Debug("ConnectionPathBridge: no backup path")
+		return false
+	}
+	c.timeout = time.Now()
+	return true
+}
+
 func (c *ConnectionPathBridge) finishHandshake(result bool) {
 	if !result {
 		logger.WithFields(logger.Fields{"addr": c.reqAddress, "port": c.reqPort, "err": nil}).
@@ -136,7 +170,7 @@ func (c *ConnectionPathBridge) readFromSocketRoutine() {
 
 		if n > 0 {
 			switch c.connectedPath.connState {
-			case connPathConnectionStateHandshakeStarted:
+			case connPathConnectionStateHandshakeStarted, connPathConnectionStateHandshakeFailed:
 				// FIXME check for if buffer grown outside limits
 				c.tmpBuffer.WriteByte(buffer[0])
 			case connPathConnectionStateActive:
@@ -170,9 +204,12 @@ func (c *ConnectionPathBridge) checkTimeoutRoutine() {
 			break
 		}
 		if c.connectedPath.connState == connPathConnectionStateInit || c.connectedPath.connState == connPathConnectionStateHandshakeStarted {
-			if time.Since(c.timeout).Milliseconds() > 3000 {
-				logger.Error(fmt.Sprintf("Closing connection beacuse timeout after %dms in connPathConnectionStateInit for handle %d", time.Since(c.timeout).Milliseconds(), c.connectedPath.handle))
-				c.close()
+			if elapsed := c.handshakeElapsed(); elapsed.Milliseconds() > 3000 {
+				retried := c.connectedPath.connState == connPathConnectionStateHandshakeStarted && c.retryHandshake()
+				if !retried {
+					logger.Error(fmt.Sprintf("Closing connection beacuse timeout after %dms in connPathConnectionStateInit for handle %d", elapsed.Milliseconds(), c.connectedPath.handle))
+					c.close()
+				}
 			}
 		}
 		time.Sleep(100 * time.Millisecond)
@@ -181,9 +218,9 @@ func (c *ConnectionPathBridge) checkTimeoutRoutine() {
 	logger.Debug("ConnectionPathBridge.checkTimeoutRoutine exited")
 }
 
-func NewConnectionPathBridge(socket net.Conn, serialProxy *ConnectedPath2Serial, network *graph.Network, addr MeshNodeId, port int, driver ConnectionPathBridgeDriver, closedCb func(*ConnectionPathBridge)) (*ConnectionPathBridge, error) {
+func NewConnectionPathBridge(socket net.Conn, serialProxy *ConnectedPath2Serial, network *graph.Network, addr MeshNodeId, port int, driver ConnectionPathBridgeDriver, stats *EspApiConnectionStats, closedCb func(*ConnectionPathBridge)) (*ConnectionPathBridge, error) {
 	if !serialProxy.IsSerialConnected() {
-		return nil, errors.New("serial is not open")
+		return nil, ErrSerialClosed
 	}
 
 	conn := ConnectionPathBridge{
@@ -194,10 +231,11 @@ func NewConnectionPathBridge(socket net.Conn, serialProxy *ConnectedPath2Serial,
 		inBuffer:      bytes.NewBuffer([]byte{}),
 		timeout:       time.Now(),
 		clientClosed:  closedCb,
-		Stats:         _allStats.Stats(addr),
+		Stats:         stats,
 		driver:        driver,
 	}
 
+	conn.connectedPath.SetTrafficClass(driver.TrafficClass())
 	conn.connectedPath.SetSerialDataAvailableCallback(conn.serialDataAvailable)
 	conn.connectedPath.SetConnectionActiveCallback(conn.connectionActive)
 	conn.connectedPath.SetConnectionInvalidCallback(conn.connectionInvalid)
@@ -0,0 +1,61 @@
+package meshmesh
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"leguru.net/m/v2/graph"
+)
+
+// MaxConnectedPaths is the number of paths tried to open a connection, the shortest one and its backups
+const MaxConnectedPaths = 3
+
+// ConnectedPathRoute is the last path, from the coordinator to the node, that opened a connection
+type ConnectedPathRoute struct {
+	Path []int64
+	Time time.Time
+}
+
+// ConnectedPathRoutes remembers the paths that opened the connections to the nodes,
+// the next connections to the same node try them first
+type ConnectedPathRoutes struct {
+	lock   sync.Mutex
+	routes map[MeshNodeId]ConnectedPathRoute
+}
+
+func (r *ConnectedPathRoutes) Succeeded(node MeshNodeId, path []int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.routes[node] = ConnectedPathRoute{Path: slices.Clone(path), Time: time.Now()}
+}
+
+// Get returns the last path that opened a connection to the node
+func (r *ConnectedPathRoutes) Get(node MeshNodeId) (ConnectedPathRoute, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	route, ok := r.routes[node]
+	return route, ok
+}
+
+// Order moves the path that last opened a connection to the node in front of the others,
+// the order is not changed when that path is not one of the given ones
+func (r *ConnectedPathRoutes) Order(node MeshNodeId, paths []graph.NetworkPath) []graph.NetworkPath {
+	route, ok := r.Get(node)
+	if !ok {
+		return paths
+	}
+	index := slices.IndexFunc(paths, func(p graph.NetworkPath) bool { return slices.Equal(p.Nodes, route.Path) })
+	if index <= 0 {
+		return paths
+	}
+
+	ordered := make([]graph.NetworkPath, 0, len(paths))
+	ordered = append(ordered, paths[index])
+	ordered = append(ordered, paths[:index]...)
+	return append(ordered, paths[index+1:]...)
+}
+
+func NewConnectedPathRoutes() *ConnectedPathRoutes {
+	return &ConnectedPathRoutes{routes: make(map[MeshNodeId]ConnectedPathRoute)}
+}
@@ -0,0 +1,90 @@
+package meshmesh
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const consoleCapacity = 200
+
+var (
+	// Color sequences of the ESP-IDF logger
+	consoleAnsiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// ESP-IDF lines start with "E (123) tag:", ESPHome lines with "[E][tag:12]:" after an optional time
+	consoleIdfLevel     = regexp.MustCompile(`^([EWIDV]) \(\d+\)`)
+	consoleEsphomeLevel = regexp.MustCompile(`^(?:\[[0-9:.]+\])?\[(VV|[EWICDV])\]\[`)
+	// Lines printed by the ESP8266 and ESP32 panic handlers
+	consoleCrashMarkers = []string{"Guru Meditation", "Backtrace:", "abort() was called", "Exception (", "CUT HERE FOR EXCEPTION DECODER", "panic", "assert failed"}
+)
+
+var consoleLevelLetters = map[string]uint16{
+	"E":  NodeLogLevelError,
+	"W":  NodeLogLevelWarn,
+	"I":  NodeLogLevelInfo,
+	"C":  NodeLogLevelConfig,
+	"D":  NodeLogLevelDebug,
+	"V":  NodeLogLevelVerbose,
+	"VV": NodeLogLevelVeryVerbose,
+}
+
+// cleanConsoleLine removes the colors and the control characters from a line printed by the coordinator
+func cleanConsoleLine(line []byte) string {
+	text := strings.ToValidUTF8(string(line), "?")
+	text = consoleAnsiEscape.ReplaceAllString(text, "")
+	text = strings.Map(func(r rune) rune {
+		if r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.TrimRight(text, " \t")
+}
+
+// consoleLineLevel guesses the log level of a line printed by the coordinator
+func consoleLineLevel(line string) uint16 {
+	for _, re := range []*regexp.Regexp{consoleIdfLevel, consoleEsphomeLevel} {
+		if match := re.FindStringSubmatch(line); match != nil {
+			return consoleLevelLetters[match[1]]
+		}
+	}
+	for _, marker := range consoleCrashMarkers {
+		if strings.Contains(line, marker) {
+			return NodeLogLevelError
+		}
+	}
+	return NodeLogLevelInfo
+}
+
+// ConsoleBuffer keeps the last text lines printed by the coordinator outside the api frames,
+// the node of the entries is the coordinator node known when the line was received.
+type ConsoleBuffer struct {
+	lock sync.Mutex
+	ring nodeLogRing
+}
+
+func (c *ConsoleBuffer) Add(node MeshNodeId, level uint16, line string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.ring.add(NodeLogEntry{Node: node, Level: level, Time: time.Now(), Line: line})
+}
+
+// Query returns the stored lines that match the filter, from the oldest to the newest
+func (c *ConsoleBuffer) Query(filter NodeLogFilter) []NodeLogEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	result := make([]NodeLogEntry, 0)
+	c.ring.each(func(entry *NodeLogEntry) {
+		if filter.match(entry) {
+			result = append(result, *entry)
+		}
+	})
+	return result
+}
+
+func NewConsoleBuffer(capacity int) *ConsoleBuffer {
+	return &ConsoleBuffer{ring: nodeLogRing{entries: make([]NodeLogEntry, 0, capacity)}}
+}
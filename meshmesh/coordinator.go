@@ -0,0 +1,277 @@
+package meshmesh
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"leguru.net/m/v2/graph"
+)
+
+// CoordinatorPort is a port of a coordinator together with its watchdog, that can be nil
+type CoordinatorPort struct {
+	Serial   *SerialConnection
+	Watchdog *CoordinatorWatchdog
+}
+
+// Coordinator is a coordinator node attached to the hub together with the networks reached through it
+type Coordinator struct {
+	Name          string
+	StarPath      *StarPath
+	ConnectedPath *ConnectedPath2Serial
+	Servers       *MultiSocketServer
+
+	networkLock sync.Mutex
+	network     *graph.Network
+
+	// The active port is the primary one until a failover to the standby port
+	portsLock   sync.Mutex
+	primary     *CoordinatorPort
+	active      *CoordinatorPort
+	standby     *CoordinatorPort
+	switchedAt  time.Time
+	failover    sync.Mutex
+	stopMonitor chan struct{}
+}
+
+// Serial returns the active port of the coordinator
+func (c *Coordinator) Serial() *SerialConnection {
+	c.portsLock.Lock()
+	defer c.portsLock.Unlock()
+	return c.active.Serial
+}
+
+// Watchdog returns the watchdog of the active port, it can be nil
+func (c *Coordinator) Watchdog() *CoordinatorWatchdog {
+	c.portsLock.Lock()
+	defer c.portsLock.Unlock()
+	return c.active.Watchdog
+}
+
+// SetWatchdog sets the watchdog of the active port
+func (c *Coordinator) SetWatchdog(watchdog *CoordinatorWatchdog) {
+	c.portsLock.Lock()
+	defer c.portsLock.Unlock()
+	c.active.Watchdog = watchdog
+}
+
+// Ports returns the active port and the standby one, that is nil without a standby port
+func (c *Coordinator) Ports() (CoordinatorPort, *CoordinatorPort) {
+	c.portsLock.Lock()
+	defer c.portsLock.Unlock()
+	if c.standby == nil {
+		return *c.active, nil
+	}
+	standby := *c.standby
+	return *c.active, &standby
+}
+
+// AllPorts returns the active port followed by the standby one, if any
+func (c *Coordinator) AllPorts() []CoordinatorPort {
+	active, standby := c.Ports()
+	if standby == nil {
+		return []CoordinatorPort{active}
+	}
+	return []CoordinatorPort{active, *standby}
+}
+
+// Stop stops the failover checks and the watchdogs of the ports
+func (c *Coordinator) Stop() {
+	c.StopFailover()
+	for _, port := range c.AllPorts() {
+		if port.Watchdog != nil {
+			port.Watchdog.Stop()
+		}
+	}
+}
+
+// IsActive tells if the port is the one in use by the coordinator
+func (c *Coordinator) IsActive(serial *SerialConnection) bool {
+	return c.Serial() == serial
+}
+
+// IsPortNode returns true if the node is the one attached to the active or to the standby port
+func (c *Coordinator) IsPortNode(id MeshNodeId) bool {
+	for _, port := range c.AllPorts() {
+		if port.Serial != nil && uint32(id) == port.Serial.LastLocalNode() {
+			return true
+		}
+	}
+	return false
+}
+
+// ConsoleLines returns the text lines printed by the ports of the coordinator that match the filter,
+// from the oldest to the newest
+func (c *Coordinator) ConsoleLines(filter NodeLogFilter) []NodeLogEntry {
+	result := make([]NodeLogEntry, 0)
+	for _, port := range c.AllPorts() {
+		result = append(result, port.Serial.Console.Query(filter)...)
+	}
+	slices.SortStableFunc(result, func(a, b NodeLogEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return result
+}
+
+// Network returns the main network of the coordinator
+func (c *Coordinator) Network() *graph.Network {
+	c.networkLock.Lock()
+	defer c.networkLock.Unlock()
+	return c.network
+}
+
+// SetNetwork replaces the main network, like at the end of a discovery. The callbacks
+// registered on the old network are moved to the new one and notified.
+func (c *Coordinator) SetNetwork(network *graph.Network) {
+	c.networkLock.Lock()
+	old := c.network
+	c.network = network
+	c.networkLock.Unlock()
+
+	if old != nil && old != network {
+		old.MoveCallbacks(network)
+		network.PublishChanges(old)
+	}
+	network.NotifyNetworkChanged(false)
+}
+
+// StarNetwork returns the network of the nodes reached with the star path protocol, it can be nil
+func (c *Coordinator) StarNetwork() *graph.Network {
+	if c.StarPath == nil {
+		return nil
+	}
+	return c.StarPath.GetNetwork()
+}
+
+// Owns returns true if the node is the coordinator itself or one of the nodes of its networks
+func (c *Coordinator) Owns(id MeshNodeId) bool {
+	if c.IsPortNode(id) {
+		return true
+	}
+	if network := c.Network(); network != nil && network.NodeIdExists(int64(id)) {
+		return true
+	}
+	star := c.StarNetwork()
+	return star != nil && star.NodeIdExists(int64(id))
+}
+
+func NewCoordinator(name string, serial *SerialConnection, network *graph.Network) *Coordinator {
+	port := &CoordinatorPort{Serial: serial}
+	return &Coordinator{Name: name, network: network, primary: port, active: port}
+}
+
+// Coordinators is the list of the coordinators managed by the hub, the first one is the default
+type Coordinators struct {
+	list []*Coordinator
+}
+
+func (c *Coordinators) All() []*Coordinator {
+	return c.list
+}
+
+func (c *Coordinators) Default() *Coordinator {
+	return c.list[0]
+}
+
+// ByName returns the coordinator with the given name, an empty name selects the default one
+func (c *Coordinators) ByName(name string) (*Coordinator, error) {
+	if name == "" {
+		return c.Default(), nil
+	}
+	for _, coordinator := range c.list {
+		if coordinator.Name == name {
+			return coordinator, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown coordinator %s", ErrInvalidRequest, name)
+}
+
+// ForNode returns the coordinator that owns the node. The nodes not found in any network are
+// assigned to the default coordinator, that can still reach its neighbours with a unicast.
+func (c *Coordinators) ForNode(id MeshNodeId) *Coordinator {
+	for _, coordinator := range c.list {
+		if coordinator.Owns(id) {
+			return coordinator
+		}
+	}
+	return c.Default()
+}
+
+// nodeLogStores returns the stores of the node logs of every port of the coordinators
+func (c *Coordinators) nodeLogStores() []*NodeLogStore {
+	stores := make([]*NodeLogStore, 0, len(c.list))
+	for _, coordinator := range c.list {
+		active, standby := coordinator.Ports()
+		stores = append(stores, active.Serial.NodeLogs)
+		if standby != nil {
+			stores = append(stores, standby.Serial.NodeLogs)
+		}
+	}
+	return stores
+}
+
+// QueryNodeLogs returns the stored lines of a node received by every coordinator, from the oldest to the newest
+func (c *Coordinators) QueryNodeLogs(node MeshNodeId, filter NodeLogFilter) []NodeLogEntry {
+	stores := c.nodeLogStores()
+	if len(stores) == 1 {
+		return stores[0].Query(node, filter)
+	}
+
+	result := make([]NodeLogEntry, 0)
+	for _, store := range stores {
+		result = append(result, store.Query(node, filter)...)
+	}
+	slices.SortStableFunc(result, func(a, b NodeLogEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return result
+}
+
+// SubscribeNodeLogs returns a channel receiving the new lines of a node from every coordinator,
+// the cancel function must be called to release the channel.
+func (c *Coordinators) SubscribeNodeLogs(node MeshNodeId, filter NodeLogFilter) (<-chan NodeLogEntry, func()) {
+	stores := c.nodeLogStores()
+	if len(stores) == 1 {
+		return stores[0].Subscribe(node, filter)
+	}
+
+	lines := make(chan NodeLogEntry, nodeLogSubscriberQueue)
+	done := make(chan struct{})
+	cancels := make([]func(), 0, len(stores))
+	for _, store := range stores {
+		ch, cancel := store.Subscribe(node, filter)
+		cancels = append(cancels, cancel)
+		go func() {
+			for {
+				select {
+				case entry := <-ch:
+					select {
+					case lines <- entry:
+					case <-done:
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	var once sync.Once
+	return lines, func() {
+		once.Do(func() {
+			close(done)
+			for _, cancel := range cancels {
+				cancel()
+			}
+		})
+	}
+}
+
+func NewCoordinators(coordinators ...*Coordinator) *Coordinators {
+	if len(coordinators) == 0 {
+		panic("at least one coordinator is needed")
+	}
+	return &Coordinators{list: coordinators}
+}
@@ -1,6 +1,7 @@
 package meshmesh
 
 import (
+	"context"
 	"errors"
 	"math"
 	"time"
@@ -24,6 +25,7 @@ const (
 )
 
 type DiscoveryProcedure struct {
+	coordinator     *Coordinator
 	serial          *SerialConnection
 	network         *gra.Network
 	currentDeviceId int64
@@ -87,6 +89,11 @@ func Rssi2weight(rssi int16) float64 {
 	return math.Round(cost*100) / 100
 }
 
+// weight2Rssi is the inverse of Rssi2weight
+func weight2Rssi(weight float64) int16 {
+	return int16(math.Round(esp32RssiMin + (1.0-weight)*(esp32RssiMax-esp32RssiMin)))
+}
+
 func CostToWeight(cost int16) float64 {
 	return math.Max(0.0, math.Min(float64(cost)/100.0, 1.0))
 }
@@ -116,18 +123,18 @@ func _neighborsAdavance(w map[int64]discWeights) {
 	}
 }
 
-func neighborsToGraph(g *gra.Network, nodeId int64, w map[int64]discWeights) {
-	nodes := g.From(nodeId)
+func neighborsToGraph(tx *gra.NetworkTx, nodeId int64, w map[int64]discWeights) {
+	nodes := tx.From(nodeId)
 
 	for nodes.Next() {
 		neighbor := nodes.Node().(gra.NodeDevice)
-		g.RemoveEdge(nodeId, neighbor.ID())
+		tx.RemoveEdge(nodeId, neighbor.ID())
 	}
 
 	for id, d := range w {
-		logger.WithFields(logger.Fields{"to": utils.FmtNodeId(id), "weight": d, "exists": g.NodeIdExists(id)}).
+		logger.WithFields(logger.Fields{"to": utils.FmtNodeId(id), "weight": d, "exists": tx.NodeIdExists(id)}).
 			Infof("[%s] Neighbor to graph", utils.FmtNodeId(nodeId))
-		g.ChangeEdgeWeight(nodeId, id, d.Next, d.Next)
+		tx.ChangeEdgeWeight(nodeId, id, d.Next, d.Next)
 	}
 }
 
@@ -140,16 +147,18 @@ func _updateNeighbor(w map[int64]discWeights, id int64, rssi1, rssi2 float64) er
 	return nil
 }
 
+// _findNextNode returns the not discovered node with the cheapest path from the coordinator
 func _findNextNode(g *gra.Network) gra.NodeDevice {
-	nodes := g.Nodes()
+	network := g.Snapshot()
+	nodes := network.Nodes()
 	var found_node gra.NodeDevice
 	var found_weight float64 = 1e9
 	for nodes.Next() {
 		dev := nodes.Node().(gra.NodeDevice)
 		if dev.Device().InUse() && !dev.Device().Discovered() {
-			path, weight, err := g.GetPath(dev)
+			path, weight, err := network.GetPath(dev)
 
-			if weight < found_weight {
+			if err == nil && weight < found_weight {
 				found_weight = weight
 				found_node = dev
 			}
@@ -164,7 +173,7 @@ func (d *DiscoveryProcedure) InitStep() error {
 	d.state = DiscoveryProcedureStateRun
 
 	if d.network == nil {
-		d.network = gra.NewNetwork(int64(d.serial.LocalNode), gra.NETWORK_ID_DISCOVERY)
+		d.network = gra.NewNetwork(int64(d.serial.LocalNode.Load()), gra.NETWORK_ID_DISCOVERY)
 	}
 
 	if d.currentDeviceId != 0 {
@@ -200,19 +209,21 @@ func (d *DiscoveryProcedure) InitStep() error {
 func (d *DiscoveryProcedure) Step() error {
 	protocol := FindBestProtocol(MeshNodeId(d.currentDeviceId), d.network)
 	logger.Log().Printf("[%s] Start discover with protocol %d repetition %d", utils.FmtNodeId(d.currentDeviceId), protocol, d.repeat)
+	// The discovery can wait for the other traffic
+	ctx := WithTrafficClass(context.Background(), BackgroundTraffic)
 
-	_, err := d.serial.SendReceiveApiProt(DiscResetTableApiRequest{}, protocol, MeshNodeId(d.currentDeviceId), d.network)
+	_, err := d.serial.SendReceiveApiProtContext(ctx, DiscResetTableApiRequest{}, protocol, MeshNodeId(d.currentDeviceId), d.network)
 	if err != nil {
 		return err
 	}
 
-	_, err = d.serial.SendReceiveApiProt(DiscStartDiscoverApiRequest{Mask: 0, Filter: 0, Slotnum: 100}, protocol, MeshNodeId(d.currentDeviceId), d.network)
+	_, err = d.serial.SendReceiveApiProtContext(ctx, DiscStartDiscoverApiRequest{Mask: 0, Filter: 0, Slotnum: 100}, protocol, MeshNodeId(d.currentDeviceId), d.network)
 	if err != nil {
 		return err
 	}
 
 	// Get tag string from device and if the graph description is empty set the same as the tag on the device.
-	_reply, err := d.serial.SendReceiveApiProt(NodeGetTagApiRequest{}, protocol, MeshNodeId(d.currentDeviceId), d.network)
+	_reply, err := d.serial.SendReceiveApiProtContext(ctx, NodeGetTagApiRequest{}, protocol, MeshNodeId(d.currentDeviceId), d.network)
 	if err != nil {
 		return err
 	}
@@ -229,12 +240,14 @@ func (d *DiscoveryProcedure) Step() error {
 	}
 
 	if len(_device.Device().Tag()) == 0 {
-		_device.Device().SetTag(utils.TruncateZeros(tagReply.Tag))
+		d.network.UpdateNode(_device.ID(), func(device *gra.Device) {
+			device.SetTag(utils.TruncateZeros(tagReply.Tag))
+		})
 	}
 
 	time.Sleep(5 * time.Second)
 
-	reply1, err := d.serial.SendReceiveApiProt(DiscTableSizeApiRequest{}, protocol, MeshNodeId(d.currentDeviceId), d.network)
+	reply1, err := d.serial.SendReceiveApiProtContext(ctx, DiscTableSizeApiRequest{}, protocol, MeshNodeId(d.currentDeviceId), d.network)
 	if err != nil {
 		return err
 	}
@@ -247,7 +260,7 @@ func (d *DiscoveryProcedure) Step() error {
 	logger.Log().Printf("[%s] Discovered nodes: %d", utils.FmtNodeId(d.currentDeviceId), tableSize.Size)
 	for i := uint8(0); i < tableSize.Size; i++ {
 
-		reply1, err = d.serial.SendReceiveApiProt(DiscTableItemGetApiRequest{Index: i}, protocol, MeshNodeId(d.currentDeviceId), d.network)
+		reply1, err = d.serial.SendReceiveApiProtContext(ctx, DiscTableItemGetApiRequest{Index: i}, protocol, MeshNodeId(d.currentDeviceId), d.network)
 		if err != nil {
 			return err
 		}
@@ -264,11 +277,15 @@ func (d *DiscoveryProcedure) Step() error {
 
 func (d *DiscoveryProcedure) Clear() {
 	if d.network != nil {
-		nodes := d.network.Nodes()
-		for nodes.Next() {
-			node := nodes.Node().(gra.NodeDevice)
-			node.Device().SetDiscovered(false)
-		}
+		d.network.Update(func(tx *gra.NetworkTx) error {
+			nodes := tx.Nodes()
+			for nodes.Next() {
+				tx.UpdateNode(nodes.Node().ID(), func(device *gra.Device) {
+					device.SetDiscovered(false)
+				})
+			}
+			return nil
+		})
 	}
 	d.state = DiscoveryProcedureStateIdle
 }
@@ -284,9 +301,13 @@ func (d *DiscoveryProcedure) Save() error {
 	}
 
 	d.repeat++
-	node.Device().SetDiscovered(true)
-	neighborsToGraph(d.network, d.currentDeviceId, d.Neighbors)
-	return nil
+	return d.network.Update(func(tx *gra.NetworkTx) error {
+		tx.UpdateNode(node.ID(), func(device *gra.Device) {
+			device.SetDiscovered(true)
+		})
+		neighborsToGraph(tx, d.currentDeviceId, d.Neighbors)
+		return nil
+	})
 }
 
 func (d *DiscoveryProcedure) Run() {
@@ -304,9 +325,15 @@ func (d *DiscoveryProcedure) Run() {
 		}
 	}
 
-	gra.SetMainNetwork(d.network)
+	d.coordinator.SetNetwork(d.network)
+}
+
+func (d *DiscoveryProcedure) Coordinator() *Coordinator {
+	return d.coordinator
 }
 
-func NewDiscoveryProcedure(serial *SerialConnection, network *gra.Network, nodeid int64) *DiscoveryProcedure {
-	return &DiscoveryProcedure{serial: serial, network: network, currentDeviceId: 0, state: DiscoveryProcedureStateIdle, repeat: 0}
+// NewDiscoveryProcedure discovers the network of a coordinator, starting from a copy of its
+// network to refresh it or from scratch when the network is nil
+func NewDiscoveryProcedure(coordinator *Coordinator, network *gra.Network) *DiscoveryProcedure {
+	return &DiscoveryProcedure{coordinator: coordinator, serial: coordinator.Serial(), network: network, currentDeviceId: 0, state: DiscoveryProcedureStateIdle, repeat: 0}
 }
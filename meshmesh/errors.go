@@ -0,0 +1,23 @@
+package meshmesh
+
+import (
+	"errors"
+
+	"leguru.net/m/v2/graph"
+)
+
+// Errors returned by the mesh operations, they are wrapped with the details
+// of the failure and can be tested with errors.Is
+var (
+	ErrTimeout        = errors.New("reply timeout")
+	ErrSerialClosed   = errors.New("port is not open")
+	ErrDecode         = errors.New("can't decode frame")
+	ErrConnectionNack = errors.New("connection refused by the node")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrNodeNotFound   = graph.ErrNodeNotFound
+	ErrNodeInactive   = graph.ErrNodeInactive
+	ErrNoRoute        = graph.ErrNoRoute
+
+	// ErrPoliteBroadcastDisabled is returned until EnablePoliteBroadcast is called
+	ErrPoliteBroadcastDisabled = errors.New("polite broadcast is disabled, it must be enabled in the configuration")
+)
@@ -0,0 +1,208 @@
+package meshmesh
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"leguru.net/m/v2/logger"
+	"leguru.net/m/v2/utils"
+)
+
+// Time between two checks of the ports of a coordinator with a standby port
+const failoverCheckInterval = 1 * time.Second
+
+// FailbackPolicy tells when a coordinator returns to the primary port after a failover
+type FailbackPolicy int
+
+const (
+	// The standby port is kept until a failover is requested
+	FailbackManual FailbackPolicy = iota
+	// The primary port is used again when it has been answering for the failback delay
+	FailbackAuto
+)
+
+func (p FailbackPolicy) String() string {
+	switch p {
+	case FailbackManual:
+		return "manual"
+	case FailbackAuto:
+		return "auto"
+	}
+	return "unknown"
+}
+
+// ParseFailbackPolicy returns the policy with the given name, an empty name is the manual policy
+func ParseFailbackPolicy(name string) (FailbackPolicy, error) {
+	switch strings.ToLower(name) {
+	case "", "manual":
+		return FailbackManual, nil
+	case "auto":
+		return FailbackAuto, nil
+	}
+	return FailbackManual, fmt.Errorf("%w: unknown failback policy %s", ErrInvalidRequest, name)
+}
+
+// portFailed is true when the port is closed or its watchdog is trying to recover it
+func portFailed(port *CoordinatorPort) bool {
+	if !port.Serial.IsConnected() {
+		return true
+	}
+	if port.Watchdog == nil {
+		return false
+	}
+	state := port.Watchdog.Status().State
+	return state == HealthRecovering || state == HealthUnhealthy
+}
+
+// portUsable is true when the port is open, knows its node and the last check succeeded
+func portUsable(port *CoordinatorPort) bool {
+	if !port.Serial.IsConnected() || port.Serial.LocalNode.Load() == 0 {
+		return false
+	}
+	return port.Watchdog == nil || port.Watchdog.Ready()
+}
+
+// SetStandby adds a port kept open to replace the active one when it fails, the watchdog can be nil
+func (c *Coordinator) SetStandby(serial *SerialConnection, watchdog *CoordinatorWatchdog) {
+	c.portsLock.Lock()
+	c.standby = &CoordinatorPort{Serial: serial, Watchdog: watchdog}
+	c.portsLock.Unlock()
+}
+
+// FailedOver tells if the coordinator is using the standby port and since when
+func (c *Coordinator) FailedOver() (bool, time.Time) {
+	c.portsLock.Lock()
+	defer c.portsLock.Unlock()
+	return c.active != c.primary, c.switchedAt
+}
+
+// Failover swaps the active port with the standby one. The connected paths and the star path are moved to
+// the new port, the ESPHome clients are disconnected to open their connections again and the graphs are
+// re-rooted on the node of the new port, so that the paths are computed from it.
+func (c *Coordinator) Failover() error {
+	return c.switchPort("requested")
+}
+
+func (c *Coordinator) switchPort(reason string) error {
+	c.failover.Lock()
+	defer c.failover.Unlock()
+
+	c.portsLock.Lock()
+	active, standby := c.active, c.standby
+	c.portsLock.Unlock()
+
+	if standby == nil {
+		return fmt.Errorf("%w: coordinator %s has no standby port", ErrInvalidRequest, c.Name)
+	}
+
+	// The watchdogs don't reopen or reset the ports while they are switched
+	for _, port := range []*CoordinatorPort{active, standby} {
+		if port.Watchdog != nil {
+			port.Watchdog.Pause()
+			defer port.Watchdog.Resume()
+		}
+	}
+
+	if !portUsable(standby) {
+		return fmt.Errorf("%w: standby port of coordinator %s is not answering", ErrSerialClosed, c.Name)
+	}
+
+	// Close the connections while the old port can still tell the nodes
+	if c.Servers != nil {
+		c.Servers.CloseClients()
+	}
+
+	c.portsLock.Lock()
+	c.active, c.standby = standby, active
+	c.switchedAt = time.Now()
+	c.portsLock.Unlock()
+
+	if c.ConnectedPath != nil {
+		c.ConnectedPath.SetSerial(standby.Serial)
+		c.ConnectedPath.ClearConnections()
+	}
+	if c.StarPath != nil {
+		c.StarPath.SetSerial(standby.Serial)
+	}
+
+	nodeId := int64(standby.Serial.LocalNode.Load())
+	c.Network().LocalDeviceIdChanged(nodeId, standby.Serial.LocalNodeInfo())
+	if star := c.StarNetwork(); star != nil {
+		star.LocalDeviceIdChanged(nodeId, nil)
+	}
+
+	logger.Log().WithFields(logrus.Fields{"coordinator": c.Name, "reason": reason, "from": utils.FmtNodeId(int64(active.Serial.LastLocalNode())), "to": utils.FmtNodeId(nodeId)}).
+		Warn("Coordinator port switched")
+	return nil
+}
+
+// StartFailover checks the ports of the coordinator and switches to the standby port when the active one
+// fails. With the auto failback policy the primary port is used again after answering for failbackDelay.
+func (c *Coordinator) StartFailover(policy FailbackPolicy, failbackDelay time.Duration) {
+	c.portsLock.Lock()
+	if c.stopMonitor != nil {
+		c.portsLock.Unlock()
+		return
+	}
+	c.stopMonitor = make(chan struct{})
+	quit := c.stopMonitor
+	c.portsLock.Unlock()
+
+	go c.monitorPorts(policy, failbackDelay, quit)
+}
+
+func (c *Coordinator) StopFailover() {
+	c.portsLock.Lock()
+	defer c.portsLock.Unlock()
+	if c.stopMonitor != nil {
+		close(c.stopMonitor)
+		c.stopMonitor = nil
+	}
+}
+
+func (c *Coordinator) monitorPorts(policy FailbackPolicy, failbackDelay time.Duration, quit chan struct{}) {
+	ticker := time.NewTicker(failoverCheckInterval)
+	defer ticker.Stop()
+
+	var primaryUsableSince time.Time
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+		}
+
+		c.portsLock.Lock()
+		primary, active, standby := c.primary, c.active, c.standby
+		c.portsLock.Unlock()
+		if standby == nil {
+			continue
+		}
+
+		if portFailed(active) {
+			primaryUsableSince = time.Time{}
+			if portUsable(standby) {
+				if err := c.switchPort("active port failed"); err != nil {
+					logger.Log().WithFields(logrus.Fields{"coordinator": c.Name, "err": err}).Warn("Coordinator failover failed")
+				}
+			}
+			continue
+		}
+
+		if policy != FailbackAuto || active == primary {
+			continue
+		}
+		if !portUsable(primary) {
+			primaryUsableSince = time.Time{}
+		} else if primaryUsableSince.IsZero() {
+			primaryUsableSince = time.Now()
+		} else if time.Since(primaryUsableSince) >= failbackDelay {
+			primaryUsableSince = time.Time{}
+			if err := c.switchPort("failback"); err != nil {
+				logger.Log().WithFields(logrus.Fields{"coordinator": c.Name, "err": err}).Warn("Coordinator failback failed")
+			}
+		}
+	}
+}
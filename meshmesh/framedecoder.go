@@ -0,0 +1,155 @@
+package meshmesh
+
+import (
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+	"leguru.net/m/v2/logger"
+)
+
+const maxFrameLength = 1500
+
+// Longer text lines printed by the coordinator are split
+const maxConsoleLineLength = 256
+
+const (
+	waitStartByte = iota
+	escapeNextByte
+	waitEndByte
+	waitCrc16Byte1
+	waitCrc16Byte2
+	waitEndOfLine
+)
+
+// frameDecoder rebuilds the api frames from the escaped byte stream. The bytes outside the frames are
+// the text printed by the coordinator (boot messages, crash dumps), they are assembled in lines.
+type frameDecoder struct {
+	state         int
+	buffer        []byte
+	bufferPos     int
+	computedCrc16 uint16
+	receivedCrc16 uint16
+	// Called with every text line, the line is discarded when nil
+	lineReceived func(line []byte)
+}
+
+func (d *frameDecoder) emitLine() {
+	if d.lineReceived != nil && d.bufferPos > 0 {
+		line := make([]byte, d.bufferPos)
+		copy(line, d.buffer)
+		d.lineReceived(line)
+	}
+	d.bufferPos = 0
+}
+
+// flushLine emits the text line being received, used when the coordinator stops printing without a newline
+func (d *frameDecoder) flushLine() {
+	if d.state == waitEndOfLine {
+		d.emitLine()
+		d.state = waitStartByte
+	}
+}
+
+// putByte stores a byte of the frame or of the line, the decoder restarts when the buffer is full
+func (d *frameDecoder) putByte(b byte) bool {
+	if d.bufferPos >= len(d.buffer) {
+		logger.Log().WithField("len", d.bufferPos).Error("serial error: buffer full, data discarded")
+		d.state = waitStartByte
+		d.bufferPos = 0
+		return false
+	}
+	d.buffer[d.bufferPos] = b
+	d.bufferPos += 1
+	return true
+}
+
+func (d *frameDecoder) startFrame() {
+	d.bufferPos = 0
+	d.computedCrc16 = 0
+	d.state = waitEndByte
+}
+
+// decodeByte feeds a byte to the decoder and returns a copy of the unescaped frame once a frame is complete.
+// crcOk tells if the received crc16 matches the frame content.
+func (d *frameDecoder) decodeByte(b byte) (frame []byte, crcOk bool) {
+	switch d.state {
+	case waitStartByte:
+		switch b {
+		case startApiFrameCrc16:
+			d.startFrame()
+		case '\r', stopLogMsg:
+			// Empty line
+		default:
+			d.buffer[0] = b
+			d.bufferPos = 1
+			d.state = waitEndOfLine
+		}
+	case escapeNextByte:
+		d.state = waitEndByte
+		// And escaped byte is take as is not used for commands.
+		if d.putByte(b) {
+			d.computedCrc16 = crc16Byte(d.computedCrc16, b)
+		}
+	case waitCrc16Byte1:
+		d.receivedCrc16 = uint16(b) << 8
+		d.state = waitCrc16Byte2
+	case waitCrc16Byte2:
+		d.receivedCrc16 = d.receivedCrc16 | uint16(b)
+		d.state = waitStartByte
+		if d.bufferPos == 0 {
+			// The crc of an empty frame always matches, it is noise
+			break
+		}
+		frame = make([]byte, d.bufferPos)
+		copy(frame, d.buffer)
+		crcOk = d.receivedCrc16 == d.computedCrc16
+		if !crcOk {
+			logger.Log().WithFields(logrus.Fields{"len": d.bufferPos, "data": hex.EncodeToString(d.buffer[0:min(d.bufferPos, 10)])}).Trace("From serial")
+			logger.Log().WithFields(logrus.Fields{"receivedCrc16": d.receivedCrc16, "computedCrc16": d.computedCrc16}).Error("serial error: crc16 mismatch")
+		}
+		d.bufferPos = 0
+	case waitEndByte:
+		switch b {
+		case stopApiFrame:
+			// Wait for two more bytes to complete the crc16
+			d.state = waitCrc16Byte1
+		case escapeApiFrame:
+			d.state = escapeNextByte
+			d.computedCrc16 = crc16Byte(d.computedCrc16, b)
+		default:
+			if d.putByte(b) {
+				d.computedCrc16 = crc16Byte(d.computedCrc16, b)
+			}
+		}
+	case waitEndOfLine:
+		switch b {
+		case stopLogMsg:
+			d.emitLine()
+			d.state = waitStartByte
+		case startApiFrameCrc16:
+			// A frame interrupts the line
+			d.emitLine()
+			d.startFrame()
+		default:
+			if d.putByte(b) && d.bufferPos >= maxConsoleLineLength {
+				d.emitLine()
+			}
+		}
+	default:
+		logger.Log().WithField("state", d.state).Error("serial error: unexpected state")
+		d.state = waitStartByte
+		d.bufferPos = 0
+	}
+
+	if d.bufferPos >= maxFrameLength {
+		logger.Log().WithFields(logrus.Fields{"buffer": hex.EncodeToString(d.buffer)}).Error("Buffer overflow")
+		d.state = waitStartByte
+		d.bufferPos = 0
+	}
+
+	return frame, crcOk
+}
+
+func newFrameDecoder() *frameDecoder {
+	return &frameDecoder{state: waitStartByte, buffer: make([]byte, maxSerialInputBuffer)}
+}
@@ -0,0 +1,48 @@
+package meshmesh
+
+import (
+	"bytes"
+	"testing"
+)
+
+// decodeAll feeds the bytes to the decoder and returns the frames with a good crc
+func decodeAll(d *frameDecoder, data []byte) [][]byte {
+	frames := make([][]byte, 0)
+	for _, b := range data {
+		if frame, crcOk := d.decodeByte(b); frame != nil && crcOk {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+func TestFrameDecoder(t *testing.T) {
+	good := []byte{firmRevApiReply, '1', '.', '5'}
+	unterminated := append([]byte{startApiFrameCrc16}, bytes.Repeat([]byte{0x41}, 2*maxSerialInputBuffer)...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want [][]byte
+	}{
+		{"frame", NewApiFrame(good, false).Output(), [][]byte{good}},
+		{"empty frame", []byte{startApiFrameCrc16, stopApiFrame, 0, 0}, [][]byte{}},
+		{"text before frame", append([]byte("boot\n"), NewApiFrame(good, false).Output()...), [][]byte{good}},
+		{"unterminated frame", append(unterminated, NewApiFrame(good, false).Output()...), [][]byte{good}},
+		{"unterminated escape", append([]byte{startApiFrameCrc16}, bytes.Repeat([]byte{escapeApiFrame}, 2*maxSerialInputBuffer+1)...), [][]byte{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := decodeAll(newFrameDecoder(), test.data)
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d frames, want %d", len(got), len(test.want))
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], test.want[i]) {
+					t.Errorf("frame %d: got %x, want %x", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package meshmesh
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-restruct/restruct"
+	"google.golang.org/protobuf/proto"
+	pb "leguru.net/m/v2/meshmesh/pb"
+)
+
+// FrameDecodeFunc decodes the unescaped data of a received frame, the first byte is the frame id
+type FrameDecodeFunc func(data []byte) (any, error)
+
+// RawFrame is the value of the frames without a registered type. It can be sent as well.
+type RawFrame struct {
+	Id   uint8
+	Data []byte
+}
+
+type frameKey struct {
+	id      uint8
+	subtype uint8
+}
+
+// FrameReply is the frame awaited after a request
+type FrameReply struct {
+	Id      uint8
+	Subtype uint8
+}
+
+// NoReply declares a request without an awaited reply, the reply ids are odd and never 0
+var NoReply = FrameReply{}
+
+// Reply returns the awaited reply with the given id and subtype, 0 outside the frame families
+func Reply(id uint8, subtype uint8) FrameReply {
+	return FrameReply{Id: id, Subtype: subtype}
+}
+
+type frameRegistry struct {
+	lock     sync.RWMutex
+	families map[uint8]bool
+	requests map[reflect.Type]frameKey
+	awaited  map[frameKey]frameKey
+	replies  map[frameKey]FrameDecodeFunc
+}
+
+var registry = frameRegistry{
+	families: make(map[uint8]bool),
+	requests: make(map[reflect.Type]frameKey),
+	awaited:  make(map[frameKey]frameKey),
+	replies:  make(map[frameKey]FrameDecodeFunc),
+}
+
+// key returns the registry key of a frame, the subtype is used only by the frame families
+func (r *frameRegistry) key(data []byte) frameKey {
+	k := frameKey{id: data[0]}
+	if r.families[k.id] && len(data) > 1 {
+		k.subtype = data[1]
+	}
+	return k
+}
+
+// RegisterFrameFamily declares a frame id that uses the second byte to select the message
+func RegisterFrameFamily(id uint8) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.families[id] = true
+}
+
+// RegisterRequest registers a request struct and the reply it awaits, NoReply when it isn't answered. The struct
+// starts with the uint8 id field, in the families followed by the uint8 subtype field.
+func RegisterRequest(value any, id uint8, subtype uint8, reply FrameReply) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	key := frameKey{id, subtype}
+	registry.requests[reflect.TypeOf(value)] = key
+	registry.awaited[key] = frameKey{reply.Id, reply.Subtype}
+}
+
+// RegisterReply registers a reply decoded with restruct in a value of the same type of value
+func RegisterReply(value any, id uint8, subtype uint8) {
+	t := reflect.TypeOf(value)
+	RegisterReplyDecoder(id, subtype, func(data []byte) (any, error) {
+		v := reflect.New(t)
+		restruct.Unpack(data, binary.LittleEndian, v.Interface())
+		return v.Elem().Interface(), nil
+	})
+}
+
+// RegisterReplyDecoder registers a reply decoded by a custom function
+func RegisterReplyDecoder(id uint8, subtype uint8, decode FrameDecodeFunc) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	registry.replies[frameKey{id, subtype}] = decode
+}
+
+func (r *frameRegistry) encode(cmd any) ([]byte, error) {
+	if raw, ok := cmd.(RawFrame); ok {
+		return append([]byte{raw.Id}, raw.Data...), nil
+	}
+
+	r.lock.RLock()
+	key, ok := r.requests[reflect.TypeOf(cmd)]
+	family := r.families[key.id]
+	r.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: unknow type request", ErrInvalidRequest)
+	}
+
+	v := reflect.New(reflect.TypeOf(cmd))
+	v.Elem().Set(reflect.ValueOf(cmd))
+	b, err := restruct.Pack(binary.LittleEndian, v.Interface())
+	if err != nil || len(b) == 0 {
+		return b, err
+	}
+
+	b[0] = key.id
+	if family {
+		if len(b) < 2 {
+			return nil, fmt.Errorf("%w: missing subtype in request", ErrInvalidRequest)
+		}
+		b[1] = key.subtype
+	}
+	return b, nil
+}
+
+// awaitedReply returns the reply declared for the request, 0 for NoReply
+func (r *frameRegistry) awaitedReply(data []byte) (uint8, uint8) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	if reply, ok := r.awaited[r.key(data)]; ok {
+		return reply.id, reply.subtype
+	}
+
+	// The raw frames await the odd id following the request id, the same for the subtype
+	var wantType uint8 = data[0]&0xFE + 1
+	var wantSubtype uint8 = 0
+	if r.families[wantType] && len(data) > 1 {
+		wantSubtype = data[1]&0xFE + 1
+	}
+	return wantType, wantSubtype
+}
+
+func (r *frameRegistry) decode(data []byte) (any, error) {
+	r.lock.RLock()
+	decode, ok := r.replies[r.key(data)]
+	r.lock.RUnlock()
+
+	if !ok {
+		return RawFrame{Id: data[0], Data: data[1:]}, nil
+	}
+	return decode(data)
+}
+
+func decodeEchoApiReply(data []byte) (any, error) {
+	return EchoApiReply{Id: 0, Echo: string(data[1:])}, nil
+}
+
+func decodeFirmRevApiReply(data []byte) (any, error) {
+	return FirmRevApiReply{Id: 0, Revision: data[1:]}, nil
+}
+
+func decodeNodeInfo(data []byte) (any, error) {
+	v := pb.NodeInfo{}
+	if err := proto.Unmarshal(data[1:], &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func decodeNodePresentationRx(data []byte) (any, error) {
+	v := pb.NodePresentationRx{}
+	if err := proto.Unmarshal(data[1:], &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func decodeLogEventApiReply(data []byte) (any, error) {
+	v := LogEventApiReply{}
+	restruct.Unpack(data, binary.LittleEndian, &v)
+	if len(data) > 7 {
+		v.Line = string(data[7:])
+	}
+	return v, nil
+}
+
+func decodeConnectedPathApiReply(data []byte) (any, error) {
+	v := ConnectedPathApiReply{}
+	restruct.Unpack(data, binary.LittleEndian, &v)
+	if len(data) > 4 {
+		v.Data = data[4:]
+	}
+	return v, nil
+}
+
+func decodeUnicastReply(data []byte) (any, error) {
+	if len(data) < 5 {
+		return nil, errors.New("invalid unicast reply")
+	}
+	return UnicastReply{Id: data[0], Source: MeshNodeId(binary.LittleEndian.Uint32(data[1:5])), Payload: data[5:]}, nil
+}
+
+func decodeMultiPathReply(data []byte) (any, error) {
+	if len(data) < 5 {
+		return nil, errors.New("invalid multipath reply")
+	}
+	return MultiPathReply{Id: data[0], Source: MeshNodeId(binary.LittleEndian.Uint32(data[1:5])), Payload: data[5:]}, nil
+}
+
+func decodeBroadcastReply(data []byte) (any, error) {
+	if len(data) < 5 {
+		return nil, errors.New("invalid broadcast reply")
+	}
+	return BroadcastReply{Id: data[0], Source: MeshNodeId(binary.LittleEndian.Uint32(data[1:5])), Payload: data[5:]}, nil
+}
+
+func decodePoliteBroadcastReply(data []byte) (any, error) {
+	if len(data) < 5 {
+		return nil, errors.New("invalid polite broadcast reply")
+	}
+	return PoliteBroadcastReply{Id: data[0], Source: MeshNodeId(binary.LittleEndian.Uint32(data[1:5])), Payload: data[5:]}, nil
+}
+
+func init() {
+	RegisterFrameFamily(discoveryApiRequest)
+	RegisterFrameFamily(discoveryApiReply)
+	RegisterFrameFamily(flashOperationApiRequest)
+	RegisterFrameFamily(flashOperationApiReply)
+
+	RegisterRequest(EchoApiRequest{}, echoApiRequest, 0, Reply(echoApiReply, 0))
+	RegisterRequest(FirmRevApiRequest{}, firmRevApiRequest, 0, Reply(firmRevApiReply, 0))
+	RegisterRequest(NodeIdApiRequest{}, nodeIdApiRequest, 0, Reply(nodeIdApiReply, 0))
+	RegisterRequest(NodeGetTagApiRequest{}, nodeGetTagApiRequest, 0, Reply(nodeGetTagApiReply, 0))
+	RegisterRequest(NodeSetTagApiRequest{}, nodeSetTagApiRequest, 0, Reply(nodeSetTagApiReply, 0))
+	RegisterRequest(NodeBindClearApiRequest{}, nodeBindClearApiRequest, 0, Reply(nodeBindClearApiReply, 0))
+	RegisterRequest(NodeSetChannelApiRequest{}, nodeSetChannelApiRequest, 0, Reply(nodeSetChannelApiReply, 0))
+	RegisterRequest(NodeConfigApiRequest{}, nodeConfigApiRequest, 0, Reply(nodeConfigApiReply, 0))
+	RegisterRequest(ProtoNodeInfoApiRequest{}, protoNodeInfoApiRequest, 0, Reply(protoNodeInfoApiReply, 0))
+	RegisterRequest(NodeRebootApiRequest{}, nodeRebootApiRequest, 0, Reply(nodeRebootApiReply, 0))
+	RegisterRequest(EntitiesCountApiRequest{}, entitiesCountApiRequest, 0, Reply(entitiesCountApiReply, 0))
+	RegisterRequest(EntityHashApiRequest{}, entityHashApiRequest, 0, Reply(entityHashApiReply, 0))
+	RegisterRequest(GetEntityStateApiRequest{}, getEntityStateApiRequest, 0, Reply(getEntityStateApiReply, 0))
+	RegisterRequest(SetEntityStateApiRequest{}, setEntityStateApiRequest, 0, Reply(setEntityStateApiReply, 0))
+	// The connected path replies are delivered to the connections, not to the requests
+	RegisterRequest(ConnectedPathApiRequest{}, connectedPathApiRequest, 0, NoReply)
+	RegisterRequest(ConnectedPathApiRequest2{}, connectedPathApiRequest, 0, NoReply)
+	// The envelopes await the reply of the wrapped request, see ApiFrame.AwaitedReply
+	RegisterRequest(UnicastRequest{}, connectedUnicastRequest, 0, Reply(connectedUnicastReply, 0))
+	RegisterRequest(MultiPathRequest{}, multipathRequest, 0, Reply(multipathReply, 0))
+	RegisterRequest(BroadcastRequest{}, broadcastRequest, 0, Reply(broadcastReply, 0))
+	RegisterRequest(PoliteBroadcastRequest{}, politeBroadcastRequest, 0, Reply(politeBroadcastReply, 0))
+	RegisterRequest(DiscResetTableApiRequest{}, discoveryApiRequest, discResetTableApiRequest, Reply(discoveryApiReply, discResetTableApiReply))
+	RegisterRequest(DiscTableSizeApiRequest{}, discoveryApiRequest, discTableSizeApiRequest, Reply(discoveryApiReply, discTableSizeApiReply))
+	RegisterRequest(DiscTableItemGetApiRequest{}, discoveryApiRequest, discTableItemGetApiRequest, Reply(discoveryApiReply, discTableItemGetApiReply))
+	RegisterRequest(DiscStartDiscoverApiRequest{}, discoveryApiRequest, discStartDiscoverApiRequest, Reply(discoveryApiReply, discStartDiscoverApiReply))
+	RegisterRequest(FlashGetMd5ApiRequest{}, flashOperationApiRequest, flashGetMd5Api, Reply(flashOperationApiReply, flashGetMd5Api))
+	RegisterRequest(FlashEraseApiRequest{}, flashOperationApiRequest, flashEraseApi, Reply(flashOperationApiReply, flashEraseApi))
+	RegisterRequest(FlashWriteApiRequest{}, flashOperationApiRequest, flashWriteApi, Reply(flashOperationApiReply, flashWriteApi))
+	RegisterRequest(FlashEBootApiRequest{}, flashOperationApiRequest, flashEBootApiRequest, Reply(flashOperationApiReply, flashEBootApiRequest))
+
+	RegisterReplyDecoder(echoApiReply, 0, decodeEchoApiReply)
+	RegisterReplyDecoder(firmRevApiReply, 0, decodeFirmRevApiReply)
+	RegisterReply(NodeIdApiReply{}, nodeIdApiReply, 0)
+	RegisterReply(NodeGetTagApiReply{}, nodeGetTagApiReply, 0)
+	RegisterReply(NodeSetTagApiReply{}, nodeSetTagApiReply, 0)
+	RegisterReply(NodeBindClearApiReply{}, nodeBindClearApiReply, 0)
+	RegisterReply(NodeSetChannelApiReply{}, nodeSetChannelApiReply, 0)
+	RegisterReply(NodeConfigApiReply{}, nodeConfigApiReply, 0)
+	RegisterReplyDecoder(protoNodeInfoApiReply, 0, decodeNodeInfo)
+	RegisterReply(NodeRebootApiReply{}, nodeRebootApiReply, 0)
+	RegisterReply(EntitiesCountApiReply{}, entitiesCountApiReply, 0)
+	RegisterReply(EntityHashApiReply{}, entityHashApiReply, 0)
+	RegisterReply(GetEntityStateApiReply{}, getEntityStateApiReply, 0)
+	RegisterReply(SetEntityStateApiReply{}, setEntityStateApiReply, 0)
+	RegisterReplyDecoder(logEventApiReply, 0, decodeLogEventApiReply)
+	RegisterReplyDecoder(protoPresentationRxApiReply, 0, decodeNodePresentationRx)
+	RegisterReplyDecoder(connectedUnicastReply, 0, decodeUnicastReply)
+	RegisterReplyDecoder(multipathReply, 0, decodeMultiPathReply)
+	RegisterReplyDecoder(broadcastReply, 0, decodeBroadcastReply)
+	RegisterReplyDecoder(politeBroadcastReply, 0, decodePoliteBroadcastReply)
+	RegisterReplyDecoder(connectedPathApiReply, 0, decodeConnectedPathApiReply)
+	RegisterReply(DiscResetTableApiReply{}, discoveryApiReply, discResetTableApiReply)
+	RegisterReply(DiscTableSizeApiReply{}, discoveryApiReply, discTableSizeApiReply)
+	RegisterReply(DiscTableItemGetApiReply{}, discoveryApiReply, discTableItemGetApiReply)
+	RegisterReply(DiscStartDiscoverApiReply{}, discoveryApiReply, discStartDiscoverApiReply)
+	RegisterReply(DiscAssociateApiReply{}, discoveryApiReply, discAssociateApiReply)
+	RegisterReply(FlashGetMd5ApiReply{}, flashOperationApiReply, flashGetMd5Api)
+	RegisterReply(FlashEraseApiReply{}, flashOperationApiReply, flashEraseApi)
+	RegisterReply(FlashWriteApiReply{}, flashOperationApiReply, flashWriteApi)
+	RegisterReply(FlashEBootApiReply{}, flashOperationApiReply, flashEBootApiRequest)
+}
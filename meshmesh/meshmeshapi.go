@@ -1,17 +1,18 @@
 package meshmesh
 
 import (
-	"container/list"
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-restruct/restruct"
 	"github.com/sirupsen/logrus"
-	"go.bug.st/serial"
 	"leguru.net/m/v2/graph"
 	"leguru.net/m/v2/logger"
 	pb "leguru.net/m/v2/meshmesh/pb"
@@ -21,22 +22,66 @@ import (
 const defaultSessionMaxTimeoutMs = 500
 const maxSerialInputBuffer = 8192
 
+// Bytes read from the port with a single call
+const serialReadChunk = 512
+
+// The reader checks if the port is still open at least every read timeout
+const serialReadTimeout = 100 * time.Millisecond
+
+// Maximum number of requests waiting for a reply at the same time
+const maxInflightSessions = 4
+
 type SerialSession struct {
 	Request      *ApiFrame
 	Reply        *ApiFrame
+	Target       MeshNodeId
 	WaitReply1   uint8
 	WaitReply2   uint8
-	Wait         sync.WaitGroup
 	SentTime     time.Time
 	MaxTimeoutMs int64
+	// Deadline replaces MaxTimeoutMs when not zero
+	Deadline time.Time
+	// Collect keeps the session in flight until it expires and stores the first reply of every node
+	Collect bool
+	Replies map[MeshNodeId]*ApiFrame
+	// Class decides the priority of the session, Hops estimates its airtime
+	Class TrafficClass
+	Hops  int
+	done  chan struct{}
 }
 
 func (session *SerialSession) IsAwaitable() bool {
 	return session.WaitReply1 > 0
 }
 
+// complete sets the reply, nil on timeout, and wakes up the requester
+func (session *SerialSession) complete(reply *ApiFrame) {
+	session.Reply = reply
+	close(session.done)
+}
+
+// deadline returns the time when a session in flight expires
+func (session *SerialSession) deadline() time.Time {
+	if !session.Deadline.IsZero() {
+		return session.Deadline
+	}
+	return session.SentTime.Add(time.Duration(session.MaxTimeoutMs) * time.Millisecond)
+}
+
+// earliest returns the first of two times, a zero time means never
+func earliest(a time.Time, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func (session *SerialSession) isExpired(now time.Time) bool {
+	return now.After(session.deadline())
+}
+
 func NewSimpleSerialSession(request *ApiFrame) *SerialSession {
-	s := SerialSession{Request: request, MaxTimeoutMs: defaultSessionMaxTimeoutMs}
+	s := SerialSession{Request: request, Target: request.Target(), MaxTimeoutMs: defaultSessionMaxTimeoutMs, Class: ControlTraffic, Hops: request.Hops()}
 	return &s
 }
 
@@ -45,12 +90,38 @@ func NewSerialSession(request *ApiFrame) (*SerialSession, error) {
 	if err != nil {
 		return nil, err
 	}
-	s := SerialSession{Request: request, WaitReply1: w1, WaitReply2: w2}
+	if w1 == 0 {
+		return nil, fmt.Errorf("%w: the request has no reply", ErrInvalidRequest)
+	}
+	s := SerialSession{Request: request, Target: request.Target(), WaitReply1: w1, WaitReply2: w2, Class: ControlTraffic, Hops: request.Hops(), done: make(chan struct{})}
 	s.MaxTimeoutMs = defaultSessionMaxTimeoutMs
 	s.SentTime = time.Now()
 	return &s, nil
 }
 
+// isFrom tells if a reply with a known source can be the answer to this session
+func (session *SerialSession) isFrom(source MeshNodeId, localNode uint32) bool {
+	if session.Target == BroadcastAddress {
+		// Only the replies that tell which node sent them can be collected
+		return source != 0 && uint32(source) != localNode
+	}
+	if source == 0 {
+		return true
+	}
+	if session.Target == 0 {
+		return localNode == 0 || uint32(source) == localNode
+	}
+	return source == session.Target
+}
+
+// expiredSession remembers a timed out session to recognize its reply if it arrives late
+type expiredSession struct {
+	Target     MeshNodeId
+	WaitReply1 uint8
+	WaitReply2 uint8
+	Until      time.Time
+}
+
 type FrameReceivedCallback struct {
 	FrameType    uint8
 	FrameSubtype uint8
@@ -58,38 +129,42 @@ type FrameReceivedCallback struct {
 }
 
 type SerialConnection struct {
-	isPortOpen            bool
-	port                  serial.Port
+	isPortOpen            atomic.Bool
+	portRoutines          sync.WaitGroup
+	port                  Transport
 	portName              string
 	baudRate              int
-	isEsp8266             bool
+	isEsp8266             atomic.Bool
 	pulseResetOnOpen      bool
 	txOneByteMs           int
 	debug                 bool
+	capture               *FrameCapture
+	recorder              *StreamRecorder
+	politeBroadcast       atomic.Bool
+	NodeLogs              *NodeLogStore
+	Console               *ConsoleBuffer
 	incoming              chan []byte
-	session               *SerialSession
-	Sessions              *list.List
+	inflight              map[MeshNodeId]*SerialSession
+	writerWakeup          chan struct{}
+	portClosed            chan struct{}
+	expired               []expiredSession
+	lateReplies           uint64
+	wrappedReplies        atomic.Bool
+	scheduler             *trafficScheduler
 	SessionsLock          sync.Mutex
 	NextHandle            uint16
-	LocalNode             uint32
+	LocalNode             atomic.Uint32
 	DiscAssociateFn       func(*DiscAssociateApiReply, *SerialConnection)
 	ProtoPresentationFn   func(*pb.NodePresentationRx, *SerialConnection)
 	FrameReceivedCallback []FrameReceivedCallback
 	localNodeIdChangedCb  func(meshNodeId MeshNodeId, nodeInfo *pb.NodeInfo)
+	localNodeInfo         atomic.Pointer[pb.NodeInfo]
+	lastLocalNode         atomic.Uint32
 	lastUseTime           time.Time
 }
 
-const (
-	waitStartByte = iota
-	escapeNextByte
-	waitEndByte
-	waitCrc16Byte1
-	waitCrc16Byte2
-	waitEndOfLine
-)
-
 func (serialConn *SerialConnection) IsConnected() bool {
-	return serialConn.isPortOpen
+	return serialConn.isPortOpen.Load()
 }
 
 func (serialConn *SerialConnection) TryReconnect() {
@@ -109,6 +184,9 @@ func (serialConn *SerialConnection) GetNextHandle() uint16 {
 }
 
 func (serialConn *SerialConnection) ReadFrame(buffer []byte) {
+	if len(buffer) == 0 {
+		return
+	}
 	frame := NewApiFrame(buffer, true)
 	if buffer[0] != logEventApiReply {
 		logger.Log().WithFields(logrus.Fields{"len": len(frame.data), "data": hex.EncodeToString(frame.data[0:min(len(frame.data), 10)])}).Trace("From serial")
@@ -123,156 +201,328 @@ func (serialConn *SerialConnection) ReadFrame(buffer []byte) {
 			lo, ok := v.(LogEventApiReply)
 			if !ok {
 				logger.Log().Error("Can't decode incoming log packet 2/2")
+			} else {
+				logger.Log().WithFields(logrus.Fields{"from": lo.From}).Debug(lo.Line)
+				serialConn.NodeLogs.Add(lo.From, lo.Level, lo.Line)
 			}
-			logger.Log().WithFields(logrus.Fields{"from": lo.From}).Debug(lo.Line)
 		}
 	default:
 		// Handle session pacekts next
-		if serialConn.session != nil {
-			if serialConn.session.WaitReply1 > 0 {
-				if frame.AssertType(serialConn.session.WaitReply1, serialConn.session.WaitReply2) {
-					serialConn.session.Reply = frame
-					serialConn.session.Wait.Done()
-					serialConn.session = nil
-				} else {
-					logger.Log().WithFields(logrus.Fields{"Type": serialConn.session.WaitReply1, "Subtype": serialConn.session.WaitReply2}).Error("Serial reply assertion failed")
-				}
+		broadcast := buffer[0] == broadcastReply || buffer[0] == politeBroadcastReply
+		var source MeshNodeId
+		source, frame = serialConn.replySource(frame)
+		if session := serialConn.matchSession(frame, source, broadcast); session != nil {
+			if !session.Collect {
+				session.complete(frame)
+				serialConn.wakeupWriter()
+			}
+			return
+		}
+
+		if serialConn.dropLateReply(frame, source, broadcast) {
+			return
+		}
+
+		if frame.AssertType(discoveryApiReply, discResetTableApiReply) {
+			vv := DiscAssociateApiReply{}
+			restruct.Unpack(frame.data, binary.LittleEndian, &vv)
+			if serialConn.DiscAssociateFn != nil {
+				serialConn.DiscAssociateFn(&vv, serialConn)
 			}
 		} else {
-			if frame.AssertType(discoveryApiReply, discResetTableApiReply) {
-				vv := DiscAssociateApiReply{}
-				restruct.Unpack(frame.data, binary.LittleEndian, &vv)
-				if serialConn.DiscAssociateFn != nil {
-					serialConn.DiscAssociateFn(&vv, serialConn)
-				}
-			} else {
-				for _, callback := range serialConn.FrameReceivedCallback {
-					if frame.AssertType(callback.FrameType, callback.FrameSubtype) {
-						decoded, err := frame.Decode()
-						if err != nil {
-							logger.Log().Error("Can't decode incoming connectedpath packet 1/2")
-						} else {
-							callback.Callback(decoded)
-						}
-						return
+			for _, callback := range serialConn.FrameReceivedCallback {
+				if frame.AssertType(callback.FrameType, callback.FrameSubtype) {
+					decoded, err := frame.Decode()
+					if err != nil {
+						logger.Log().Error("Can't decode incoming connectedpath packet 1/2")
+					} else {
+						callback.Callback(decoded)
 					}
+					return
+				}
+			}
+			logger.Log().WithField("type", fmt.Sprintf("%02X", frame.data[0])).Error("Unused packet received")
+		}
+	}
+}
+
+// replySource returns the node that sent the frame and the frame without the unicast or multipath envelope
+func (serialConn *SerialConnection) replySource(frame *ApiFrame) (MeshNodeId, *ApiFrame) {
+	source, reply := frame.ReplySource()
+	if reply != frame && frame.data[0] != broadcastReply && frame.data[0] != politeBroadcastReply {
+		serialConn.wrappedReplies.Store(true)
+	} else if source == 0 && serialConn.wrappedReplies.Load() {
+		// The coordinator wraps the replies of the remote nodes, a bare reply comes from the coordinator itself
+		source = MeshNodeId(serialConn.LocalNode.Load())
+	}
+	return source, reply
+}
+
+// matchSession removes from the in flight sessions and returns the one waiting for the received frame,
+// the replies in the broadcast envelope are matched only to the broadcast sessions
+func (serialConn *SerialConnection) matchSession(frame *ApiFrame, source MeshNodeId, broadcast bool) *SerialSession {
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+
+	for target, session := range serialConn.inflight {
+		if frame.AssertType(session.WaitReply1, session.WaitReply2) && broadcast == (session.Target == BroadcastAddress) && session.isFrom(source, serialConn.LocalNode.Load()) {
+			if session.Collect {
+				if _, ok := session.Replies[source]; !ok {
+					session.Replies[source] = frame
 				}
-				logger.Log().WithField("type", fmt.Sprintf("%02X", buffer[0])).Error("Unused packet received")
+				return session
 			}
+			delete(serialConn.inflight, target)
+			return session
 		}
 	}
+	return nil
 }
 
-func (conn *SerialConnection) checkSessionTimeout() {
-	if conn.session != nil {
-		if time.Since(conn.session.SentTime).Milliseconds() > conn.session.MaxTimeoutMs {
-			conn.session.Reply = nil
-			if conn.session.WaitReply1 > 0 {
-				conn.session.Wait.Done()
+// dropLateReply discards a reply that belongs to a session already timed out. A reply with a known source
+// matches only the sessions to that node, the stragglers of a broadcast come in the broadcast envelope.
+func (serialConn *SerialConnection) dropLateReply(frame *ApiFrame, source MeshNodeId, broadcast bool) bool {
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+
+	localNode := MeshNodeId(serialConn.LocalNode.Load())
+	for i, expired := range serialConn.expired {
+		if !frame.AssertType(expired.WaitReply1, expired.WaitReply2) || broadcast != (expired.Target == BroadcastAddress) {
+			continue
+		}
+		if !broadcast {
+			if source != 0 && source != expired.Target && (expired.Target != 0 || source != localNode) {
+				continue
 			}
-			conn.session = nil
+			// Every node can still answer to a broadcast, the other sessions get a single reply
+			serialConn.expired = append(serialConn.expired[:i], serialConn.expired[i+1:]...)
 		}
+		serialConn.lateReplies += 1
+		logger.Log().WithFields(logrus.Fields{"target": utils.FmtNodeId(int64(expired.Target)), "source": utils.FmtNodeId(int64(source)), "type": frame.data[0], "late": serialConn.lateReplies}).Warn("Late reply dropped")
+		return true
 	}
+	return false
 }
 
-func (serialConn *SerialConnection) processSerialBuffer(buffer []byte, bufferPos int) {
-	destination := make([]byte, bufferPos)
-	copy(destination, buffer)
-	serialConn.ReadFrame(destination)
+// LateReplies returns the number of replies dropped because their session was already timed out
+func (serialConn *SerialConnection) LateReplies() uint64 {
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+	return serialConn.lateReplies
 }
 
-func (serialConn *SerialConnection) Read() {
-	var computedCrc16 uint16
-	var receivedCrc16 uint16
-	var inputBufferPos int
-	inputBuffer := make([]byte, maxSerialInputBuffer)
-	var decodeState int = waitStartByte
-	serialConn.port.ResetInputBuffer()
+// expireSession remembers a session removed while in flight, a late reply is still expected for another timeout period
+func (conn *SerialConnection) expireSession(session *SerialSession, now time.Time) {
+	conn.expired = append(conn.expired, expiredSession{
+		Target:     session.Target,
+		WaitReply1: session.WaitReply1,
+		WaitReply2: session.WaitReply2,
+		Until:      now.Add(time.Duration(session.MaxTimeoutMs) * time.Millisecond),
+	})
+}
+
+// cancelSession removes a session from the queue or from the sessions in flight.
+// It returns false if the session was already completed.
+func (serialConn *SerialConnection) cancelSession(session *SerialSession) bool {
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+
+	if serialConn.inflight[session.Target] == session {
+		delete(serialConn.inflight, session.Target)
+		serialConn.expireSession(session, time.Now())
+		return true
+	}
+
+	return serialConn.scheduler.remove(session)
+}
+
+// checkSessionTimeout completes the sessions in flight past their deadline. It returns the
+// next deadline of the sessions in flight or of the expired ones, zero when there is none.
+func (conn *SerialConnection) checkSessionTimeout() time.Time {
+	conn.SessionsLock.Lock()
+	defer conn.SessionsLock.Unlock()
+
+	now := time.Now()
+	var next time.Time
+	for target, session := range conn.inflight {
+		if session.isExpired(now) {
+			logger.Log().WithFields(logrus.Fields{"target": utils.FmtNodeId(int64(target)), "Type": session.WaitReply1, "Subtype": session.WaitReply2}).Debug("Serial session timeout")
+			delete(conn.inflight, target)
+			conn.expireSession(session, now)
+			session.complete(nil)
+		} else {
+			next = earliest(next, session.deadline())
+		}
+	}
+
+	expired := conn.expired[:0]
+	for _, e := range conn.expired {
+		if now.Before(e.Until) {
+			expired = append(expired, e)
+			// A session held back by this one can be sent when it is forgotten
+			next = earliest(next, e.Until)
+		}
+	}
+	conn.expired = expired
+	return next
+}
+
+// isReplyAmbiguous tells if a reply to the session could be mistaken for the reply of another node,
+// either a session in flight or a timed out one whose reply can still arrive.
+func (serialConn *SerialConnection) isReplyAmbiguous(session *SerialSession) bool {
+	for _, other := range serialConn.inflight {
+		// The replies to a broadcast come from any node
+		if (session.Target == BroadcastAddress || other.Target == BroadcastAddress) && other.WaitReply1 == session.WaitReply1 && other.WaitReply2 == session.WaitReply2 {
+			return true
+		}
+	}
+
+	if session.WaitReply1 == nodeIdApiReply || (serialConn.wrappedReplies.Load() && session.Target != 0) {
+		// The reply tells which node sent it
+		return false
+	}
+
+	for _, other := range serialConn.inflight {
+		if other.WaitReply1 == session.WaitReply1 && other.WaitReply2 == session.WaitReply2 {
+			return true
+		}
+	}
+	for _, other := range serialConn.expired {
+		// The late replies to a broadcast come in the broadcast envelope
+		if other.Target != session.Target && other.Target != BroadcastAddress && other.WaitReply1 == session.WaitReply1 && other.WaitReply2 == session.WaitReply2 {
+			return true
+		}
+	}
+	return false
+}
 
-	for serialConn.isPortOpen {
-		var buffer = make([]byte, 1)
-		// Read a byte from serial with a timout of a time slot
-		serialConn.port.SetReadTimeout(50 * time.Millisecond)
+// nextSession removes from the queues the first session that can be sent now, the queues are
+// visited from the highest priority class and skipped while their class is over its airtime budget.
+// A session is held back while its target has a request in flight, or an older or more urgent
+// request still queued, so that every node handles one request at a time. When the replies don't
+// tell which node sent them two sessions never wait for the same reply type at once.
+// It also returns when a class over budget can send again, zero if no class is waiting for it.
+func (serialConn *SerialConnection) nextSession() (*SerialSession, time.Time) {
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+
+	if len(serialConn.inflight) >= maxInflightSessions {
+		return nil, time.Time{}
+	}
+
+	now := time.Now()
+	var wakeup time.Time
+	busy := make(map[MeshNodeId]bool)
+	for class := range serialConn.scheduler.classes {
+		state := &serialConn.scheduler.classes[class]
+		if state.queue.Len() == 0 {
+			continue
+		}
+
+		state.settle(now)
+		if until := state.blockedUntil(now); !until.IsZero() {
+			wakeup = earliest(wakeup, until)
+			continue
+		}
+
+		for element := state.queue.Front(); element != nil; element = element.Next() {
+			session := element.Value.(*SerialSession)
+			_, inflight := serialConn.inflight[session.Target]
+			if inflight || busy[session.Target] || (session.IsAwaitable() && serialConn.isReplyAmbiguous(session)) {
+				busy[session.Target] = true
+				continue
+			}
+
+			state.queue.Remove(element)
+			state.charge(session.airtime())
+			if session.IsAwaitable() {
+				// Reserve the target before to release the lock
+				session.SentTime = now
+				serialConn.inflight[session.Target] = session
+			}
+			return session, time.Time{}
+		}
+	}
+
+	return nil, wakeup
+}
+
+// releaseSessions completes with a nil reply all the sessions still waiting
+func (serialConn *SerialConnection) releaseSessions() {
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+
+	for target, session := range serialConn.inflight {
+		delete(serialConn.inflight, target)
+		session.complete(nil)
+	}
+	serialConn.expired = nil
+
+	for _, session := range serialConn.scheduler.drain() {
+		if session.IsAwaitable() {
+			session.complete(nil)
+		}
+	}
+}
+
+// consoleLine stores a text line printed by the coordinator, it is added to the logs of the coordinator node
+// when the node is known
+func (serialConn *SerialConnection) consoleLine(line []byte) {
+	text := cleanConsoleLine(line)
+	if text == "" {
+		return
+	}
+	level := consoleLineLevel(text)
+	node := MeshNodeId(serialConn.lastLocalNode.Load())
+	serialConn.Console.Add(node, level, text)
+	if node != 0 {
+		serialConn.NodeLogs.Add(node, level, text)
+	}
+
+	entry := logger.Log().WithField("port", serialConn.portName)
+	switch level {
+	case NodeLogLevelError:
+		entry.Error("Coordinator console: " + text)
+	case NodeLogLevelWarn:
+		entry.Warn("Coordinator console: " + text)
+	default:
+		entry.Info("Coordinator console: " + text)
+	}
+}
+
+func (serialConn *SerialConnection) Read() {
+	defer serialConn.portRoutines.Done()
+	decoder := newFrameDecoder()
+	decoder.lineReceived = serialConn.consoleLine
+	buffer := make([]byte, serialReadChunk)
+	serialConn.port.SetReadTimeout(serialReadTimeout)
+
+	for serialConn.isPortOpen.Load() {
+		// Read all the bytes available, the call returns as soon as some data is received
 		n, err := serialConn.port.Read(buffer)
 		if err != nil {
 			logger.Log().WithField("err", err).Warn("SerialConnection.Read: error reading from serial port")
 			break
 		}
-
 		if n == 0 {
-			// We don't receive any data check if we want a reply
-			serialConn.checkSessionTimeout()
-		} else if n > 0 {
-			b := buffer[0]
-			switch decodeState {
-			case waitStartByte:
-				switch b {
-				case startApiFrameCrc16:
-					inputBufferPos = 0
-					computedCrc16 = 0
-					decodeState = waitEndByte
-				default:
-					logger.Log().WithField("b", fmt.Sprintf("0x%02X ", b)).Error("serial error: received a character outside a frame")
-				}
-			case escapeNextByte:
-				decodeState = waitEndByte
-				// And escaped byte is take as is not used for commands.
-				inputBuffer[inputBufferPos] = b
-				computedCrc16 = crc16Byte(computedCrc16, b)
-				inputBufferPos += 1
-			case waitCrc16Byte1:
-				receivedCrc16 = uint16(b) << 8
-				decodeState = waitCrc16Byte2
-			case waitCrc16Byte2:
-				receivedCrc16 = receivedCrc16 | uint16(b)
-				if receivedCrc16 == computedCrc16 {
-					decodeState = waitStartByte
-					serialConn.processSerialBuffer(inputBuffer, inputBufferPos)
-					inputBufferPos = 0
-				} else {
-					decodeState = waitStartByte
-					logger.Log().WithFields(logrus.Fields{"len": inputBufferPos, "data": hex.EncodeToString(inputBuffer[0:min(inputBufferPos, 10)])}).Trace("From serial")
-					logger.Log().WithFields(logrus.Fields{"receivedCrc16": receivedCrc16, "computedCrc16": computedCrc16}).Error("serial error: crc16 mismatch")
-					inputBufferPos = 0
-				}
-			case waitEndByte:
-				switch b {
-				case stopApiFrame:
-					// Wait for two more bytes to complete the crc16
-					decodeState = waitCrc16Byte1
-				case escapeApiFrame:
-					decodeState = escapeNextByte
-					computedCrc16 = crc16Byte(computedCrc16, b)
-				default:
-					inputBuffer[inputBufferPos] = b
-					inputBufferPos += 1
-					computedCrc16 = crc16Byte(computedCrc16, b)
+			// The coordinator is silent, don't wait the end of an unterminated line
+			decoder.flushLine()
+		}
+
+		for _, b := range buffer[:n] {
+			frame, crcOk := decoder.decodeByte(b)
+			if frame != nil {
+				if serialConn.capture != nil {
+					serialConn.capture.WriteInbound(frame, crcOk)
 				}
-			case waitEndOfLine:
-				if b == stopLogMsg {
-					destination := make([]byte, inputBufferPos)
-					copy(destination, inputBuffer)
-					fmt.Println("==> " + string(destination))
-					decodeState = waitStartByte
-				} else {
-					inputBuffer[inputBufferPos] = b
-					inputBufferPos += 1
+				if crcOk {
+					serialConn.ReadFrame(frame)
 				}
-			default:
-				logger.Log().WithField("state", decodeState).Error("serial error: unexpected state")
-				decodeState = waitStartByte
-				inputBufferPos = 0
-			}
-
-			if inputBufferPos >= 1500 {
-				logger.Log().WithFields(logrus.Fields{"buffer": hex.EncodeToString(inputBuffer)}).Error("Buffer overflow")
-				decodeState = waitStartByte
-				inputBufferPos = 0
 			}
 		}
 	}
 
-	if serialConn.isPortOpen {
+	if serialConn.isPortOpen.Load() {
 		logger.Log().Warn("SerialConnection.Read: closing serial port")
 		serialConn.closePort()
 	}
@@ -280,89 +530,103 @@ func (serialConn *SerialConnection) Read() {
 	logger.Log().Warn("SerialConnection.Read go routine terminated")
 }
 
+// Write sends the queued sessions and expires the sessions in flight. It sleeps until a session
+// is queued or completed, the next session deadline or the end of the guard time.
 func (serialConn *SerialConnection) Write() {
-	for serialConn.isPortOpen {
-		// If we are idle
-		if serialConn.session == nil {
-			// And there is not more work to do
-			if serialConn.Sessions.Len() == 0 {
-				// Sleep a time slot
-				time.Sleep(50 * time.Millisecond)
-			} else {
-				// We are idle but we have work to do...
-				serialConn.SessionsLock.Lock()
-				element := serialConn.Sessions.Front().Value
-				// Remove from sessions list
-				serialConn.Sessions.Remove(serialConn.Sessions.Front())
-				serialConn.SessionsLock.Unlock()
-
-				if element == nil {
-					// Ok we don't really need this
-					logger.Log().WithFields(logrus.Fields{"queue": serialConn.Sessions.Len()}).Error("got session with nil value")
-					// Sleep a time slot
-					time.Sleep(50 * time.Millisecond)
-				} else {
-					// Get next session and remove from list
-					session, ok := element.(*SerialSession)
-
-					if ok {
-						b := session.Request.Output()
-						level := logger.Log().GetLevel()
-						if level >= logrus.TraceLevel {
-							logger.Log().WithFields(logrus.Fields{"len": len(b), "data": hex.EncodeToString(b[0:min(len(b), 32)])}).Trace("To serial")
-						}
-
-						writed, err := serialConn.port.Write(b)
-
-						if err != nil {
-							logger.Log().WithField("err", err).Error("Write to serial port error")
-							break
-						}
-
-						if writed < len(b) {
-							logger.Log().WithFields(logrus.Fields{"sent": writed, "want": len(b)}).Error("Write to serial port incomplete")
-							break
-						}
-
-						if session.WaitReply1 > 0 {
-							// If we need a reply mark we as busy
-							serialConn.session = session
-						} else {
-							// Sleep a time slot beofre send next session
-							// Is a guard time for wifi retransmissions
-							time.Sleep(50 * time.Millisecond)
-						}
-					} else {
-						// Ok we don't really need this
-						logger.Log().WithFields(logrus.Fields{"queue": serialConn.Sessions.Len(), "val": element}).Error("interface conversion invalid")
-						// Sleep a time slot
-						time.Sleep(50 * time.Millisecond)
-					}
-				}
+	defer serialConn.portRoutines.Done()
+	closed := serialConn.portClosed
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	var guardUntil time.Time
+	for serialConn.isPortOpen.Load() {
+		wakeup := serialConn.checkSessionTimeout()
+
+		var session *SerialSession
+		if time.Now().Before(guardUntil) {
+			wakeup = earliest(wakeup, guardUntil)
+		} else {
+			var budgetWait time.Time
+			session, budgetWait = serialConn.nextSession()
+			wakeup = earliest(wakeup, budgetWait)
+		}
 
+		if session == nil {
+			// Nothing can be sent now, wait a change of the queues or the next deadline
+			var timeout <-chan time.Time
+			if !wakeup.IsZero() {
+				timer.Reset(time.Until(wakeup))
+				timeout = timer.C
 			}
-		} else {
-			// We are busy Sleep a time slot before check again
-			time.Sleep(50 * time.Millisecond)
+			select {
+			case <-serialConn.writerWakeup:
+			case <-timeout:
+			case <-closed:
+			}
+			timer.Stop()
+			continue
+		}
+
+		if serialConn.capture != nil && !session.Request.escaped {
+			serialConn.capture.WriteOutbound(session.Request.data)
+		}
+
+		b := session.Request.Output()
+		level := logger.Log().GetLevel()
+		if level >= logrus.TraceLevel {
+			logger.Log().WithFields(logrus.Fields{"len": len(b), "data": hex.EncodeToString(b[0:min(len(b), 32)])}).Trace("To serial")
+		}
+
+		writed, err := serialConn.port.Write(b)
+
+		if err != nil {
+			logger.Log().WithField("err", err).Error("Write to serial port error")
+			break
+		}
+
+		if writed < len(b) {
+			logger.Log().WithFields(logrus.Fields{"sent": writed, "want": len(b)}).Error("Write to serial port incomplete")
+			break
+		}
+
+		if !session.IsAwaitable() {
+			// Wait a time slot before send next session
+			// Is a guard time for wifi retransmissions
+			guardUntil = time.Now().Add(session.guardTime())
 		}
 	}
 
-	if serialConn.isPortOpen {
+	if serialConn.isPortOpen.Load() {
 		serialConn.closePort()
 	}
 
 	logger.Log().Warn("SerialConnection.Write go routine terminated")
 }
 
+// wakeupWriter signals the Write go routine that a session could be sent
+func (serialConn *SerialConnection) wakeupWriter() {
+	select {
+	case serialConn.writerWakeup <- struct{}{}:
+	default:
+	}
+}
+
 func (serialConn *SerialConnection) QueueApiSession(session *SerialSession) {
 	serialConn.SessionsLock.Lock()
-	serialConn.Sessions.PushBack(session)
+	serialConn.scheduler.push(session)
 	serialConn.SessionsLock.Unlock()
+	serialConn.wakeupWriter()
 }
 
 func (serialConn *SerialConnection) SendApi(cmd any) error {
-	if !serialConn.isPortOpen {
-		return errors.New("port is not open")
+	return serialConn.sendApi(cmd, ControlTraffic, 0)
+}
+
+// sendApi queues a request without reply in the given class, hops is the length of the path
+// used by the coordinator to forward it when the frame doesn't tell.
+func (serialConn *SerialConnection) sendApi(cmd any, class TrafficClass, hops int) error {
+	if !serialConn.isPortOpen.Load() {
+		return ErrSerialClosed
 	}
 
 	frame, err := NewApiFrameFromStruct(cmd, DirectProtocol, 0, nil)
@@ -371,32 +635,53 @@ func (serialConn *SerialConnection) SendApi(cmd any) error {
 	}
 
 	session := NewSimpleSerialSession(frame)
+	session.Class = class
+	session.Hops = max(session.Hops, hops)
 	serialConn.QueueApiSession(session)
 	return nil
 }
 
-func (serialConn *SerialConnection) sendReceiveApiProt(session *SerialSession) (any, error) {
-	if !serialConn.isPortOpen {
-		return nil, errors.New("port is not open")
+func (serialConn *SerialConnection) sendReceiveApiProt(ctx context.Context, session *SerialSession) (any, error) {
+	if !serialConn.isPortOpen.Load() {
+		return nil, ErrSerialClosed
 	}
 
-	if session.IsAwaitable() {
-		session.Wait.Add(1)
+	if deadline, ok := ctx.Deadline(); ok {
+		session.Deadline = deadline
 	}
+	session.Class = trafficClassFrom(ctx)
+
 	serialConn.QueueApiSession(session)
 	if session.IsAwaitable() {
-		session.Wait.Wait()
+		select {
+		case <-session.done:
+		case <-ctx.Done():
+			if serialConn.cancelSession(session) {
+				serialConn.wakeupWriter()
+				return nil, ctx.Err()
+			}
+			// Too late, the session is being completed
+			<-session.done
+		}
 	}
 
 	if session.Reply == nil {
-		return nil, errors.New("reply timeout")
+		if !serialConn.isPortOpen.Load() {
+			// The sessions are released when the port is closed
+			return nil, ErrSerialClosed
+		}
+		if ctx.Err() != nil {
+			// The session deadline is the context one, both timers fired
+			return nil, ctx.Err()
+		}
+		return nil, ErrTimeout
 	} else {
 		return session.Reply.Decode()
 	}
 }
 
-func (serialConn *SerialConnection) SendReceiveApiProt(cmd any, protocol MeshProtocol, target MeshNodeId, network *graph.Network) (any, error) {
-	if target == 0 {
+func (serialConn *SerialConnection) newProtSession(cmd any, protocol MeshProtocol, target MeshNodeId, network *graph.Network) (*SerialSession, error) {
+	if target == 0 && protocol != BradcastProtocol && protocol != PoliteBroadcastProtocol {
 		protocol = DirectProtocol
 	}
 	frame, err := NewApiFrameFromStruct(cmd, protocol, target, network)
@@ -404,50 +689,127 @@ func (serialConn *SerialConnection) SendReceiveApiProt(cmd any, protocol MeshPro
 		return nil, err
 	}
 
-	session, err := NewSerialSession(frame)
+	return NewSerialSession(frame)
+}
+
+func (serialConn *SerialConnection) SendReceiveApiProt(cmd any, protocol MeshProtocol, target MeshNodeId, network *graph.Network) (any, error) {
+	return serialConn.SendReceiveApiProtContext(context.Background(), cmd, protocol, target, network)
+}
+
+// SendReceiveApiProtContext sends a request and waits for its reply. The request is withdrawn when the context
+// is cancelled, and the context deadline, if any, replaces the default reply timeout. The request is sent in
+// the traffic class set with WithTrafficClass, control by default.
+func (serialConn *SerialConnection) SendReceiveApiProtContext(ctx context.Context, cmd any, protocol MeshProtocol, target MeshNodeId, network *graph.Network) (any, error) {
+	session, err := serialConn.newProtSession(cmd, protocol, target, network)
 	if err != nil {
 		return nil, err
 	}
 
-	return serialConn.sendReceiveApiProt(session)
+	return serialConn.sendReceiveApiProt(ctx, session)
 }
 
 func (serialConn *SerialConnection) SendReceiveApiProtTimeout(cmd interface{}, protocol MeshProtocol, target MeshNodeId, network *graph.Network, timeoutMs int64) (any, error) {
-	if target == 0 {
-		protocol = DirectProtocol
-	}
-	frame, err := NewApiFrameFromStruct(cmd, protocol, target, network)
+	session, err := serialConn.newProtSession(cmd, protocol, target, network)
 	if err != nil {
 		return nil, err
 	}
 
+	session.MaxTimeoutMs = timeoutMs
+	return serialConn.sendReceiveApiProt(context.Background(), session)
+}
+
+// SendReceiveApiBroadcast sends a request to all the nodes in range of the coordinator and
+// collects the replies received within the window, keyed by the node that sent them
+func (serialConn *SerialConnection) SendReceiveApiBroadcast(cmd any, window time.Duration) (map[MeshNodeId]any, error) {
+	return serialConn.SendReceiveApiBroadcastContext(context.Background(), cmd, window)
+}
+
+// SendReceiveApiBroadcastContext is SendReceiveApiBroadcast with a context, when the context is
+// cancelled the replies received so far are returned together with the context error.
+func (serialConn *SerialConnection) SendReceiveApiBroadcastContext(ctx context.Context, cmd any, window time.Duration) (map[MeshNodeId]any, error) {
+	return serialConn.collectReplies(ctx, cmd, BradcastProtocol, window, 1)
+}
+
+// collectReplies sends a broadcast request and returns the decoded replies received within the window,
+// hops is the number of times the request is repeated on air
+func (serialConn *SerialConnection) collectReplies(ctx context.Context, cmd any, protocol MeshProtocol, window time.Duration, hops int) (map[MeshNodeId]any, error) {
+	if !serialConn.isPortOpen.Load() {
+		return nil, ErrSerialClosed
+	}
+
+	frame, err := NewApiFrameFromStruct(cmd, protocol, 0, nil)
+	if err != nil {
+		return nil, err
+	}
 	session, err := NewSerialSession(frame)
 	if err != nil {
 		return nil, err
 	}
+	session.Collect = true
+	session.Replies = make(map[MeshNodeId]*ApiFrame)
+	session.MaxTimeoutMs = window.Milliseconds()
+	session.Class = trafficClassFrom(ctx)
+	session.Hops = hops
 
-	session.MaxTimeoutMs = timeoutMs
-	return serialConn.sendReceiveApiProt(session)
+	serialConn.QueueApiSession(session)
+	select {
+	case <-session.done:
+	case <-ctx.Done():
+		if serialConn.cancelSession(session) {
+			serialConn.wakeupWriter()
+			err = ctx.Err()
+		} else {
+			<-session.done
+		}
+	}
+
+	replies := make(map[MeshNodeId]any)
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+	for source, reply := range session.Replies {
+		v, decodeErr := reply.Decode()
+		if decodeErr != nil {
+			logger.Log().WithFields(logrus.Fields{"source": utils.FmtNodeId(int64(source)), "err": decodeErr}).Warn("Can't decode broadcast reply")
+			continue
+		}
+		replies[source] = v
+	}
+	return replies, err
 }
 
 func (serialConn *SerialConnection) SendReceiveApi(cmd interface{}) (interface{}, error) {
 	return serialConn.SendReceiveApiProt(cmd, DirectProtocol, 0, nil)
 }
 
+func (serialConn *SerialConnection) SendReceiveApiContext(ctx context.Context, cmd any) (any, error) {
+	return serialConn.SendReceiveApiProtContext(ctx, cmd, DirectProtocol, 0, nil)
+}
+
 func (serialConn *SerialConnection) closePort() error {
-	if !serialConn.isPortOpen {
+	if !serialConn.isPortOpen.CompareAndSwap(true, false) {
 		logger.Log().Info("SerialConnection.Close: port is not open")
-		return errors.New("port is not open")
+		return ErrSerialClosed
 	}
 
 	logger.Log().Trace("SerialConnection.Close: closing serial port")
 	err := serialConn.port.Close()
+	close(serialConn.portClosed)
 	serialConn.lastUseTime = time.Now()
-	serialConn.isPortOpen = false
-	serialConn.LocalNode = 0
+	serialConn.LocalNode.Store(0)
+	serialConn.releaseSessions()
 	return err
 }
 
+// LastLocalNode returns the id of the coordinator node, unlike LocalNode it is kept while the port is closed
+func (serialConn *SerialConnection) LastLocalNode() uint32 {
+	return serialConn.lastLocalNode.Load()
+}
+
+// LocalNodeInfo returns the node info read from the coordinator when the port was opened, it is nil with old firmwares
+func (serialConn *SerialConnection) LocalNodeInfo() *pb.NodeInfo {
+	return serialConn.localNodeInfo.Load()
+}
+
 func (serialConn *SerialConnection) SetLocalNodeIdChangedCb(cb func(meshNodeId MeshNodeId, nodeInfo *pb.NodeInfo)) {
 	serialConn.localNodeIdChangedCb = cb
 }
@@ -477,27 +839,48 @@ func (serialConn *SerialConnection) pulseReset() error {
 }
 
 func (serialConn *SerialConnection) openPort() error {
-	if serialConn.isPortOpen {
+	return serialConn.openPortWithReset(serialConn.pulseResetOnOpen)
+}
+
+// Reopen closes and opens again the port, with pulseReset the coordinator is reset too
+func (serialConn *SerialConnection) Reopen(pulseReset bool) error {
+	if serialConn.isPortOpen.Load() {
+		serialConn.closePort()
+	}
+	return serialConn.openPortWithReset(pulseReset)
+}
+
+func (serialConn *SerialConnection) openPortWithReset(pulseReset bool) error {
+	if serialConn.isPortOpen.Load() {
 		logger.Log().Info("SerialConnection.openPort: port already open")
 		return errors.New("port already open")
 	}
 
-	if serialConn.pulseResetOnOpen {
-		logger.Log().Info("SerialConnection.openPort: pulse reset started")
-		if err := serialConn.pulseReset(); err != nil {
-			logger.Log().WithError(err).Warn("SerialConnection.openPort: pulse reset failed")
-		}
-	}
+	// The reader and writer of the previous port must be terminated before reusing the connection
+	serialConn.portRoutines.Wait()
 
 	var err error
-	mode := &serial.Mode{BaudRate: serialConn.baudRate}
-	serialConn.port, err = serial.Open(serialConn.portName, mode)
+	serialConn.port, err = openTransport(serialConn.portName, serialConn.baudRate)
 	if err != nil {
 		return err
 	}
+	if serialConn.recorder != nil {
+		serialConn.port = serialConn.recorder.wrap(serialConn.port)
+	}
+
+	if pulseReset {
+		logger.Log().Info("SerialConnection.openPort: pulse reset started")
+		if err := serialConn.pulseReset(); err != nil {
+			logger.Log().WithError(err).Warn("SerialConnection.openPort: pulse reset failed")
+		}
+	}
 
-	serialConn.isPortOpen = true
+	// Discard the input before the writer can send the first request
+	serialConn.port.ResetInputBuffer()
+	serialConn.portClosed = make(chan struct{})
+	serialConn.isPortOpen.Store(true)
 
+	serialConn.portRoutines.Add(2)
 	go serialConn.Write()
 	go serialConn.Read()
 
@@ -509,11 +892,11 @@ func (serialConn *SerialConnection) openPort() error {
 	echo, ok := reply1.(EchoApiReply)
 	if !ok {
 		serialConn.closePort()
-		return errors.New("invalid echo reply type")
+		return fmt.Errorf("%w: invalid echo reply type", ErrDecode)
 	}
 	if echo.Echo != "CIAO" {
 		serialConn.closePort()
-		return errors.New("invalid echo reply")
+		return fmt.Errorf("%w: invalid echo reply", ErrDecode)
 	}
 
 	reply2, err := serialConn.SendReceiveApi(NodeIdApiRequest{})
@@ -525,7 +908,7 @@ func (serialConn *SerialConnection) openPort() error {
 	nodeid, ok := reply2.(NodeIdApiReply)
 	if !ok {
 		serialConn.closePort()
-		return errors.New("invalid nodeid reply")
+		return fmt.Errorf("%w: invalid nodeid reply", ErrDecode)
 	}
 
 	reply3, err := serialConn.SendReceiveApi(FirmRevApiRequest{})
@@ -536,7 +919,7 @@ func (serialConn *SerialConnection) openPort() error {
 	firmrev, ok := reply3.(FirmRevApiReply)
 	if !ok {
 		serialConn.closePort()
-		return errors.New("invalid firmware reply")
+		return fmt.Errorf("%w: invalid firmware reply", ErrDecode)
 	}
 
 	var nodeInfo *pb.NodeInfo
@@ -544,18 +927,30 @@ func (serialConn *SerialConnection) openPort() error {
 		reply4, err := serialConn.SendReceiveApi(ProtoNodeInfoApiRequest{})
 		if err != nil {
 			logger.Log().WithError(err).Warn("SerialConnection.openPort: failed to send proto node info api request")
+		} else if info, ok := reply4.(*pb.NodeInfo); !ok {
+			// The platform stays the configured one
+			logger.Log().WithField("type", fmt.Sprintf("%T", reply4)).Warn("SerialConnection.openPort: invalid proto node info reply")
 		} else {
-			nodeInfo = reply4.(*pb.NodeInfo)
+			nodeInfo = info
 			logger.Log().WithFields(logrus.Fields{"friendlyName": nodeInfo.FriendlyName, "firmwareVersion": nodeInfo.FirmwareVersion}).Info("Node info received")
 			logger.Log().WithFields(logrus.Fields{"macAddress": nodeInfo.MacAddress, "platform": nodeInfo.Platform}).Info("Node info received")
 			logger.Log().WithFields(logrus.Fields{"board": nodeInfo.Board, "compileTime": nodeInfo.CompileTime}).Info("Node info received")
 			logger.Log().WithFields(logrus.Fields{"libVersion": nodeInfo.LibVersion, "nodeType": nodeInfo.NodeType}).Info("Node info received")
+
+			// The platform reported by the coordinator wins over the configuration
+			isEsp8266 := strings.Contains(strings.ToUpper(nodeInfo.Platform), "ESP8266")
+			if isEsp8266 != serialConn.isEsp8266.Load() {
+				logger.Log().WithFields(logrus.Fields{"platform": nodeInfo.Platform, "esp8266": isEsp8266}).Info("SerialConnection.openPort: coordinator platform detected")
+				serialConn.isEsp8266.Store(isEsp8266)
+			}
 		}
 	}
 
-	serialConn.LocalNode = uint32(nodeid.Serial)
+	changed := serialConn.lastLocalNode.Swap(uint32(nodeid.Serial)) != uint32(nodeid.Serial)
+	serialConn.LocalNode.Store(uint32(nodeid.Serial))
+	serialConn.localNodeInfo.Store(nodeInfo)
 
-	if serialConn.LocalNode != uint32(nodeid.Serial) {
+	if changed {
 		if serialConn.localNodeIdChangedCb != nil {
 			serialConn.localNodeIdChangedCb(nodeid.Serial, nodeInfo)
 		}
@@ -568,21 +963,29 @@ func (serialConn *SerialConnection) AddFrameReceivedCallback(frameType uint8, fr
 	serialConn.FrameReceivedCallback = append(serialConn.FrameReceivedCallback, FrameReceivedCallback{FrameType: frameType, FrameSubtype: frameSubtype, Callback: callback})
 }
 
-func NewSerial(portName string, baudRate int, isEsp8266 bool, pulseResetOnOpen bool, debug bool) (*SerialConnection, error) {
+// NewSerial opens the connection with the coordinator. When capture is not nil every frame is recorded into it,
+// when recorder is not nil the raw byte stream is saved for a later replay.
+func NewSerial(portName string, baudRate int, isEsp8266 bool, pulseResetOnOpen bool, debug bool, capture *FrameCapture, recorder *StreamRecorder) (*SerialConnection, error) {
 	serial := &SerialConnection{
-		isPortOpen:       false,
 		port:             nil,
 		portName:         portName,
 		baudRate:         baudRate,
-		isEsp8266:        isEsp8266,
 		pulseResetOnOpen: pulseResetOnOpen,
 		txOneByteMs:      int(float32(8) / float32(baudRate) * 1000000.0),
 		debug:            debug,
+		capture:          capture,
+		recorder:         recorder,
+		NodeLogs:         NewNodeLogStore(nodeLogCapacity),
+		Console:          NewConsoleBuffer(consoleCapacity),
 		incoming:         make(chan []byte),
-		Sessions:         list.New(),
+		inflight:         make(map[MeshNodeId]*SerialSession),
+		writerWakeup:     make(chan struct{}, 1),
+		scheduler:        newTrafficScheduler(),
 		NextHandle:       1,
 		lastUseTime:      time.Now(),
 	}
 
+	serial.isEsp8266.Store(isEsp8266)
+
 	return serial, serial.openPort()
 }
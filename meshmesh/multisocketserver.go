@@ -24,10 +24,11 @@ func (m *MultiSocketServer) ShutdownServer(addr MeshNodeId) {
 	}
 }
 
-func (m *MultiSocketServer) StarPathProtocol(starpath *StarPath) {
-	m.starPathNetwork = starpath.network
-	m.starPathNetwork.AddNetworkChangedCallback(m.networkChanged)
-	m.networkChanged(m.starPathNetwork, false)
+// CloseClients closes the connections of all the servers, the ESPHome clients will connect again
+func (m *MultiSocketServer) CloseClients() {
+	for _, server := range m.Servers {
+		server.CloseClients()
+	}
 }
 
 func (m *MultiSocketServer) serverAddressExists(nodeId MeshNodeId) bool {
@@ -51,7 +52,7 @@ func (m *MultiSocketServer) createApiAndOtaServers(nodeId MeshNodeId, network *g
 	configApi := m.config
 	configApi.RemotePort = fixedApiRemotePort
 
-	server, err := NewSocketServer(m.serialProxy, network, nodeId, &configApi, m.listenerClosed)
+	server, err := NewSocketServer(m.serialProxy, network, nodeId, &configApi, m.espApiStats, m.listenerClosed)
 	if err != nil {
 		log.Error(err)
 	} else {
@@ -61,7 +62,7 @@ func (m *MultiSocketServer) createApiAndOtaServers(nodeId MeshNodeId, network *g
 	configOta := m.config
 	configOta.BindPort = fixedOtaRemotePort
 	configOta.RemotePort = fixedOtaRemotePort
-	serverOta, err := NewSocketServer(m.serialProxy, network, nodeId, &configOta, m.listenerClosed)
+	serverOta, err := NewSocketServer(m.serialProxy, network, nodeId, &configOta, m.espApiStats, m.listenerClosed)
 	if err != nil {
 		log.Error(err)
 	} else {
@@ -69,28 +70,57 @@ func (m *MultiSocketServer) createApiAndOtaServers(nodeId MeshNodeId, network *g
 	}
 }
 
-func (m *MultiSocketServer) networkChanged(network *graph.Network, noBackup bool) {
-	logger.WithFields(logger.Fields{"network": network.NetworkId()}).Info("MultiSocketServer.networkChanged")
+// updateNodeServer creates or shuts down the servers of a node after a change of the network
+func (m *MultiSocketServer) updateNodeServer(network *graph.Network, nodeId int64) {
+	node, err := network.GetNodeDevice(nodeId)
+	if err != nil {
+		if m.serverAddressExists(MeshNodeId(nodeId)) && !m.coordinator.Owns(MeshNodeId(nodeId)) {
+			logger.WithFields(logger.Fields{"nodeId": utils.FmtNodeId(nodeId), "network": network.NetworkId()}).Debug("MultiSocketServer.updateNodeServer: removing old node server")
+			m.ShutdownServer(MeshNodeId(nodeId))
+		}
+		return
+	}
+
+	wantServer := node.Device().InUse() && !network.IsLocalDevice(node) && !m.coordinator.IsPortNode(MeshNodeId(node.ID())) && !node.Device().DeepSleep()
+	hasServer := m.serverAddressExists(MeshNodeId(node.ID()))
+
+	if wantServer && !hasServer {
+		logger.WithFields(logger.Fields{"nodeId": utils.FmtNodeId(int64(node.ID())), "network": network.NetworkId()}).Debug("MultiSocketServer.updateNodeServer: adding new node server")
+		m.createApiAndOtaServers(MeshNodeId(node.ID()), network)
+	} else if !wantServer && hasServer {
+		logger.WithFields(logger.Fields{"nodeId": utils.FmtNodeId(int64(node.ID())), "network": network.NetworkId()}).Debug("MultiSocketServer.updateNodeServer: removing node server")
+		m.ShutdownServer(MeshNodeId(node.ID()))
+	}
+}
+
+// networkEvent updates only the servers of the node changed
+func (m *MultiSocketServer) networkEvent(network *graph.Network, event graph.NetworkEvent) {
+	switch event.Type {
+	case graph.NodeAdded, graph.NodeRemoved:
+		m.updateNodeServer(network, event.Node)
+	case graph.NodeUpdated:
+		if event.Changed("inuse", "deepsleep") {
+			m.updateNodeServer(network, event.Node)
+		}
+	case graph.LocalNodeChanged:
+		m.updateNodeServer(network, event.Previous)
+		m.updateNodeServer(network, event.Node)
+	}
+}
+
+// syncServers creates the servers of all the nodes of the network and shuts down the ones of the nodes
+// no longer in the networks of the coordinator
+func (m *MultiSocketServer) syncServers(network *graph.Network) {
+	logger.WithFields(logger.Fields{"network": network.NetworkId()}).Info("MultiSocketServer.syncServers")
 	nodes := network.Nodes()
 	for nodes.Next() {
-		node := nodes.Node().(graph.NodeDevice)
-		wantServer := node.Device().InUse() && !network.IsLocalDevice(node) && !node.Device().DeepSleep()
-		hasServer := m.serverAddressExists(MeshNodeId(node.ID()))
-		//logger.WithFields(logger.Fields{"node": node.Device().Name(), "wantServer": wantServer, "hasServer": hasServer}).Info("MultiSocketServer.networkChanged")
-
-		if wantServer && !hasServer {
-			logger.WithFields(logger.Fields{"nodeId": utils.FmtNodeId(int64(node.ID())), "network": network.NetworkId()}).Debug("MultiSocketServer.networkChanged: adding new node server")
-			m.createApiAndOtaServers(MeshNodeId(node.ID()), network)
-		} else if !wantServer && hasServer {
-			logger.WithFields(logger.Fields{"nodeId": utils.FmtNodeId(int64(node.ID())), "network": network.NetworkId()}).Debug("MultiSocketServer.networkChanged: removing node server")
-			m.ShutdownServer(MeshNodeId(node.ID()))
-		}
+		m.updateNodeServer(network, nodes.Node().ID())
 	}
 
 	oldnodes := make([]MeshNodeId, 0)
 	for _, server := range m.Servers {
 		addr := server.Address
-		if m.mainNetwork.Node(int64(addr)) == nil && m.starPathNetwork.Node(int64(addr)) == nil {
+		if !m.coordinator.Owns(addr) {
 			oldnodes = append(oldnodes, addr)
 		}
 	}
@@ -108,21 +138,24 @@ type ServerApiConfig struct {
 }
 
 type MultiSocketServer struct {
-	espApiStats     *EspApiStats
-	serialProxy     *ConnectedPath2Serial
-	starPathNetwork *graph.Network
-	mainNetwork     *graph.Network
-	config          ServerApiConfig
-	Servers         []*SocketServer
+	espApiStats *EspApiStats
+	serialProxy *ConnectedPath2Serial
+	coordinator *Coordinator
+	config      ServerApiConfig
+	Servers     []*SocketServer
 }
 
-func NewMultiSocketServer(serialProxy *ConnectedPath2Serial, config ServerApiConfig) *MultiSocketServer {
-	_allStats = NewEspApiStats()
-	multisrv := MultiSocketServer{serialProxy: serialProxy, espApiStats: _allStats, config: config}
-	serialProxy.ClearConnections()
-
-	multisrv.mainNetwork = graph.GetMainNetwork()
-	multisrv.mainNetwork.AddNetworkChangedCallback(multisrv.networkChanged)
-	multisrv.networkChanged(multisrv.mainNetwork, false)
+// NewMultiSocketServer serves the nodes of the main and star path networks of a coordinator
+func NewMultiSocketServer(coordinator *Coordinator, config ServerApiConfig) *MultiSocketServer {
+	multisrv := MultiSocketServer{serialProxy: coordinator.ConnectedPath, espApiStats: NewEspApiStats(), coordinator: coordinator, config: config}
+	multisrv.serialProxy.ClearConnections()
+
+	network := coordinator.Network()
+	network.AddNetworkEventCallback(multisrv.networkEvent)
+	multisrv.syncServers(network)
+	if star := coordinator.StarNetwork(); star != nil {
+		star.AddNetworkEventCallback(multisrv.networkEvent)
+		multisrv.syncServers(star)
+	}
 	return &multisrv
 }
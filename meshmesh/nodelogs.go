@@ -0,0 +1,195 @@
+package meshmesh
+
+import (
+	"sync"
+	"time"
+)
+
+const nodeLogCapacity = 500
+const nodeLogSubscriberQueue = 64
+
+// Log levels sent by the nodes, same values of the ESPHome logger
+const (
+	NodeLogLevelNone uint16 = iota
+	NodeLogLevelError
+	NodeLogLevelWarn
+	NodeLogLevelInfo
+	NodeLogLevelConfig
+	NodeLogLevelDebug
+	NodeLogLevelVerbose
+	NodeLogLevelVeryVerbose
+)
+
+var nodeLogLevelNames = []string{"NONE", "ERROR", "WARN", "INFO", "CONFIG", "DEBUG", "VERBOSE", "VERY_VERBOSE"}
+
+// NodeLogLevelName returns the name of a log level
+func NodeLogLevelName(level uint16) string {
+	if int(level) < len(nodeLogLevelNames) {
+		return nodeLogLevelNames[level]
+	}
+	return "UNKNOWN"
+}
+
+// NodeLogLevelFromName returns the log level with the given name
+func NodeLogLevelFromName(name string) (uint16, bool) {
+	for i, n := range nodeLogLevelNames {
+		if n == name {
+			return uint16(i), true
+		}
+	}
+	return 0, false
+}
+
+type NodeLogEntry struct {
+	Node  MeshNodeId
+	Level uint16
+	Time  time.Time
+	Line  string
+}
+
+// NodeLogFilter selects the log entries. Level is the most verbose level
+// returned, zero times are not checked.
+type NodeLogFilter struct {
+	Level uint16
+	Since time.Time
+	Until time.Time
+}
+
+func (f NodeLogFilter) match(entry *NodeLogEntry) bool {
+	if entry.Level > f.Level {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && entry.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+type nodeLogRing struct {
+	entries []NodeLogEntry
+	next    int
+}
+
+func (r *nodeLogRing) add(entry NodeLogEntry) {
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, entry)
+		return
+	}
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// each calls fn for the entries from the oldest to the newest
+func (r *nodeLogRing) each(fn func(entry *NodeLogEntry)) {
+	for i := range r.entries {
+		fn(&r.entries[(r.next+i)%len(r.entries)])
+	}
+}
+
+type nodeLogSubscriber struct {
+	node   MeshNodeId
+	filter NodeLogFilter
+	ch     chan NodeLogEntry
+}
+
+// NodeLogStore keeps the last log lines received from every node
+type NodeLogStore struct {
+	lock        sync.Mutex
+	capacity    int
+	nodes       map[MeshNodeId]*nodeLogRing
+	subscribers map[*nodeLogSubscriber]struct{}
+	dropped     uint64
+}
+
+// Add stores a log line received from a node and forwards it to the subscribers
+func (s *NodeLogStore) Add(node MeshNodeId, level uint16, line string) {
+	entry := NodeLogEntry{Node: node, Level: level, Time: time.Now(), Line: line}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ring, ok := s.nodes[node]
+	if !ok {
+		ring = &nodeLogRing{entries: make([]NodeLogEntry, 0, s.capacity)}
+		s.nodes[node] = ring
+	}
+	ring.add(entry)
+
+	for sub := range s.subscribers {
+		if (sub.node == 0 || sub.node == node) && sub.filter.match(&entry) {
+			select {
+			case sub.ch <- entry:
+			default:
+				// A slow reader must not block the serial reader
+				s.dropped++
+			}
+		}
+	}
+}
+
+// Query returns the stored lines of a node that match the filter, from the oldest to the newest
+func (s *NodeLogStore) Query(node MeshNodeId, filter NodeLogFilter) []NodeLogEntry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result := make([]NodeLogEntry, 0)
+	ring, ok := s.nodes[node]
+	if !ok {
+		return result
+	}
+	ring.each(func(entry *NodeLogEntry) {
+		if filter.match(entry) {
+			result = append(result, *entry)
+		}
+	})
+	return result
+}
+
+// Nodes returns the ids of the nodes with stored log lines
+func (s *NodeLogStore) Nodes() []MeshNodeId {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	nodes := make([]MeshNodeId, 0, len(s.nodes))
+	for node := range s.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// Subscribe returns a channel receiving the new lines of a node, a zero node receives
+// the lines of all the nodes. The cancel function must be called to release the channel.
+func (s *NodeLogStore) Subscribe(node MeshNodeId, filter NodeLogFilter) (<-chan NodeLogEntry, func()) {
+	sub := &nodeLogSubscriber{node: node, filter: filter, ch: make(chan NodeLogEntry, nodeLogSubscriberQueue)}
+
+	s.lock.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.lock.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			s.lock.Lock()
+			delete(s.subscribers, sub)
+			s.lock.Unlock()
+		})
+	}
+}
+
+// Dropped returns the number of lines not delivered to slow subscribers
+func (s *NodeLogStore) Dropped() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.dropped
+}
+
+func NewNodeLogStore(capacity int) *NodeLogStore {
+	return &NodeLogStore{
+		capacity:    capacity,
+		nodes:       make(map[MeshNodeId]*nodeLogRing),
+		subscribers: make(map[*nodeLogSubscriber]struct{}),
+	}
+}
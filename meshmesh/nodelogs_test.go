@@ -0,0 +1,45 @@
+package meshmesh
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestNodeLogStoreWraparound(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  int
+		filter NodeLogFilter
+		want   []string
+	}{
+		{"not full", 2, NodeLogFilter{Level: NodeLogLevelVeryVerbose}, []string{"line 0", "line 1"}},
+		{"full", 3, NodeLogFilter{Level: NodeLogLevelVeryVerbose}, []string{"line 0", "line 1", "line 2"}},
+		{"wrapped", 5, NodeLogFilter{Level: NodeLogLevelVeryVerbose}, []string{"line 2", "line 3", "line 4"}},
+		{"wrapped twice", 7, NodeLogFilter{Level: NodeLogLevelVeryVerbose}, []string{"line 4", "line 5", "line 6"}},
+		{"wrapped and filtered", 5, NodeLogFilter{Level: NodeLogLevelWarn}, []string{"line 2", "line 4"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := NewNodeLogStore(3)
+			for i := range test.lines {
+				// The even lines are errors, the odd ones debug
+				level := NodeLogLevelError
+				if i%2 == 1 {
+					level = NodeLogLevelDebug
+				}
+				store.Add(0x100002, level, fmt.Sprintf("line %d", i))
+			}
+			store.Add(0x100003, NodeLogLevelError, "other node")
+
+			got := make([]string, 0)
+			for _, entry := range store.Query(0x100002, test.filter) {
+				got = append(got, entry.Line)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -43,6 +43,11 @@ func (client *OtaConnection) Socket2Serial(buffer *bytes.Buffer, connectedPath *
 	}
 }
 
+// TrafficClass returns the bulk class, the firmware upload can't slow down the other traffic
+func (client *OtaConnection) TrafficClass() TrafficClass {
+	return BulkTraffic
+}
+
 func NewOtaConnection() *OtaConnection {
 	return &OtaConnection{}
 }
@@ -0,0 +1,125 @@
+package meshmesh
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"leguru.net/m/v2/graph"
+	"leguru.net/m/v2/logger"
+	"leguru.net/m/v2/utils"
+)
+
+// DefaultPoliteBroadcastWindow is the time given to the whole network to answer a polite broadcast
+const DefaultPoliteBroadcastWindow = 3 * time.Second
+
+// Requests that can be sent to the whole network by name
+var politeBroadcastCommands = map[string]func() any{
+	"echo":     func() any { return EchoApiRequest{Echo: "PING"} },
+	"firmware": func() any { return FirmRevApiRequest{} },
+	"tag":      func() any { return NodeGetTagApiRequest{} },
+	"reboot":   func() any { return NodeRebootApiRequest{} },
+}
+
+// PoliteBroadcastCommand returns the request of a polite broadcast command
+func PoliteBroadcastCommand(name string) (any, error) {
+	command, ok := politeBroadcastCommands[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown polite broadcast command %s", ErrInvalidRequest, name)
+	}
+	return command(), nil
+}
+
+// PoliteBroadcastCommands returns the names of the polite broadcast commands
+func PoliteBroadcastCommands() []string {
+	names := make([]string, 0, len(politeBroadcastCommands))
+	for name := range politeBroadcastCommands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// PoliteBroadcastReplyText returns a printable value of a reply to a polite broadcast command
+func PoliteBroadcastReplyText(reply any) string {
+	switch v := reply.(type) {
+	case EchoApiReply:
+		return v.Echo
+	case FirmRevApiReply:
+		return utils.TruncateZeros(v.Revision)
+	case NodeGetTagApiReply:
+		return utils.TruncateZeros(v.Tag)
+	case NodeRebootApiReply:
+		return "rebooting"
+	}
+	return ""
+}
+
+// PoliteBroadcastResult aggregates the replies of a polite broadcast by node
+type PoliteBroadcastResult struct {
+	Replies map[MeshNodeId]any
+	// Nodes of the main graph and of the star path graph that didn't answer
+	MissingMain []MeshNodeId
+	MissingStar []MeshNodeId
+}
+
+// missingNodes returns the nodes in use of the network, other than the local node, without a reply
+func missingNodes(replies map[MeshNodeId]any, network *graph.Network) []MeshNodeId {
+	missing := make([]MeshNodeId, 0)
+	if network == nil {
+		return missing
+	}
+
+	nodes := network.Nodes()
+	for nodes.Next() {
+		dev := nodes.Node().(graph.NodeDevice)
+		if dev.ID() == network.LocalDeviceId() || !dev.Device().InUse() {
+			continue
+		}
+		if _, ok := replies[MeshNodeId(dev.ID())]; !ok {
+			missing = append(missing, MeshNodeId(dev.ID()))
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// EnablePoliteBroadcast allows the requests flooded to the whole network. They are disabled by
+// default because every node repeats them and they take a lot of airtime.
+func (serialConn *SerialConnection) EnablePoliteBroadcast(enable bool) {
+	serialConn.politeBroadcast.Store(enable)
+}
+
+func (serialConn *SerialConnection) PoliteBroadcastEnabled() bool {
+	return serialConn.politeBroadcast.Load()
+}
+
+// SendReceiveApiPoliteBroadcastContext floods a request to the whole network and collects the replies received
+// within the window, keyed by the node that sent them. The nodes of the main and star path graphs that didn't
+// answer are reported in the result, the graphs can be nil.
+func (serialConn *SerialConnection) SendReceiveApiPoliteBroadcastContext(ctx context.Context, cmd any, window time.Duration, mainNetwork *graph.Network, starNetwork *graph.Network) (*PoliteBroadcastResult, error) {
+	if !serialConn.politeBroadcast.Load() {
+		return nil, ErrPoliteBroadcastDisabled
+	}
+
+	// Every node repeats the request once
+	hops := 1
+	if mainNetwork != nil {
+		hops = max(hops, mainNetwork.Nodes().Len())
+	}
+	replies, err := serialConn.collectReplies(ctx, cmd, PoliteBroadcastProtocol, window, hops)
+	if replies == nil {
+		return nil, err
+	}
+
+	result := &PoliteBroadcastResult{
+		Replies:     replies,
+		MissingMain: missingNodes(replies, mainNetwork),
+		MissingStar: missingNodes(replies, starNetwork),
+	}
+	logger.Log().WithFields(logrus.Fields{"replies": len(replies), "missingMain": len(result.MissingMain), "missingStar": len(result.MissingStar)}).
+		Info("Polite broadcast completed")
+	return result, err
+}
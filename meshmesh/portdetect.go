@@ -0,0 +1,156 @@
+package meshmesh
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.bug.st/serial"
+	"go.bug.st/serial/enumerator"
+	"leguru.net/m/v2/logger"
+)
+
+// AutoPortName is the port name that starts the detection of the coordinator port
+const AutoPortName = "auto"
+
+const portProbeTimeout = 2 * time.Second
+const portProbeRetry = 500 * time.Millisecond
+
+// USB to serial bridges used by the ESP boards, the VID:PID as reported by sysfs
+var knownUsbSerialBridges = map[string]string{
+	"10c4:ea60": "Silicon Labs CP210x",
+	"1a86:7523": "WCH CH340",
+	"1a86:55d4": "WCH CH9102",
+	"0403:6001": "FTDI FT232R",
+	"0403:6015": "FTDI FT231X",
+	"067b:2303": "Prolific PL2303",
+	"303a:1001": "Espressif USB JTAG/serial",
+	"303a:0002": "Espressif USB CDC",
+}
+
+type portCandidate struct {
+	name     string
+	priority int
+	bridge   string
+}
+
+// listPortCandidates returns the serial ports sorted by the probability to be a coordinator
+func listPortCandidates() ([]portCandidate, error) {
+	var candidates []portCandidate
+
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		// Without the details all the ports have the same priority
+		names, err := serial.GetPortsList()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			candidates = append(candidates, portCandidate{name: name})
+		}
+		return candidates, nil
+	}
+
+	for _, port := range ports {
+		candidate := portCandidate{name: port.Name}
+		if port.IsUSB {
+			candidate.priority = 1
+			bridge, ok := knownUsbSerialBridges[strings.ToLower(port.VID+":"+port.PID)]
+			if ok {
+				candidate.priority = 2
+				candidate.bridge = bridge
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	slices.SortStableFunc(candidates, func(a, b portCandidate) int {
+		if a.priority != b.priority {
+			return b.priority - a.priority
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return candidates, nil
+}
+
+// probeCoordinatorPort sends the echo request used by openPort and waits the reply
+func probeCoordinatorPort(portName string, baudRate int) bool {
+	port, err := openSerialTransport(portName, baudRate)
+	if err != nil {
+		logger.Log().WithFields(logrus.Fields{"port": portName, "err": err}).Debug("probeCoordinatorPort: can't open port")
+		return false
+	}
+	defer port.Close()
+
+	request, err := NewApiFrameFromStruct(EchoApiRequest{Echo: "CIAO"}, DirectProtocol, 0, nil)
+	if err != nil {
+		return false
+	}
+	output := request.Output()
+
+	port.ResetInputBuffer()
+	port.SetReadTimeout(50 * time.Millisecond)
+
+	decoder := newFrameDecoder()
+	buffer := make([]byte, 64)
+	deadline := time.Now().Add(portProbeTimeout)
+	// The board could be resetting after the open, repeat the request until the deadline
+	var lastWrite time.Time
+	for time.Now().Before(deadline) {
+		if time.Since(lastWrite) > portProbeRetry {
+			if _, err := port.Write(output); err != nil {
+				return false
+			}
+			lastWrite = time.Now()
+		}
+
+		n, err := port.Read(buffer)
+		if err != nil {
+			return false
+		}
+		for _, b := range buffer[:n] {
+			frame, crcOk := decoder.decodeByte(b)
+			if frame == nil || !crcOk {
+				continue
+			}
+			reply, err := NewApiFrame(frame, true).Decode()
+			if err != nil {
+				continue
+			}
+			if echo, ok := reply.(EchoApiReply); ok && echo.Echo == "CIAO" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DetectCoordinatorPort scans the serial ports and returns the first one with a coordinator
+// answering to the echo request. The preferred port, if any, is probed first.
+func DetectCoordinatorPort(baudRate int, preferred string) (string, error) {
+	if preferred != "" && preferred != AutoPortName {
+		if probeCoordinatorPort(preferred, baudRate) {
+			logger.Log().WithField("port", preferred).Info("DetectCoordinatorPort: coordinator found on the last used port")
+			return preferred, nil
+		}
+	}
+
+	candidates, err := listPortCandidates()
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range candidates {
+		if candidate.name == preferred {
+			continue
+		}
+		logger.Log().WithFields(logrus.Fields{"port": candidate.name, "bridge": candidate.bridge}).Debug("DetectCoordinatorPort: probing port")
+		if probeCoordinatorPort(candidate.name, baudRate) {
+			logger.Log().WithFields(logrus.Fields{"port": candidate.name, "bridge": candidate.bridge}).Info("DetectCoordinatorPort: coordinator found")
+			return candidate.name, nil
+		}
+	}
+	return "", errors.New("no coordinator found on the serial ports")
+}
@@ -0,0 +1,184 @@
+package meshmesh
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recording file layout: the magic string followed by the records. Every record
+// is the kind byte, the time since the start of the recording in microseconds
+// (uint64), the data length (uint32) and the data.
+const recordingMagic = "MMRECv1\n"
+
+const (
+	recordRead  byte = 'R'
+	recordWrite byte = 'W'
+)
+
+// Consecutive reads closer than this are stored as a single record
+const recordCoalesceGap = 2 * time.Millisecond
+const recordMaxChunk = 4096
+
+type streamRecord struct {
+	Kind byte
+	Time time.Duration
+	Data []byte
+}
+
+// StreamRecorder saves the timed byte stream exchanged with the coordinator.
+// The replay:// transport plays the file back.
+type StreamRecorder struct {
+	lock        sync.Mutex
+	file        *os.File
+	start       time.Time
+	pending     []byte
+	pendingTime time.Time
+	lastRead    time.Time
+}
+
+func (r *StreamRecorder) writeRecord(kind byte, t time.Time, data []byte) {
+	if r.file == nil {
+		return
+	}
+	b := make([]byte, 0, 13+len(data))
+	b = append(b, kind)
+	b = binary.LittleEndian.AppendUint64(b, uint64(t.Sub(r.start).Microseconds()))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(data)))
+	b = append(b, data...)
+	r.file.Write(b)
+}
+
+func (r *StreamRecorder) flushReads() {
+	if len(r.pending) > 0 {
+		r.writeRecord(recordRead, r.pendingTime, r.pending)
+		r.pending = r.pending[:0]
+	}
+}
+
+func (r *StreamRecorder) recordRead(data []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastRead) > recordCoalesceGap || len(r.pending) >= recordMaxChunk {
+		r.flushReads()
+	}
+	if len(r.pending) == 0 {
+		r.pendingTime = now
+	}
+	r.pending = append(r.pending, data...)
+	r.lastRead = now
+}
+
+func (r *StreamRecorder) recordWrite(data []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.flushReads()
+	r.writeRecord(recordWrite, time.Now(), data)
+}
+
+// wrap returns a transport that records the traffic of the given one.
+// The recording time starts when the first port is wrapped.
+func (r *StreamRecorder) wrap(port Transport) Transport {
+	r.lock.Lock()
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+	r.lock.Unlock()
+	return &recordingTransport{Transport: port, recorder: r}
+}
+
+func (r *StreamRecorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	r.flushReads()
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// NewStreamRecorder creates a new recording file, an existing file is overwritten
+func NewStreamRecorder(filename string) (*StreamRecorder, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.WriteString(recordingMagic); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &StreamRecorder{file: file}, nil
+}
+
+type recordingTransport struct {
+	Transport
+	recorder *StreamRecorder
+}
+
+func (t *recordingTransport) Read(p []byte) (int, error) {
+	n, err := t.Transport.Read(p)
+	if n > 0 {
+		t.recorder.recordRead(p[:n])
+	} else {
+		// The line is idle, nothing more to coalesce
+		t.recorder.lock.Lock()
+		t.recorder.flushReads()
+		t.recorder.lock.Unlock()
+	}
+	return n, err
+}
+
+// Write records the data before sending it, the reply can't be recorded before the request
+func (t *recordingTransport) Write(p []byte) (int, error) {
+	t.recorder.recordWrite(p)
+	return t.Transport.Write(p)
+}
+
+func (t *recordingTransport) Close() error {
+	t.recorder.lock.Lock()
+	t.recorder.flushReads()
+	t.recorder.lock.Unlock()
+	return t.Transport.Close()
+}
+
+func loadRecording(filename string) ([]streamRecord, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < len(recordingMagic) || string(data[:len(recordingMagic)]) != recordingMagic {
+		return nil, errors.New("not a meshmesh recording: " + filename)
+	}
+
+	var records []streamRecord
+	data = data[len(recordingMagic):]
+	for len(data) > 0 {
+		if len(data) < 13 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		record := streamRecord{
+			Kind: data[0],
+			Time: time.Duration(binary.LittleEndian.Uint64(data[1:9])) * time.Microsecond,
+		}
+		size := int(binary.LittleEndian.Uint32(data[9:13]))
+		if len(data) < 13+size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		record.Data = data[13 : 13+size]
+		if record.Kind != recordRead && record.Kind != recordWrite {
+			return nil, errors.New("invalid record in recording file")
+		}
+		records = append(records, record)
+		data = data[13+size:]
+	}
+	return records, nil
+}
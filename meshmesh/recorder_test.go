@@ -0,0 +1,90 @@
+package meshmesh
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"leguru.net/m/v2/graph"
+)
+
+func newTestTopology(t *testing.T) (string, *graph.Network) {
+	t.Helper()
+	topology := graph.NewNetwork(0x100001, graph.NETWORK_ID_MAIN)
+	topology.UpdateNode(0x100001, func(device *graph.Device) { device.SetNodeType(graph.NodeTypeCoordinator) })
+	topology.ChangeEdgeWeight(0x100001, 0x100002, 0.2, 0.2)
+	topology.ChangeEdgeWeight(0x100002, 0x100001, 0.2, 0.2)
+	topology.ChangeEdgeWeight(0x100002, 0x100003, 0.3, 0.3)
+	topology.ChangeEdgeWeight(0x100003, 0x100002, 0.3, 0.3)
+	for _, id := range []int64{0x100002, 0x100003} {
+		topology.UpdateNode(id, func(device *graph.Device) { device.SetInUse(true) })
+	}
+
+	filename := filepath.Join(t.TempDir(), "topology.graphml")
+	if err := topology.SaveToFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	return filename, topology
+}
+
+// exchange sends the same requests to a port and returns the decoded replies
+func exchange(t *testing.T, serial *SerialConnection, topology *graph.Network) []any {
+	t.Helper()
+	requests := []struct {
+		cmd      any
+		protocol MeshProtocol
+		target   MeshNodeId
+	}{
+		{FirmRevApiRequest{}, DirectProtocol, 0},
+		{NodeConfigApiRequest{}, UnicastProtocol, 0x100002},
+		{FirmRevApiRequest{}, MultipathProtocol, 0x100003},
+		{DiscTableSizeApiRequest{}, UnicastProtocol, 0x100002},
+		{DiscTableItemGetApiRequest{Index: 0}, UnicastProtocol, 0x100002},
+	}
+
+	replies := make([]any, 0, len(requests))
+	for _, request := range requests {
+		reply, err := serial.SendReceiveApiProt(request.cmd, request.protocol, request.target, topology)
+		if err != nil {
+			t.Fatalf("%T to %d: %v", request.cmd, request.target, err)
+		}
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	topologyFile, topology := newTestTopology(t)
+	recordFile := filepath.Join(t.TempDir(), "session.rec")
+
+	recorder, err := NewStreamRecorder(recordFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := NewSerial("sim://"+topologyFile, 115200, false, true, false, nil, recorder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := exchange(t, recorded, topology)
+	recorded.closePort()
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := NewSerial("replay://"+recordFile, 115200, false, true, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replayed.closePort()
+	if replayed.LocalNode.Load() != 0x100001 {
+		t.Errorf("local node %x, want 100001", replayed.LocalNode.Load())
+	}
+
+	got := exchange(t, replayed, topology)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed replies differ\ngot:  %+v\nwant: %+v", got, want)
+	}
+	if mismatches := replayed.port.(*ReplayTransport).Mismatches(); mismatches != 0 {
+		t.Errorf("%d writes differ from the recording", mismatches)
+	}
+}
@@ -0,0 +1,354 @@
+package meshmesh
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"leguru.net/m/v2/logger"
+)
+
+// Messages exchanged between a relay and the hub, every message is the type, the size of the payload and the payload
+const (
+	// Bytes of the coordinator port
+	relayMsgData uint8 = iota
+	// Relay to hub: protocol version and the challenge of the authentication
+	relayMsgHello
+	// Hub to relay: hmac of the challenge with the shared secret
+	relayMsgAuth
+	// Relay to hub: state of the coordinator port, open flag followed by the error text
+	relayMsgStatus
+	// Hub to relay: modem line and its value
+	relayMsgControl
+	// Hub to relay: discard the input of the coordinator port
+	relayMsgPurge
+)
+
+// Modem lines of the control message
+const (
+	relayLineRts uint8 = iota
+	relayLineDtr
+)
+
+const (
+	relayProtocolVersion = 1
+	relayChallengeSize   = 32
+	relayHeaderSize      = 3
+	relayHandshakeTime   = 5 * time.Second
+	relayReopenInterval  = 2 * time.Second
+)
+
+var errRelayAuthentication = errors.New("relay authentication failed")
+
+func writeRelayMessage(w io.Writer, msgType uint8, payload []byte) error {
+	if len(payload) > 0xFFFF {
+		return fmt.Errorf("%w: relay message of %d bytes", ErrInvalidRequest, len(payload))
+	}
+	msg := make([]byte, relayHeaderSize+len(payload))
+	msg[0] = msgType
+	binary.BigEndian.PutUint16(msg[1:], uint16(len(payload)))
+	copy(msg[relayHeaderSize:], payload)
+	_, err := w.Write(msg)
+	return err
+}
+
+func readRelayMessage(r io.Reader) (uint8, []byte, error) {
+	header := make([]byte, relayHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[1:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return header[0], payload, nil
+}
+
+func relayStatusPayload(open bool, err error) []byte {
+	payload := []byte{0}
+	if open {
+		payload[0] = 1
+	}
+	if err != nil {
+		payload = append(payload, err.Error()...)
+	}
+	return payload
+}
+
+func relayChallengeResponse(secret string, challenge []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(challenge)
+	return mac.Sum(nil)
+}
+
+// relayClient is the hub connected to the relay
+type relayClient struct {
+	conn      net.Conn
+	writeLock sync.Mutex
+}
+
+func (c *relayClient) send(msgType uint8, payload []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return writeRelayMessage(c.conn, msgType, payload)
+}
+
+// RelayServer opens the coordinator port and serves its byte stream to a hub running on another machine.
+// The hub must answer to a challenge with the shared secret, a new hub replaces the one connected.
+type RelayServer struct {
+	portName string
+	baudRate int
+	secret   string
+	listener net.Listener
+	lock     sync.Mutex
+	port     Transport
+	portErr  error
+	client   *relayClient
+	purges   chan chan struct{}
+	quit     chan struct{}
+	routines sync.WaitGroup
+}
+
+func (r *RelayServer) currentClient() *relayClient {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.client
+}
+
+func (r *RelayServer) currentPort() Transport {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.port
+}
+
+// setPort changes the coordinator port and tells the hub its state
+func (r *RelayServer) setPort(port Transport, err error) {
+	r.lock.Lock()
+	r.port = port
+	r.portErr = err
+	client := r.client
+	r.lock.Unlock()
+
+	if client != nil {
+		client.send(relayMsgStatus, relayStatusPayload(port != nil, err))
+	}
+}
+
+func (r *RelayServer) dropClient(client *relayClient) {
+	r.lock.Lock()
+	if r.client == client {
+		r.client = nil
+	}
+	r.lock.Unlock()
+	client.conn.Close()
+}
+
+// servePort keeps the coordinator port open and forwards its bytes to the hub
+func (r *RelayServer) servePort() {
+	defer r.routines.Done()
+	buffer := make([]byte, serialReadChunk)
+
+	for {
+		select {
+		case <-r.quit:
+			if port := r.currentPort(); port != nil {
+				port.Close()
+			}
+			return
+		default:
+		}
+
+		port := r.currentPort()
+		if port == nil {
+			opened, err := openTransport(r.portName, r.baudRate)
+			if err != nil {
+				logger.Log().WithFields(logrus.Fields{"port": r.portName, "err": err}).Warn("RelayServer: can't open the coordinator port")
+				r.setPort(nil, err)
+				select {
+				case <-r.quit:
+				case <-time.After(relayReopenInterval):
+				}
+				continue
+			}
+			opened.SetReadTimeout(serialReadTimeout)
+			logger.Log().WithField("port", r.portName).Info("RelayServer: coordinator port open")
+			r.setPort(opened, nil)
+			continue
+		}
+
+		// The input is discarded by the reader of the port, the transports can't be read by two routines
+		select {
+		case done := <-r.purges:
+			port.ResetInputBuffer()
+			close(done)
+		default:
+		}
+
+		n, err := port.Read(buffer)
+		if err != nil {
+			logger.Log().WithFields(logrus.Fields{"port": r.portName, "err": err}).Warn("RelayServer: coordinator port closed")
+			port.Close()
+			r.setPort(nil, err)
+			continue
+		}
+		if n > 0 {
+			if client := r.currentClient(); client != nil {
+				if err := client.send(relayMsgData, buffer[:n]); err != nil {
+					r.dropClient(client)
+				}
+			}
+		}
+	}
+}
+
+// authenticate sends the challenge to a new connection and checks the answer
+func (r *RelayServer) authenticate(conn net.Conn) error {
+	conn.SetDeadline(time.Now().Add(relayHandshakeTime))
+	defer conn.SetDeadline(time.Time{})
+
+	challenge := make([]byte, relayChallengeSize)
+	if _, err := rand.Read(challenge); err != nil {
+		return err
+	}
+	if err := writeRelayMessage(conn, relayMsgHello, append([]byte{relayProtocolVersion}, challenge...)); err != nil {
+		return err
+	}
+
+	msgType, payload, err := readRelayMessage(conn)
+	if err != nil {
+		return err
+	}
+	if msgType != relayMsgAuth || !hmac.Equal(payload, relayChallengeResponse(r.secret, challenge)) {
+		writeRelayMessage(conn, relayMsgStatus, relayStatusPayload(false, errRelayAuthentication))
+		return errRelayAuthentication
+	}
+	return nil
+}
+
+// serveClient executes the requests of the hub until the connection is closed
+func (r *RelayServer) serveClient(conn net.Conn) {
+	defer r.routines.Done()
+
+	if err := r.authenticate(conn); err != nil {
+		logger.Log().WithFields(logrus.Fields{"remote": conn.RemoteAddr().String(), "err": err}).Warn("RelayServer: hub refused")
+		conn.Close()
+		return
+	}
+
+	client := &relayClient{conn: conn}
+	r.lock.Lock()
+	previous := r.client
+	r.client = client
+	open, portErr := r.port != nil, r.portErr
+	r.lock.Unlock()
+	if previous != nil {
+		logger.Log().WithField("remote", previous.conn.RemoteAddr().String()).Info("RelayServer: hub replaced by a new connection")
+		previous.conn.Close()
+	}
+	logger.Log().WithField("remote", conn.RemoteAddr().String()).Info("RelayServer: hub connected")
+	client.send(relayMsgStatus, relayStatusPayload(open, portErr))
+
+	for {
+		msgType, payload, err := readRelayMessage(conn)
+		if err != nil {
+			break
+		}
+
+		port := r.currentPort()
+		if port == nil {
+			continue
+		}
+		switch msgType {
+		case relayMsgData:
+			_, err = port.Write(payload)
+		case relayMsgControl:
+			if len(payload) == 2 && payload[0] == relayLineRts {
+				err = port.SetRTS(payload[1] != 0)
+			} else if len(payload) == 2 && payload[0] == relayLineDtr {
+				err = port.SetDTR(payload[1] != 0)
+			}
+		case relayMsgPurge:
+			// The next requests are written after the input is discarded
+			done := make(chan struct{})
+			select {
+			case r.purges <- done:
+				select {
+				case <-done:
+				case <-r.quit:
+				}
+			case <-r.quit:
+			}
+		}
+		if err != nil {
+			logger.Log().WithFields(logrus.Fields{"type": msgType, "err": err}).Warn("RelayServer: request of the hub failed")
+		}
+	}
+
+	logger.Log().WithField("remote", conn.RemoteAddr().String()).Info("RelayServer: hub disconnected")
+	r.dropClient(client)
+}
+
+func (r *RelayServer) acceptClients() {
+	defer r.routines.Done()
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Log().WithError(err).Error("RelayServer: accept failed")
+			}
+			return
+		}
+		r.routines.Add(1)
+		go r.serveClient(conn)
+	}
+}
+
+// Start listens for the hub on the address, with a tls config the connections are encrypted
+func (r *RelayServer) Start(address string, tlsConfig *tls.Config) error {
+	if r.secret == "" {
+		return fmt.Errorf("%w: the relay needs a secret", ErrInvalidRequest)
+	}
+
+	var err error
+	if tlsConfig != nil {
+		r.listener, err = tls.Listen("tcp", address, tlsConfig)
+	} else {
+		r.listener, err = net.Listen("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Log().WithFields(logrus.Fields{"address": r.listener.Addr().String(), "tls": tlsConfig != nil, "port": r.portName}).Info("RelayServer: listening")
+	r.routines.Add(2)
+	go r.servePort()
+	go r.acceptClients()
+	return nil
+}
+
+// Addr returns the address the relay is listening on
+func (r *RelayServer) Addr() net.Addr {
+	return r.listener.Addr()
+}
+
+func (r *RelayServer) Stop() {
+	close(r.quit)
+	r.listener.Close()
+	if client := r.currentClient(); client != nil {
+		r.dropClient(client)
+	}
+	r.routines.Wait()
+}
+
+func NewRelayServer(portName string, baudRate int, secret string) *RelayServer {
+	return &RelayServer{portName: portName, baudRate: baudRate, secret: secret, purges: make(chan chan struct{}), quit: make(chan struct{})}
+}
@@ -0,0 +1,277 @@
+package meshmesh
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrafficClass is the priority of a request, the classes with a lower value are sent first
+type TrafficClass uint8
+
+const (
+	// Data of the ESPHome API connections, someone is waiting for it
+	InteractiveTraffic TrafficClass = iota
+	// Commands sent from the REST and gRPC servers
+	ControlTraffic
+	// Firmware updates and the other long transfers
+	BulkTraffic
+	// Discovery and the periodic polling of the nodes
+	BackgroundTraffic
+	trafficClassCount
+)
+
+var trafficClassNames = [trafficClassCount]string{"interactive", "control", "bulk", "background"}
+
+func (class TrafficClass) String() string {
+	if class < trafficClassCount {
+		return trafficClassNames[class]
+	}
+	return fmt.Sprintf("class%d", class)
+}
+
+// ParseTrafficClass returns the class with the given name
+func ParseTrafficClass(name string) (TrafficClass, error) {
+	for class, className := range trafficClassNames {
+		if strings.EqualFold(className, name) {
+			return TrafficClass(class), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unknown traffic class %s", ErrInvalidRequest, name)
+}
+
+// Airtime of a byte at the 1Mbit/s rate of the 802.11b frames
+const airtimePerByte = 8 * time.Microsecond
+
+// Preamble, ack and backoff of every frame sent on air
+const airtimePerFrame = time.Millisecond
+
+// Period used to measure the airtime used by every class
+const airtimeWindow = time.Second
+
+// A request without reply is followed by a guard time for the wifi retransmissions,
+// long enough to repeat the frame guardRetries times on every hop
+const minGuardTime = 5 * time.Millisecond
+const guardRetries = 3
+
+type trafficClassKey struct{}
+
+// WithTrafficClass returns a context that sends the requests made with it in the given class
+func WithTrafficClass(ctx context.Context, class TrafficClass) context.Context {
+	return context.WithValue(ctx, trafficClassKey{}, class)
+}
+
+// trafficClassFrom returns the class of the requests made with the context, control by default
+func trafficClassFrom(ctx context.Context) TrafficClass {
+	if class, ok := ctx.Value(trafficClassKey{}).(TrafficClass); ok && class < trafficClassCount {
+		return class
+	}
+	return ControlTraffic
+}
+
+// airtime estimates the time on air of a session, the reply is assumed as long as the request
+func (session *SerialSession) airtime() time.Duration {
+	if session.Hops == 0 {
+		// Handled by the coordinator without using the radio
+		return 0
+	}
+	airtime := time.Duration(session.Hops) * (airtimePerFrame + time.Duration(len(session.Request.data))*airtimePerByte)
+	if session.IsAwaitable() {
+		airtime *= 2
+	}
+	return airtime
+}
+
+// guardTime returns the time to wait after a session without reply before sending the next one
+func (session *SerialSession) guardTime() time.Duration {
+	return minGuardTime + guardRetries*session.airtime()
+}
+
+type trafficClassState struct {
+	queue *list.List
+	// Percent of the airtime that the class can use, 0 for no limit
+	budget int
+	// Airtime used and not yet paid back at the budget rate
+	debt time.Duration
+	// Airtime used in the last window, decays exponentially
+	recent  time.Duration
+	updated time.Time
+	sent    uint64
+	airtime time.Duration
+}
+
+// settle pays back the debt and decays the recent airtime up to now
+func (state *trafficClassState) settle(now time.Time) {
+	elapsed := now.Sub(state.updated)
+	if elapsed <= 0 {
+		return
+	}
+	if state.budget > 0 {
+		state.debt = max(0, state.debt-elapsed*time.Duration(state.budget)/100)
+	}
+	state.recent = time.Duration(float64(state.recent) * math.Exp(-float64(elapsed)/float64(airtimeWindow)))
+	state.updated = now
+}
+
+// blockedUntil returns when the class will be back within its budget, zero if it is already
+func (state *trafficClassState) blockedUntil(now time.Time) time.Time {
+	if state.budget == 0 {
+		return time.Time{}
+	}
+	burst := airtimeWindow * time.Duration(state.budget) / 100
+	if state.debt <= burst {
+		return time.Time{}
+	}
+	return now.Add((state.debt - burst) * 100 / time.Duration(state.budget))
+}
+
+func (state *trafficClassState) charge(airtime time.Duration) {
+	state.sent += 1
+	state.airtime += airtime
+	state.recent += airtime
+	if state.budget > 0 {
+		state.debt += airtime
+	}
+}
+
+// trafficScheduler keeps a queue for every traffic class, it is guarded by SessionsLock
+type trafficScheduler struct {
+	classes [trafficClassCount]trafficClassState
+}
+
+func newTrafficScheduler() *trafficScheduler {
+	scheduler := &trafficScheduler{}
+	now := time.Now()
+	for class := range scheduler.classes {
+		scheduler.classes[class].queue = list.New()
+		scheduler.classes[class].updated = now
+	}
+	return scheduler
+}
+
+func (scheduler *trafficScheduler) push(session *SerialSession) {
+	scheduler.classes[min(session.Class, trafficClassCount-1)].queue.PushBack(session)
+}
+
+// remove takes a session out of its queue, it returns false if it was not queued
+func (scheduler *trafficScheduler) remove(session *SerialSession) bool {
+	queue := scheduler.classes[min(session.Class, trafficClassCount-1)].queue
+	for element := queue.Front(); element != nil; element = element.Next() {
+		if element.Value == session {
+			queue.Remove(element)
+			return true
+		}
+	}
+	return false
+}
+
+// drain empties the queues and returns the sessions that were waiting
+func (scheduler *trafficScheduler) drain() []*SerialSession {
+	sessions := make([]*SerialSession, 0)
+	for class := range scheduler.classes {
+		queue := scheduler.classes[class].queue
+		for element := queue.Front(); element != nil; element = queue.Front() {
+			sessions = append(sessions, queue.Remove(element).(*SerialSession))
+		}
+	}
+	return sessions
+}
+
+func (scheduler *trafficScheduler) queued() int {
+	queued := 0
+	for class := range scheduler.classes {
+		queued += scheduler.classes[class].queue.Len()
+	}
+	return queued
+}
+
+// ParseAirtimeBudgets reads a list of class=percent items separated by commas, like "bulk=50,background=20"
+func ParseAirtimeBudgets(spec string) (map[TrafficClass]int, error) {
+	budgets := make(map[TrafficClass]int)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: airtime budget %s is not class=percent", ErrInvalidRequest, item)
+		}
+		class, err := ParseTrafficClass(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		percent, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("%w: airtime budget of %s must be a percent", ErrInvalidRequest, class)
+		}
+		budgets[class] = percent
+	}
+	return budgets, nil
+}
+
+// SetAirtimeBudgets limits the percent of airtime used by the traffic classes, 0 removes the limit.
+// The classes not in the map are not changed.
+func (serialConn *SerialConnection) SetAirtimeBudgets(budgets map[TrafficClass]int) {
+	serialConn.SessionsLock.Lock()
+	now := time.Now()
+	for class, percent := range budgets {
+		if class < trafficClassCount {
+			state := &serialConn.scheduler.classes[class]
+			state.settle(now)
+			state.budget = min(max(percent, 0), 100)
+			if state.budget == 0 {
+				state.debt = 0
+			}
+		}
+	}
+	serialConn.SessionsLock.Unlock()
+	serialConn.wakeupWriter()
+}
+
+// TrafficClassStats reports the requests waiting and the airtime used by a traffic class
+type TrafficClassStats struct {
+	Class   TrafficClass
+	Budget  int
+	Queued  int
+	Sent    uint64
+	Airtime time.Duration
+	// Percent of the airtime used in the last second
+	Usage float64
+}
+
+type SchedulerStats struct {
+	Queued   int
+	Inflight int
+	Classes  []TrafficClassStats
+}
+
+// SchedulerStats returns the depth of the queues and the airtime used by every traffic class
+func (serialConn *SerialConnection) SchedulerStats() SchedulerStats {
+	serialConn.SessionsLock.Lock()
+	defer serialConn.SessionsLock.Unlock()
+
+	now := time.Now()
+	stats := SchedulerStats{
+		Queued:   serialConn.scheduler.queued(),
+		Inflight: len(serialConn.inflight),
+		Classes:  make([]TrafficClassStats, 0, trafficClassCount),
+	}
+	for class := range serialConn.scheduler.classes {
+		state := &serialConn.scheduler.classes[class]
+		state.settle(now)
+		stats.Classes = append(stats.Classes, TrafficClassStats{
+			Class:   TrafficClass(class),
+			Budget:  state.budget,
+			Queued:  state.queue.Len(),
+			Sent:    state.sent,
+			Airtime: state.airtime,
+			Usage:   float64(state.recent) * 100 / float64(airtimeWindow),
+		})
+	}
+	return stats
+}
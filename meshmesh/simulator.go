@@ -0,0 +1,497 @@
+package meshmesh
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"leguru.net/m/v2/graph"
+	"leguru.net/m/v2/logger"
+	pb "leguru.net/m/v2/meshmesh/pb"
+	"leguru.net/m/v2/utils"
+)
+
+const (
+	simulatorHopDelay           = 5 * time.Millisecond
+	simulatorPresentationPeriod = 5 * time.Second
+	simulatorOutputQueueSize    = 64
+	simulatorDefaultFirmware    = "1.5.0"
+	simulatorDefaultChannel     = 1
+)
+
+type simulatedConnection struct {
+	target MeshNodeId
+	port   uint16
+	hops   int
+}
+
+// Simulator is an in memory coordinator node. It speaks the same framed protocol of the real
+// firmware and answers for every node of a GraphML topology, the edges of the topology are
+// the radio links between the nodes.
+type Simulator struct {
+	topology    *graph.Network
+	coordinator MeshNodeId
+	nodes       map[MeshNodeId]*simulatedNode
+	connections map[uint16]simulatedConnection
+	decoder     *frameDecoder
+	output      chan []byte
+	pending     []byte
+	pendingLock sync.Mutex
+	readTimeout time.Duration
+	lock        sync.Mutex
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+func (s *Simulator) Read(p []byte) (int, error) {
+	s.pendingLock.Lock()
+	if len(s.pending) > 0 {
+		defer s.pendingLock.Unlock()
+		return s.readPending(p), nil
+	}
+	s.pendingLock.Unlock()
+
+	var timeout <-chan time.Time
+	if s.readTimeout > 0 {
+		timeout = time.After(s.readTimeout)
+	}
+
+	select {
+	case data := <-s.output:
+		s.pendingLock.Lock()
+		defer s.pendingLock.Unlock()
+		s.pending = append(s.pending, data...)
+		return s.readPending(p), nil
+	case <-timeout:
+		return 0, nil
+	case <-s.closed:
+		return 0, io.EOF
+	}
+}
+
+// readPending copies the pending bytes, the caller holds pendingLock
+func (s *Simulator) readPending(p []byte) int {
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n
+}
+
+func (s *Simulator) Write(p []byte) (int, error) {
+	select {
+	case <-s.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	for _, b := range p {
+		frame, crcOk := s.decoder.decodeByte(b)
+		if frame != nil && crcOk {
+			s.handleFrame(frame)
+		}
+	}
+	return len(p), nil
+}
+
+func (s *Simulator) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+func (s *Simulator) SetReadTimeout(t time.Duration) error {
+	s.readTimeout = t
+	return nil
+}
+
+func (s *Simulator) ResetInputBuffer() error {
+	s.pendingLock.Lock()
+	defer s.pendingLock.Unlock()
+
+	s.pending = nil
+	for {
+		select {
+		case <-s.output:
+		default:
+			return nil
+		}
+	}
+}
+
+// SetRTS emulates the reset line of the coordinator, all the connected paths are dropped
+func (s *Simulator) SetRTS(rts bool) error {
+	if rts {
+		s.lock.Lock()
+		s.connections = make(map[uint16]simulatedConnection)
+		s.lock.Unlock()
+	}
+	return nil
+}
+
+func (s *Simulator) SetDTR(dtr bool) error {
+	return nil
+}
+
+// send queues a reply frame for the host after the given delay
+func (s *Simulator) send(delay time.Duration, data []byte) {
+	if data == nil {
+		return
+	}
+
+	out := NewApiFrame(data, false).Output()
+	push := func() {
+		select {
+		case s.output <- out:
+		case <-s.closed:
+		}
+	}
+
+	if delay > 0 {
+		time.AfterFunc(delay, push)
+	} else {
+		push()
+	}
+}
+
+// node returns the simulated node if it exists and is powered on
+func (s *Simulator) node(id MeshNodeId) *simulatedNode {
+	node, ok := s.nodes[id]
+	if !ok {
+		return nil
+	}
+	dev, err := s.topology.GetNodeDevice(int64(id))
+	if err != nil || !dev.Device().InUse() {
+		return nil
+	}
+	return node
+}
+
+// checkRoute verifies that every hop of the route is a link of the topology
+func (s *Simulator) checkRoute(route []MeshNodeId) bool {
+	for i := range len(route) - 1 {
+		if s.node(route[i+1]) == nil || !s.topology.HasEdgeBetween(int64(route[i]), int64(route[i+1])) {
+			logger.WithFields(logger.Fields{"from": utils.FmtNodeId(int64(route[i])), "to": utils.FmtNodeId(int64(route[i+1]))}).
+				Debug("Simulator: packet lost, no link between nodes")
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Simulator) hopDelay(hops int) time.Duration {
+	return time.Duration(hops) * simulatorHopDelay
+}
+
+// wrapReply puts the reply of a remote node in the envelope with its address, like the firmware does
+func wrapReply(id uint8, source MeshNodeId, reply []byte) []byte {
+	if reply == nil {
+		return nil
+	}
+	wrapped := []byte{id, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(wrapped[1:], uint32(source))
+	return append(wrapped, reply...)
+}
+
+func (s *Simulator) handleFrame(data []byte) {
+	switch data[0] {
+	case connectedUnicastRequest:
+		if len(data) < 6 {
+			return
+		}
+		target := MeshNodeId(binary.LittleEndian.Uint32(data[1:5]))
+		if !s.checkRoute([]MeshNodeId{s.coordinator, target}) {
+			return
+		}
+		s.send(s.hopDelay(2), wrapReply(connectedUnicastReply, target, s.node(target).handleRequest(s, data[5:])))
+	case multipathRequest:
+		if len(data) < 6 {
+			return
+		}
+		target := MeshNodeId(binary.LittleEndian.Uint32(data[1:5]))
+		pathLen := int(data[5])
+		if len(data) < 6+4*pathLen+1 {
+			return
+		}
+		route := []MeshNodeId{s.coordinator}
+		for i := range pathLen {
+			route = append(route, MeshNodeId(binary.LittleEndian.Uint32(data[6+4*i:])))
+		}
+		route = append(route, target)
+		if !s.checkRoute(route) {
+			return
+		}
+		reply := s.node(target).handleRequest(s, data[6+4*pathLen:])
+		s.send(s.hopDelay(2*(len(route)-1)), wrapReply(multipathReply, target, reply))
+	case broadcastRequest:
+		if len(data) < 2 {
+			return
+		}
+		s.handleBroadcast(data[1:])
+	case politeBroadcastRequest:
+		if len(data) < 2 {
+			return
+		}
+		s.handlePoliteBroadcast(data[1:])
+	case connectedPathApiRequest:
+		s.handleConnectedPath(data)
+	default:
+		s.send(0, s.nodes[s.coordinator].handleRequest(s, data))
+	}
+}
+
+// handleBroadcast delivers the request to the neighbors of the coordinator, every reply is wrapped with its source
+func (s *Simulator) handleBroadcast(payload []byte) {
+	neighbors := s.topology.From(int64(s.coordinator))
+	for i := 0; neighbors.Next(); i++ {
+		id := MeshNodeId(neighbors.Node().ID())
+		node := s.node(id)
+		if node == nil {
+			continue
+		}
+		// The nodes don't answer all at the same time
+		s.send(s.hopDelay(2+i), wrapReply(broadcastReply, id, node.handleRequest(s, payload)))
+	}
+}
+
+// handlePoliteBroadcast floods the request through the links of the topology, every node reached answers once
+func (s *Simulator) handlePoliteBroadcast(payload []byte) {
+	hops := map[MeshNodeId]int{s.coordinator: 0}
+	queue := []MeshNodeId{s.coordinator}
+	for i := 0; len(queue) > 0; i++ {
+		id := queue[0]
+		queue = queue[1:]
+
+		if id != s.coordinator {
+			s.send(s.hopDelay(2*hops[id]+i), wrapReply(politeBroadcastReply, id, s.nodes[id].handleRequest(s, payload)))
+		}
+
+		neighbors := s.topology.From(int64(id))
+		for neighbors.Next() {
+			next := MeshNodeId(neighbors.Node().ID())
+			if _, ok := hops[next]; ok || s.node(next) == nil {
+				continue
+			}
+			hops[next] = hops[id] + 1
+			queue = append(queue, next)
+		}
+	}
+}
+
+func (s *Simulator) connectedPathReply(command uint8, handle uint16, payload []byte) []byte {
+	reply := []byte{connectedPathApiReply, command, 0, 0}
+	binary.LittleEndian.PutUint16(reply[2:], handle)
+	return append(reply, payload...)
+}
+
+// handleConnectedPath emulates the connected path protocol, the remote node acts as an echo server.
+func (s *Simulator) handleConnectedPath(data []byte) {
+	if len(data) < 11 {
+		return
+	}
+
+	command := data[2]
+	handle := binary.LittleEndian.Uint16(data[3:5])
+	payload := data[11:]
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch command {
+	case connectedPathOpenConnectionRequest:
+		if len(payload) < 3 {
+			return
+		}
+		port := binary.LittleEndian.Uint16(payload[0:2])
+		pathLen := int(payload[2])
+		if pathLen == 0 || len(payload) < 3+4*pathLen {
+			return
+		}
+		route := []MeshNodeId{s.coordinator}
+		for i := range pathLen {
+			route = append(route, MeshNodeId(binary.LittleEndian.Uint32(payload[3+4*i:])))
+		}
+		hops := len(route) - 1
+		if !s.checkRoute(route) {
+			s.send(s.hopDelay(hops), s.connectedPathReply(connectedPathOpenConnectionNack, handle, nil))
+			return
+		}
+		s.connections[handle] = simulatedConnection{target: route[hops], port: port, hops: hops}
+		s.send(s.hopDelay(2*hops), s.connectedPathReply(connectedPathOpenConnectionAck, handle, nil))
+	case connectedPathSendDataRequest:
+		conn, ok := s.connections[handle]
+		if !ok || s.node(conn.target) == nil {
+			s.send(0, s.connectedPathReply(connectedPathSendDataNackReply, handle, nil))
+			return
+		}
+		s.send(s.hopDelay(2*conn.hops), s.connectedPathReply(connectedPathSendDataRequest, handle, slices.Clone(payload)))
+	case connectedPathDisconnectRequest:
+		delete(s.connections, handle)
+	case connectedPathClearConnections:
+		s.connections = make(map[uint16]simulatedConnection)
+	}
+}
+
+// neighborTable builds the discovery table of a node from the links of the topology
+func (s *Simulator) neighborTable(id MeshNodeId) []DiscTableItemGetApiReply {
+	table := make([]DiscTableItemGetApiReply, 0)
+	neighbors := s.topology.From(int64(id))
+	for neighbors.Next() {
+		neighbor := neighbors.Node().(graph.NodeDevice)
+		if s.node(MeshNodeId(neighbor.ID())) == nil {
+			continue
+		}
+		weightTo, _ := s.topology.Weight(int64(id), neighbor.ID())
+		weightFrom, ok := s.topology.Weight(neighbor.ID(), int64(id))
+		if !ok {
+			weightFrom = weightTo
+		}
+		table = append(table, DiscTableItemGetApiReply{
+			NodeId: uint32(neighbor.ID()),
+			Rssi1:  weight2Rssi(weightTo),
+			Rssi2:  weight2Rssi(weightFrom),
+		})
+	}
+	return table
+}
+
+// presentNode sends a star path presentation of the node as received by the coordinator
+func (s *Simulator) presentNode(id MeshNodeId) {
+	dev, err := s.topology.GetNodeDevice(int64(id))
+	if err != nil {
+		return
+	}
+	path, _, err := s.topology.GetPath(dev)
+	if err != nil || len(path) < 2 {
+		return
+	}
+
+	// Repeaters are listed from the source node to the coordinator, costs from the coordinator to the source node
+	repeaters := make([]uint32, 0, len(path)-2)
+	for i := len(path) - 2; i > 0; i-- {
+		repeaters = append(repeaters, uint32(path[i]))
+	}
+	costs := make([]int32, 0, len(path)-1)
+	for i := range len(path) - 1 {
+		weight, _ := s.topology.Weight(path[i], path[i+1])
+		costs = append(costs, int32(weight*100))
+	}
+
+	node := s.nodes[id]
+	presentation := &pb.NodePresentationRx{
+		NodePresentation: &pb.NodePresentation{
+			Hostname:        node.hostname(),
+			FirmwareVersion: node.firmware,
+			CompileTime:     node.compileTime,
+			LibVersion:      node.firmware,
+			Type:            pb.NodePresentationFlags_NODE_PRESENTATION_TYPE_REFRESH,
+			NodeType:        pb.NodeType(node.nodeType),
+		},
+		PathRouting: &pb.PathRouting{
+			SourceAddress: uint32(id),
+			TargetAddress: uint32(s.coordinator),
+			Repeaters:     repeaters,
+			Rssi:          costs,
+			Direction:     pb.PathDirection_TO_COORDINATOR,
+		},
+	}
+
+	data, err := proto.Marshal(presentation)
+	if err != nil {
+		logger.WithError(err).Error("Simulator: can't encode node presentation")
+		return
+	}
+	s.send(s.hopDelay(len(path)-1), append([]byte{protoPresentationRxApiReply}, data...))
+}
+
+// presentationRoutine makes the nodes present themselves to the coordinator one at a time
+func (s *Simulator) presentationRoutine() {
+	ticker := time.NewTicker(simulatorPresentationPeriod)
+	defer ticker.Stop()
+
+	// The index is owned by this routine, the first round presents the first node
+	index := -1
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			ids := make([]MeshNodeId, 0, len(s.nodes))
+			for id := range s.nodes {
+				if id != s.coordinator && s.node(id) != nil {
+					ids = append(ids, id)
+				}
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			slices.Sort(ids)
+			index = (index + 1) % len(ids)
+			s.presentNode(ids[index])
+		}
+	}
+}
+
+// findCoordinator returns the node with the coordinator type or the node with the lowest id
+func findCoordinator(topology *graph.Network) (MeshNodeId, error) {
+	var coordinator int64
+	var lowest int64
+	nodes := topology.Nodes()
+	for nodes.Next() {
+		node := nodes.Node().(graph.NodeDevice)
+		if node.Device().NodeType() == graph.NodeTypeCoordinator && (coordinator == 0 || node.ID() < coordinator) {
+			coordinator = node.ID()
+		}
+		if lowest == 0 || node.ID() < lowest {
+			lowest = node.ID()
+		}
+	}
+
+	if coordinator == 0 {
+		coordinator = lowest
+	}
+	if coordinator == 0 {
+		return 0, errors.New("simulator topology is empty")
+	}
+	return MeshNodeId(coordinator), nil
+}
+
+func NewSimulator(topologyFile string) (*Simulator, error) {
+	topology, err := graph.NewNeworkFromFile(topologyFile, 0, graph.NETWORK_ID_SIMULATOR)
+	if err != nil {
+		return nil, err
+	}
+
+	coordinator, err := findCoordinator(topology)
+	if err != nil {
+		return nil, err
+	}
+	topology.LocalDeviceIdChanged(int64(coordinator), nil)
+
+	s := &Simulator{
+		topology:    topology,
+		coordinator: coordinator,
+		nodes:       make(map[MeshNodeId]*simulatedNode),
+		connections: make(map[uint16]simulatedConnection),
+		decoder:     newFrameDecoder(),
+		output:      make(chan []byte, simulatorOutputQueueSize),
+		closed:      make(chan struct{}),
+	}
+
+	nodes := topology.Nodes()
+	for nodes.Next() {
+		node := nodes.Node().(graph.NodeDevice)
+		s.nodes[MeshNodeId(node.ID())] = newSimulatedNode(node)
+	}
+
+	logger.WithFields(logger.Fields{"coordinator": utils.FmtNodeId(int64(coordinator)), "nodes": len(s.nodes), "topology": topologyFile}).
+		Info("Simulator: coordinator started")
+
+	go s.presentationRoutine()
+	return s, nil
+}
+
+func openSimulatorTransport(address string, _ int) (Transport, error) {
+	return NewSimulator(address)
+}
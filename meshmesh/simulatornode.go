@@ -0,0 +1,171 @@
+package meshmesh
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/go-restruct/restruct"
+	"google.golang.org/protobuf/proto"
+	"leguru.net/m/v2/graph"
+	"leguru.net/m/v2/logger"
+	pb "leguru.net/m/v2/meshmesh/pb"
+	"leguru.net/m/v2/utils"
+)
+
+// simulatedNode holds the state of a single node of the simulated network
+type simulatedNode struct {
+	id           MeshNodeId
+	tag          string
+	friendlyName string
+	firmware     string
+	compileTime  string
+	nodeType     graph.NodeType
+	channel      uint8
+	discTable    []DiscTableItemGetApiReply
+	entityStates map[uint16]uint16
+	// lock guards the state changed by the requests
+	lock sync.Mutex
+}
+
+// hostname returns the tag that the node presents to the coordinator
+func (n *simulatedNode) hostname() string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.tag
+}
+
+func (n *simulatedNode) fixedString(s string, size int) []byte {
+	b := make([]byte, size)
+	copy(b[:size-1], s)
+	return b
+}
+
+func (n *simulatedNode) pack(v any) []byte {
+	b, err := restruct.Pack(binary.LittleEndian, v)
+	if err != nil {
+		logger.WithError(err).Error("Simulator: can't encode reply")
+		return nil
+	}
+	return b
+}
+
+func (n *simulatedNode) handleDiscovery(sim *Simulator, data []byte) []byte {
+	if len(data) < 2 {
+		return nil
+	}
+
+	switch data[1] {
+	case discResetTableApiRequest:
+		n.discTable = nil
+		return n.pack(&DiscResetTableApiReply{Id: discoveryApiReply, ApiId: discResetTableApiReply})
+	case discStartDiscoverApiRequest:
+		n.discTable = sim.neighborTable(n.id)
+		return n.pack(&DiscStartDiscoverApiReply{Id: discoveryApiReply, ApiId: discStartDiscoverApiReply})
+	case discTableSizeApiRequest:
+		return n.pack(&DiscTableSizeApiReply{Id: discoveryApiReply, ApiId: discTableSizeApiReply, Size: uint8(len(n.discTable))})
+	case discTableItemGetApiRequest:
+		if len(data) < 3 {
+			return nil
+		}
+		item := DiscTableItemGetApiReply{}
+		if int(data[2]) < len(n.discTable) {
+			item = n.discTable[data[2]]
+		}
+		item.Id = discoveryApiReply
+		item.ApiId = discTableItemGetApiReply
+		item.Index = data[2]
+		return n.pack(&item)
+	}
+	return nil
+}
+
+// handleRequest answers to a request addressed to this node, nil means no reply.
+func (n *simulatedNode) handleRequest(sim *Simulator, data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	switch data[0] {
+	case echoApiRequest:
+		return append([]byte{echoApiReply}, data[1:]...)
+	case firmRevApiRequest:
+		return append([]byte{firmRevApiReply}, []byte(n.firmware)...)
+	case nodeIdApiRequest:
+		return n.pack(&NodeIdApiReply{Id: nodeIdApiReply, Serial: n.id})
+	case nodeGetTagApiRequest:
+		return n.pack(&NodeGetTagApiReply{Id: nodeGetTagApiReply, Tag: n.fixedString(n.tag, 31)})
+	case nodeSetTagApiRequest:
+		n.tag = utils.TruncateZeros(data[1:])
+		return []byte{nodeSetTagApiReply}
+	case nodeBindClearApiRequest:
+		return []byte{nodeBindClearApiReply}
+	case nodeSetChannelApiRequest:
+		if len(data) > 1 {
+			n.channel = data[1]
+		}
+		return []byte{nodeSetChannelApiReply}
+	case nodeConfigApiRequest:
+		return n.pack(&NodeConfigApiReply{Id: nodeConfigApiReply, Tag: n.fixedString(n.tag, 32), Channel: n.channel, TxPower: 20})
+	case protoNodeInfoApiRequest:
+		info, err := proto.Marshal(&pb.NodeInfo{
+			FriendlyName:    n.friendlyName,
+			FirmwareVersion: n.firmware,
+			MacAddress:      fmt.Sprintf("FE:7F:00:%02X:%02X:%02X", byte(n.id>>16), byte(n.id>>8), byte(n.id)),
+			Platform:        "ESP32",
+			Board:           "esp32dev",
+			CompileTime:     n.compileTime,
+			LibVersion:      n.firmware,
+			NodeType:        int32(n.nodeType),
+		})
+		if err != nil {
+			return nil
+		}
+		return append([]byte{protoNodeInfoApiReply}, info...)
+	case nodeRebootApiRequest:
+		return []byte{nodeRebootApiReply}
+	case entitiesCountApiRequest:
+		return n.pack(&EntitiesCountApiReply{Id: entitiesCountApiReply, Counters: make([]uint8, 6)})
+	case entityHashApiRequest:
+		// The simulated nodes don't have entities
+		return append([]byte{entityHashApiReply, 0, 0}, []byte("E!")...)
+	case getEntityStateApiRequest:
+		if len(data) < 4 {
+			return nil
+		}
+		hash := binary.LittleEndian.Uint16(data[2:4])
+		return n.pack(&GetEntityStateApiReply{Id: getEntityStateApiReply, State: n.entityStates[hash]})
+	case setEntityStateApiRequest:
+		if len(data) < 6 {
+			return nil
+		}
+		n.entityStates[binary.LittleEndian.Uint16(data[2:4])] = binary.LittleEndian.Uint16(data[4:6])
+		return []byte{setEntityStateApiReply}
+	case discoveryApiRequest:
+		return n.handleDiscovery(sim, data)
+	}
+
+	logger.WithFields(logger.Fields{"node": utils.FmtNodeId(int64(n.id)), "type": data[0]}).Debug("Simulator: unsupported request")
+	return nil
+}
+
+func newSimulatedNode(node graph.NodeDevice) *simulatedNode {
+	firmware := node.Device().Firmware()
+	if firmware == "" {
+		firmware = simulatorDefaultFirmware
+	}
+
+	return &simulatedNode{
+		id:           MeshNodeId(node.ID()),
+		tag:          node.Device().Tag(),
+		friendlyName: node.Device().FriendlyName(),
+		firmware:     firmware,
+		compileTime:  node.Device().CompileTimeString(),
+		nodeType:     node.Device().NodeType(),
+		channel:      simulatorDefaultChannel,
+		entityStates: make(map[uint16]uint16),
+	}
+}
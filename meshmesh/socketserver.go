@@ -11,8 +11,6 @@ import (
 	"leguru.net/m/v2/utils"
 )
 
-var _allStats *EspApiStats
-
 const (
 	fixedApiRemotePort int = 6053
 	fixedOtaRemotePort int = 3232
@@ -25,6 +23,7 @@ type SocketServer struct {
 	listenAddress string
 	listnerClosed func(*SocketServer)
 	network       *graph.Network
+	stats         *EspApiStats
 	hasShutdown   bool
 }
 
@@ -67,7 +66,7 @@ func (s *SocketServer) ListenAndServe(serialProxy *ConnectedPath2Serial, remoteP
 		logger.WithFields(logger.Fields{"nodeId": utils.FmtNodeId(int64(s.Address)), "address": s.listenAddress, "active": len(s.Clients)}).Debug("ServerSocket.ListenAndServe: connection accepted")
 
 		driver := s.connectionFactory(remotePort)
-		client, err := NewConnectionPathBridge(socket, serialProxy, s.network, s.Address, remotePort, driver, s.ClientClosedCb)
+		client, err := NewConnectionPathBridge(socket, serialProxy, s.network, s.Address, remotePort, driver, s.stats.Stats(s.Address), s.ClientClosedCb)
 		if err == nil {
 			s.Clients = append(s.Clients, client)
 		} else {
@@ -94,7 +93,14 @@ func (s *SocketServer) ShutDown() {
 	s.hasShutdown = true
 }
 
-func NewSocketServer(serialProxy *ConnectedPath2Serial, network *graph.Network, address MeshNodeId, config *ServerApiConfig, listenerClosed func(*SocketServer)) (*SocketServer, error) {
+// CloseClients closes the connections of the clients while the server keeps listening for new ones
+func (s *SocketServer) CloseClients() {
+	for _, client := range slices.Clone(s.Clients) {
+		client.close()
+	}
+}
+
+func NewSocketServer(serialProxy *ConnectedPath2Serial, network *graph.Network, address MeshNodeId, config *ServerApiConfig, stats *EspApiStats, listenerClosed func(*SocketServer)) (*SocketServer, error) {
 	var bindAddress string = config.BindAddress
 	if config.BindAddress == "" || config.BindAddress == "dynamic" {
 		bindAddress = utils.FmtNodeIdHass(int64(address))
@@ -105,7 +111,7 @@ func NewSocketServer(serialProxy *ConnectedPath2Serial, network *graph.Network,
 		bindPort = utils.HashString(utils.FmtNodeId(int64(address)), config.SizeOfPortsPool) + config.BasePortOffset
 	}
 
-	server := SocketServer{Address: address, network: network, listnerClosed: listenerClosed}
+	server := SocketServer{Address: address, network: network, stats: stats, listnerClosed: listenerClosed}
 	server.listenAddress = fmt.Sprintf("%s:%d", bindAddress, bindPort)
 	listener, err := net.Listen("tcp4", server.listenAddress)
 	if err != nil {
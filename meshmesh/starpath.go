@@ -5,6 +5,8 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"leguru.net/m/v2/graph"
@@ -14,8 +16,10 @@ import (
 )
 
 type StarPath struct {
-	serial  *SerialConnection
-	network *graph.Network
+	serial          atomic.Pointer[SerialConnection]
+	attachedSerials map[*SerialConnection]bool
+	attachLock      sync.Mutex
+	network         *graph.Network
 }
 
 func (s *StarPath) GetNetwork() *graph.Network {
@@ -26,18 +30,20 @@ func (s *StarPath) GetNetwork() *graph.Network {
 If toId not exists, create a new node with the toId and add it to the network, otherwise remove all input edges from the toId node.
 Then add a new edge from the fromId node to the toId node.
 */
-func (s *StarPath) refreshInputEdges(fromId int64, toId int64, weight float64) {
-	if !s.network.NodeIdExists(toId) {
+func (s *StarPath) refreshInputEdges(tx *graph.NetworkTx, fromId int64, toId int64, weight float64) {
+	if !tx.NodeIdExists(toId) {
 		node := graph.NewNodeDevice(toId, true, "")
-		s.network.AddNode(node)
+		tx.AddNode(node)
 	} else {
-		edges := s.network.EdgesTo(toId)
+		edges := tx.EdgesTo(toId)
 		for edges.Next() {
-			edge := edges.Edge()
-			s.network.RemoveEdge(edge.From().ID(), edge.To().ID())
+			// The edge from fromId is kept to publish only the change of its weight
+			if edge := edges.Edge(); edge.From().ID() != fromId {
+				tx.RemoveEdge(edge.From().ID(), edge.To().ID())
+			}
 		}
 	}
-	s.network.ChangeEdgeWeight(fromId, toId, weight, weight)
+	tx.ChangeEdgeWeight(fromId, toId, weight, weight)
 }
 
 func (s *StarPath) buildPathString(source int32, target int32, path []uint32, costs []int32) string {
@@ -77,7 +83,8 @@ func (s *StarPath) handleProtoPresentationRxReply(data any) {
 		return
 	}
 
-	if v.PathRouting.TargetAddress != uint32(s.serial.LocalNode) {
+	localNode := s.serial.Load().LocalNode.Load()
+	if v.PathRouting.TargetAddress != localNode {
 		logger.Log().Error("PathRouting target address is not the local node")
 		return
 	}
@@ -87,42 +94,56 @@ func (s *StarPath) handleProtoPresentationRxReply(data any) {
 	logger.WithFields(logger.Fields{"repeaters": len(v.PathRouting.Repeaters), "rssi": len(v.PathRouting.Repeaters)}).Info("NodePresentationReply")
 	logger.WithFields(logger.Fields{"path": s.buildPathString(int32(v.PathRouting.SourceAddress), int32(v.PathRouting.TargetAddress), v.PathRouting.Repeaters, v.PathRouting.Rssi)}).Info("PathRouting received")
 
-	if uint32(v.PathRouting.TargetAddress) == s.serial.LocalNode {
-		sourceNodeIsNew := false
+	if uint32(v.PathRouting.TargetAddress) == localNode {
+		// The node and its path are changed in a single transaction
+		s.network.Update(func(tx *graph.NetworkTx) error {
+			s.updatePresentedNode(tx, v)
+			return nil
+		})
+	}
+}
 
-		sourceNode, err := s.network.GetNodeDevice(int64(v.PathRouting.SourceAddress))
-		if err != nil {
-			sourceNode = graph.NewNodeDevice(int64(v.PathRouting.SourceAddress), true, "")
-			sourceNodeIsNew = true
-		}
+// updatePresentedNode adds or updates the node that sent the presentation and the edges of its path
+func (s *StarPath) updatePresentedNode(tx *graph.NetworkTx, v *pb.NodePresentationRx) {
+	sourceNodeIsNew := false
 
-		sourceNode.Device().SetTag(v.NodePresentation.Hostname)
+	sourceNode, err := tx.GetNodeDevice(int64(v.PathRouting.SourceAddress))
+	if err != nil {
+		sourceNode = graph.NewNodeDevice(int64(v.PathRouting.SourceAddress), true, "")
+		sourceNodeIsNew = true
+	}
 
-		sourceNode.Device().SetName(v.NodePresentation.Hostname)
-		sourceNode.Device().SetFirmware(v.NodePresentation.FirmwareVersion)
-		sourceNode.Device().SetCompileTimeString(v.NodePresentation.CompileTime)
-		sourceNode.Device().SetLibVersion(v.NodePresentation.LibVersion)
-		sourceNode.Device().SetDeepSleep(v.NodePresentation.Type == pb.NodePresentationFlags_NODE_PRESENTATION_TYPE_GOODBYE)
-		sourceNode.Device().SetNodeType(graph.NodeType(v.NodePresentation.NodeType))
-		sourceNode.Device().SetLastSeen(time.Now())
+	updateDevice := func(device *graph.Device) {
+		device.SetTag(v.NodePresentation.Hostname)
 
-		if sourceNodeIsNew {
-			s.network.AddNode(sourceNode)
-		}
+		device.SetName(v.NodePresentation.Hostname)
+		device.SetFirmware(v.NodePresentation.FirmwareVersion)
+		device.SetCompileTimeString(v.NodePresentation.CompileTime)
+		device.SetLibVersion(v.NodePresentation.LibVersion)
+		device.SetDeepSleep(v.NodePresentation.Type == pb.NodePresentationFlags_NODE_PRESENTATION_TYPE_GOODBYE)
+		device.SetNodeType(graph.NodeType(v.NodePresentation.NodeType))
+		device.SetLastSeen(time.Now())
+	}
 
-		// Create the path from Target (coordinator) to Source (node)
-		slices.Reverse(v.PathRouting.Repeaters)
-		path := append([]uint32{v.PathRouting.TargetAddress}, v.PathRouting.Repeaters...)
-		path = append(path, v.PathRouting.SourceAddress)
+	if sourceNodeIsNew {
+		updateDevice(sourceNode.Device())
+		tx.AddNode(sourceNode)
+	} else {
+		tx.UpdateNode(sourceNode.ID(), updateDevice)
+	}
 
-		for i := range len(path) - 1 {
-			// new edge is: from:node[i] -> rssi[i] --> to:node[i+1]
-			s.refreshInputEdges(int64(path[i]), int64(path[i+1]), CostToWeight(int16(v.PathRouting.Rssi[i])))
-		}
+	// Create the path from Target (coordinator) to Source (node)
+	slices.Reverse(v.PathRouting.Repeaters)
+	path := append([]uint32{v.PathRouting.TargetAddress}, v.PathRouting.Repeaters...)
+	path = append(path, v.PathRouting.SourceAddress)
 
-		// Reduce unmber of backups for lowpower nodes resuming from sleep
-		s.network.NotifyNetworkChanged(sourceNode.Device().NodeType() == graph.NodeTypeEdge && sourceNodeIsNew)
+	for i := range len(path) - 1 {
+		// new edge is: from:node[i] -> rssi[i] --> to:node[i+1]
+		s.refreshInputEdges(tx, int64(path[i]), int64(path[i+1]), CostToWeight(int16(v.PathRouting.Rssi[i])))
 	}
+
+	// Reduce unmber of backups for lowpower nodes resuming from sleep
+	tx.NoBackup = sourceNode.Device().NodeType() == graph.NodeTypeEdge && sourceNodeIsNew
 }
 
 /*
@@ -142,11 +163,27 @@ func initNetwork(localNodeId int64, filename string) *graph.Network {
 	return network
 }
 
+// SetSerial receives the presentations from another port of the coordinator, the ones
+// received from the previous port are ignored from now on.
+func (s *StarPath) SetSerial(serial *SerialConnection) {
+	s.attachLock.Lock()
+	if !s.attachedSerials[serial] {
+		s.attachedSerials[serial] = true
+		serial.AddFrameReceivedCallback(protoPresentationRxApiReply, 0, func(data any) {
+			if s.serial.Load() == serial {
+				s.handleProtoPresentationRxReply(data)
+			}
+		})
+	}
+	s.attachLock.Unlock()
+	s.serial.Store(serial)
+}
+
 func NewStarPath(serial *SerialConnection, cacheFile string) *StarPath {
 	starPath := &StarPath{
-		serial:  serial,
-		network: initNetwork(int64(serial.LocalNode), cacheFile),
+		attachedSerials: make(map[*SerialConnection]bool),
+		network:         initNetwork(int64(serial.LocalNode.Load()), cacheFile),
 	}
-	starPath.serial.AddFrameReceivedCallback(protoPresentationRxApiReply, 0, starPath.handleProtoPresentationRxReply)
+	starPath.SetSerial(serial)
 	return starPath
 }
@@ -0,0 +1,61 @@
+package meshmesh
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+var errControlLinesNotSupported = errors.New("transport does not support modem control lines")
+
+// Transport is the byte stream used to talk with the coordinator node. A
+// serial.Port satisfies it as is, network transports emulate the missing bits.
+type Transport interface {
+	io.ReadWriteCloser
+	// SetReadTimeout sets the maximum time a Read call waits for data. When the
+	// timeout expires Read returns 0 bytes and no error.
+	SetReadTimeout(t time.Duration) error
+	// ResetInputBuffer discards any pending input data
+	ResetInputBuffer() error
+	// SetRTS sets the RequestToSend modem line
+	SetRTS(rts bool) error
+	// SetDTR sets the DataTerminalReady modem line
+	SetDTR(dtr bool) error
+}
+
+type transportFactory func(address string, baudRate int) (Transport, error)
+
+var transportFactories = map[string]transportFactory{
+	"tcp":     openTcpTransport,
+	"rfc2217": openRfc2217Transport,
+	"sim":     openSimulatorTransport,
+	"replay":  openReplayTransport,
+	"relay":   openRelayTransport,
+	"relays":  openRelayTlsTransport,
+}
+
+func openSerialTransport(portName string, baudRate int) (Transport, error) {
+	mode := &serial.Mode{BaudRate: baudRate}
+	return serial.Open(portName, mode)
+}
+
+// openTransport opens the coordinator port. The port name can be a serial
+// device path or an url like tcp://host:port or rfc2217://host:port.
+// The sim:// scheme starts a simulated coordinator from a GraphML topology,
+// the replay:// scheme plays back a recording of a previous session and relay://host:port
+// (relays:// with TLS) connects to the port served by another hub in relay mode.
+func openTransport(portName string, baudRate int) (Transport, error) {
+	scheme, address, found := strings.Cut(portName, "://")
+	if !found {
+		return openSerialTransport(portName, baudRate)
+	}
+
+	factory, ok := transportFactories[scheme]
+	if !ok {
+		return nil, errors.New("unknown transport scheme: " + scheme)
+	}
+	return factory(address, baudRate)
+}
@@ -0,0 +1,231 @@
+package meshmesh
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"leguru.net/m/v2/logger"
+)
+
+// RelayClientConfig holds the credentials used by the relay:// and relays:// ports
+type RelayClientConfig struct {
+	Secret string
+	// Used by relays://, when nil the certificate of the relay is checked against the system roots
+	TLS *tls.Config
+}
+
+var relayClientConfig atomic.Pointer[RelayClientConfig]
+
+// SetRelayClientConfig sets the credentials of the coordinator ports served by a relay
+func SetRelayClientConfig(config RelayClientConfig) {
+	relayClientConfig.Store(&config)
+}
+
+// RelayTransport is the connection with a relay serving the port of the coordinator, see RelayServer
+type RelayTransport struct {
+	conn        net.Conn
+	writeLock   sync.Mutex
+	readTimeout time.Duration
+	data        chan []byte
+	pending     []byte
+	done        chan struct{}
+	err         error
+	portOpen    atomic.Bool
+	closed      chan struct{}
+	closeOnce   sync.Once
+}
+
+func (t *RelayTransport) send(msgType uint8, payload []byte) error {
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	return writeRelayMessage(t.conn, msgType, payload)
+}
+
+// handleStatus records the state of the coordinator port reported by the relay, it returns
+// an error when the port is closed
+func (t *RelayTransport) handleStatus(payload []byte) error {
+	if len(payload) < 1 {
+		return fmt.Errorf("%w: empty relay status", ErrDecode)
+	}
+	open := payload[0] != 0
+	if t.portOpen.Swap(open) != open || !open {
+		logger.Log().WithFields(logrus.Fields{"relay": t.conn.RemoteAddr().String(), "open": open, "err": string(payload[1:])}).Info("RelayTransport: coordinator port status")
+	}
+	if !open {
+		if len(payload) > 1 {
+			return fmt.Errorf("%w: relay: %s", ErrSerialClosed, payload[1:])
+		}
+		return fmt.Errorf("%w: relay", ErrSerialClosed)
+	}
+	return nil
+}
+
+// receive reads the messages of the relay until the connection is closed or the coordinator port is lost
+func (t *RelayTransport) receive() {
+	defer close(t.done)
+	for {
+		msgType, payload, err := readRelayMessage(t.conn)
+		if err != nil {
+			t.err = err
+			return
+		}
+
+		switch msgType {
+		case relayMsgData:
+			select {
+			case t.data <- payload:
+			case <-t.closed:
+				return
+			}
+		case relayMsgStatus:
+			if err := t.handleStatus(payload); err != nil {
+				t.err = err
+				return
+			}
+		}
+	}
+}
+
+func (t *RelayTransport) Read(p []byte) (int, error) {
+	if len(t.pending) == 0 {
+		var timeout <-chan time.Time
+		if t.readTimeout > 0 {
+			timer := time.NewTimer(t.readTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case t.pending = <-t.data:
+		case <-t.done:
+			return 0, t.err
+		case <-timeout:
+			// Behave like a serial port: a timeout is not an error
+			return 0, nil
+		}
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+func (t *RelayTransport) Write(p []byte) (int, error) {
+	for start := 0; start < len(p); start += 0xFFFF {
+		if err := t.send(relayMsgData, p[start:min(len(p), start+0xFFFF)]); err != nil {
+			return start, err
+		}
+	}
+	return len(p), nil
+}
+
+func (t *RelayTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return t.conn.Close()
+}
+
+func (t *RelayTransport) SetReadTimeout(timeout time.Duration) error {
+	t.readTimeout = timeout
+	return nil
+}
+
+func (t *RelayTransport) ResetInputBuffer() error {
+	if err := t.send(relayMsgPurge, nil); err != nil {
+		return err
+	}
+	t.pending = nil
+	for {
+		select {
+		case <-t.data:
+		case <-time.After(10 * time.Millisecond):
+			return nil
+		}
+	}
+}
+
+func (t *RelayTransport) setLine(line uint8, value bool) error {
+	payload := []byte{line, 0}
+	if value {
+		payload[1] = 1
+	}
+	return t.send(relayMsgControl, payload)
+}
+
+func (t *RelayTransport) SetRTS(rts bool) error {
+	return t.setLine(relayLineRts, rts)
+}
+
+func (t *RelayTransport) SetDTR(dtr bool) error {
+	return t.setLine(relayLineDtr, dtr)
+}
+
+// handshake answers to the challenge of the relay and waits the state of the coordinator port
+func (t *RelayTransport) handshake(secret string) error {
+	t.conn.SetDeadline(time.Now().Add(relayHandshakeTime))
+	defer t.conn.SetDeadline(time.Time{})
+
+	msgType, payload, err := readRelayMessage(t.conn)
+	if err != nil {
+		return err
+	}
+	if msgType != relayMsgHello || len(payload) != relayChallengeSize+1 {
+		return fmt.Errorf("%w: invalid relay hello", ErrDecode)
+	}
+	if payload[0] != relayProtocolVersion {
+		return fmt.Errorf("%w: relay protocol version %d", ErrInvalidRequest, payload[0])
+	}
+	if err := t.send(relayMsgAuth, relayChallengeResponse(secret, payload[1:])); err != nil {
+		return err
+	}
+
+	msgType, payload, err = readRelayMessage(t.conn)
+	if err != nil {
+		return err
+	}
+	if msgType != relayMsgStatus {
+		return fmt.Errorf("%w: relay status expected", ErrDecode)
+	}
+	return t.handleStatus(payload)
+}
+
+func openRelayConnection(address string, useTls bool) (Transport, error) {
+	config := relayClientConfig.Load()
+	if config == nil {
+		config = &RelayClientConfig{}
+	}
+
+	var conn net.Conn
+	var err error
+	if useTls {
+		tlsConfig := config.TLS
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: tcpDialTimeout}, "tcp", address, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", address, tcpDialTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t := &RelayTransport{conn: conn, data: make(chan []byte, 16), done: make(chan struct{}), closed: make(chan struct{})}
+	if err := t.handshake(config.Secret); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go t.receive()
+	return t, nil
+}
+
+func openRelayTransport(address string, _ int) (Transport, error) {
+	return openRelayConnection(address, false)
+}
+
+func openRelayTlsTransport(address string, _ int) (Transport, error) {
+	return openRelayConnection(address, true)
+}
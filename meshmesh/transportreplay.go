@@ -0,0 +1,202 @@
+package meshmesh
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"leguru.net/m/v2/logger"
+)
+
+// ReplayTransport plays back a file saved by StreamRecorder. The received data
+// is delivered with the recorded timing, but a chunk recorded after a write is
+// delivered only after the hub has done the same write. The written data is
+// compared with the recorded one.
+type ReplayTransport struct {
+	records      []streamRecord
+	writeIndexes []int
+	next         int
+	// Write records before next, the replay waits the hub to do the next one
+	passedWrites int
+	pending      []byte
+	readTimeout  time.Duration
+	// Wall clock and recording time of the last replayed event
+	lastEvent     time.Time
+	lastEventTime time.Duration
+	writes        int
+	mismatches    int
+	lock          sync.Mutex
+	written       chan struct{}
+	closed        chan struct{}
+	closeOnce     sync.Once
+}
+
+// writeIndex returns the index of the n-th write record or -1
+func (t *ReplayTransport) writeIndex(n int) int {
+	if n < 0 || n >= len(t.writeIndexes) {
+		return -1
+	}
+	return t.writeIndexes[n]
+}
+
+// nextRead returns the next read record when it is due, otherwise the time to wait
+func (t *ReplayTransport) nextRead() ([]byte, time.Duration, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for t.next < len(t.records) {
+		record := t.records[t.next]
+		if record.Kind == recordWrite {
+			if t.writes <= t.passedWrites {
+				// Wait the hub to do this write
+				return nil, -1, false
+			}
+			t.next++
+			t.passedWrites++
+			continue
+		}
+
+		due := t.lastEvent.Add(record.Time - t.lastEventTime)
+		wait := time.Until(due)
+		if wait > 0 {
+			return nil, wait, false
+		}
+		t.next++
+		t.lastEvent = time.Now()
+		t.lastEventTime = record.Time
+		return record.Data, 0, false
+	}
+	return nil, 0, true
+}
+
+func (t *ReplayTransport) Read(p []byte) (int, error) {
+	var timeout <-chan time.Time
+	if t.readTimeout > 0 {
+		timeout = time.After(t.readTimeout)
+	}
+
+	for len(t.pending) == 0 {
+		data, wait, finished := t.nextRead()
+		if finished {
+			t.lock.Lock()
+			logger.Log().WithFields(logrus.Fields{"writes": t.writes, "mismatches": t.mismatches}).Info("ReplayTransport: end of recording")
+			t.lock.Unlock()
+			return 0, io.EOF
+		}
+		if data != nil {
+			t.pending = data
+			break
+		}
+
+		var due <-chan time.Time
+		if wait >= 0 {
+			due = time.After(wait)
+		}
+		select {
+		case <-due:
+		case <-t.written:
+		case <-timeout:
+			return 0, nil
+		case <-t.closed:
+			return 0, io.EOF
+		}
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+func (t *ReplayTransport) Write(p []byte) (int, error) {
+	select {
+	case <-t.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	t.lock.Lock()
+	index := t.writeIndex(t.writes)
+	t.writes++
+	if index < 0 {
+		t.mismatches++
+		logger.Log().WithField("data", hex.EncodeToString(p)).Warn("ReplayTransport: write beyond the end of the recording")
+	} else {
+		record := t.records[index]
+		if !bytes.Equal(record.Data, p) {
+			t.mismatches++
+			logger.Log().WithFields(logrus.Fields{"want": hex.EncodeToString(record.Data), "got": hex.EncodeToString(p)}).
+				Warn("ReplayTransport: write differs from the recording")
+		}
+		if t.next <= index {
+			t.lastEvent = time.Now()
+			t.lastEventTime = record.Time
+		}
+	}
+	t.lock.Unlock()
+
+	select {
+	case t.written <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Mismatches returns the number of writes that differ from the recording
+func (t *ReplayTransport) Mismatches() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.mismatches
+}
+
+func (t *ReplayTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
+
+func (t *ReplayTransport) SetReadTimeout(timeout time.Duration) error {
+	t.readTimeout = timeout
+	return nil
+}
+
+// ResetInputBuffer does nothing, the recording starts after the reset of the real port
+func (t *ReplayTransport) ResetInputBuffer() error {
+	return nil
+}
+
+func (t *ReplayTransport) SetRTS(rts bool) error {
+	return nil
+}
+
+func (t *ReplayTransport) SetDTR(dtr bool) error {
+	return nil
+}
+
+// NewReplayTransport loads a recording saved with StreamRecorder
+func NewReplayTransport(filename string) (*ReplayTransport, error) {
+	records, err := loadRecording(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	writeIndexes := make([]int, 0)
+	for i, record := range records {
+		if record.Kind == recordWrite {
+			writeIndexes = append(writeIndexes, i)
+		}
+	}
+
+	return &ReplayTransport{
+		records:      records,
+		writeIndexes: writeIndexes,
+		lastEvent:    time.Now(),
+		written:      make(chan struct{}, 1),
+		closed:       make(chan struct{}),
+	}, nil
+}
+
+func openReplayTransport(address string, _ int) (Transport, error) {
+	return NewReplayTransport(address)
+}
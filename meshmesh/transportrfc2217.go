@@ -0,0 +1,279 @@
+package meshmesh
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+	"time"
+)
+
+// Telnet protocol bytes
+const (
+	telnetSE   byte = 240
+	telnetSB   byte = 250
+	telnetWILL byte = 251
+	telnetWONT byte = 252
+	telnetDO   byte = 253
+	telnetDONT byte = 254
+	telnetIAC  byte = 255
+)
+
+// Telnet options
+const (
+	telnetOptionBinary          byte = 0
+	telnetOptionSuppressGoAhead byte = 3
+	telnetOptionComPort         byte = 44
+)
+
+// RFC2217 client to server commands
+const (
+	comPortSetBaudRate byte = 1
+	comPortSetDataSize byte = 2
+	comPortSetParity   byte = 3
+	comPortSetStopSize byte = 4
+	comPortSetControl  byte = 5
+	comPortPurgeData   byte = 12
+)
+
+// RFC2217 values of the SET-CONTROL command
+const (
+	comPortControlNoFlowControl byte = 1
+	comPortControlDtrOn         byte = 8
+	comPortControlDtrOff        byte = 9
+	comPortControlRtsOn         byte = 11
+	comPortControlRtsOff        byte = 12
+)
+
+const comPortPurgeReceiveBuffer byte = 1
+
+const (
+	telnetStateData = iota
+	telnetStateIac
+	telnetStateOption
+	telnetStateSubneg
+	telnetStateSubnegIac
+)
+
+// Rfc2217Transport is a telnet connection with a serial port server that
+// implements the RFC2217 com port control option (ser2net, esp-link, ...)
+type Rfc2217Transport struct {
+	tcp         TcpTransport
+	writeLock   sync.Mutex
+	state       int
+	command     byte
+	willOptions []byte
+	doOptions   []byte
+	raw         []byte
+}
+
+func (t *Rfc2217Transport) hasOption(options []byte, option byte) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Rfc2217Transport) writeRaw(b []byte) error {
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	_, err := t.tcp.conn.Write(b)
+	return err
+}
+
+func (t *Rfc2217Transport) negotiate(command byte, option byte) error {
+	return t.writeRaw([]byte{telnetIAC, command, option})
+}
+
+func (t *Rfc2217Transport) comPortCommand(command byte, value []byte) error {
+	b := []byte{telnetIAC, telnetSB, telnetOptionComPort, command}
+	for _, v := range value {
+		b = append(b, v)
+		if v == telnetIAC {
+			b = append(b, telnetIAC)
+		}
+	}
+	b = append(b, telnetIAC, telnetSE)
+	return t.writeRaw(b)
+}
+
+// Answer to the negotiation requests of the server. Options already requested by us are not acknowledged again to avoid loops.
+func (t *Rfc2217Transport) handleNegotiation(command byte, option byte) error {
+	switch command {
+	case telnetDO:
+		if !t.hasOption(t.willOptions, option) {
+			return t.negotiate(telnetWONT, option)
+		}
+	case telnetWILL:
+		if !t.hasOption(t.doOptions, option) {
+			return t.negotiate(telnetDONT, option)
+		}
+	}
+	return nil
+}
+
+// Remove the telnet commands from the received data, the error is the one of the answers to the negotiation
+func (t *Rfc2217Transport) filter(in []byte, out []byte) (int, error) {
+	var err error
+	n := 0
+	for _, b := range in {
+		switch t.state {
+		case telnetStateData:
+			if b == telnetIAC {
+				t.state = telnetStateIac
+			} else {
+				out[n] = b
+				n++
+			}
+		case telnetStateIac:
+			switch b {
+			case telnetIAC:
+				out[n] = b
+				n++
+				t.state = telnetStateData
+			case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+				t.command = b
+				t.state = telnetStateOption
+			case telnetSB:
+				t.state = telnetStateSubneg
+			default:
+				t.state = telnetStateData
+			}
+		case telnetStateOption:
+			if nerr := t.handleNegotiation(t.command, b); nerr != nil && err == nil {
+				err = nerr
+			}
+			t.state = telnetStateData
+		case telnetStateSubneg:
+			// Notifications from the server (modem state, line state, ...) are ignored
+			if b == telnetIAC {
+				t.state = telnetStateSubnegIac
+			}
+		case telnetStateSubnegIac:
+			if b == telnetSE {
+				t.state = telnetStateData
+			} else {
+				t.state = telnetStateSubneg
+			}
+		}
+	}
+	return n, err
+}
+
+func (t *Rfc2217Transport) Read(p []byte) (int, error) {
+	if len(t.raw) < len(p) {
+		t.raw = make([]byte, len(p))
+	}
+
+	n, err := t.tcp.Read(t.raw[:len(p)])
+	if n > 0 {
+		var ferr error
+		n, ferr = t.filter(t.raw[:n], p)
+		if err == nil {
+			err = ferr
+		}
+	}
+	return n, err
+}
+
+func (t *Rfc2217Transport) Write(p []byte) (int, error) {
+	escaped := make([]byte, 0, len(p)+8)
+	for _, b := range p {
+		escaped = append(escaped, b)
+		if b == telnetIAC {
+			escaped = append(escaped, telnetIAC)
+		}
+	}
+
+	err := t.writeRaw(escaped)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *Rfc2217Transport) Close() error {
+	return t.tcp.Close()
+}
+
+func (t *Rfc2217Transport) SetReadTimeout(timeout time.Duration) error {
+	return t.tcp.SetReadTimeout(timeout)
+}
+
+func (t *Rfc2217Transport) ResetInputBuffer() error {
+	if err := t.comPortCommand(comPortPurgeData, []byte{comPortPurgeReceiveBuffer}); err != nil {
+		return err
+	}
+	// The data is dropped after the filter, that answers the pending negotiations and keeps its state
+	dropped := make([]byte, 256)
+	return t.tcp.drain(func(data []byte) error {
+		_, err := t.filter(data, dropped)
+		return err
+	})
+}
+
+func (t *Rfc2217Transport) SetRTS(rts bool) error {
+	if rts {
+		return t.comPortCommand(comPortSetControl, []byte{comPortControlRtsOn})
+	}
+	return t.comPortCommand(comPortSetControl, []byte{comPortControlRtsOff})
+}
+
+func (t *Rfc2217Transport) SetDTR(dtr bool) error {
+	if dtr {
+		return t.comPortCommand(comPortSetControl, []byte{comPortControlDtrOn})
+	}
+	return t.comPortCommand(comPortSetControl, []byte{comPortControlDtrOff})
+}
+
+func (t *Rfc2217Transport) setup(baudRate int) error {
+	for _, option := range t.willOptions {
+		if err := t.negotiate(telnetWILL, option); err != nil {
+			return err
+		}
+	}
+	for _, option := range t.doOptions {
+		if err := t.negotiate(telnetDO, option); err != nil {
+			return err
+		}
+	}
+
+	baud := make([]byte, 4)
+	binary.BigEndian.PutUint32(baud, uint32(baudRate))
+	if err := t.comPortCommand(comPortSetBaudRate, baud); err != nil {
+		return err
+	}
+	// 8N1 without flow control
+	if err := t.comPortCommand(comPortSetDataSize, []byte{8}); err != nil {
+		return err
+	}
+	if err := t.comPortCommand(comPortSetParity, []byte{1}); err != nil {
+		return err
+	}
+	if err := t.comPortCommand(comPortSetStopSize, []byte{1}); err != nil {
+		return err
+	}
+	return t.comPortCommand(comPortSetControl, []byte{comPortControlNoFlowControl})
+}
+
+func openRfc2217Transport(address string, baudRate int) (Transport, error) {
+	conn, err := net.DialTimeout("tcp", address, tcpDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Rfc2217Transport{
+		tcp:         TcpTransport{conn: conn},
+		state:       telnetStateData,
+		willOptions: []byte{telnetOptionBinary, telnetOptionSuppressGoAhead, telnetOptionComPort},
+		doOptions:   []byte{telnetOptionBinary, telnetOptionSuppressGoAhead},
+	}
+
+	err = t.setup(baudRate)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return t, nil
+}
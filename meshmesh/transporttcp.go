@@ -0,0 +1,87 @@
+package meshmesh
+
+import (
+	"errors"
+	"net"
+	"os"
+	"time"
+)
+
+const tcpDialTimeout = 5 * time.Second
+
+// TcpTransport is a raw TCP socket connected to a serial port server like ser2net
+type TcpTransport struct {
+	conn        net.Conn
+	readTimeout time.Duration
+}
+
+func (t *TcpTransport) Read(p []byte) (int, error) {
+	if t.readTimeout > 0 {
+		t.conn.SetReadDeadline(time.Now().Add(t.readTimeout))
+	} else {
+		t.conn.SetReadDeadline(time.Time{})
+	}
+
+	n, err := t.conn.Read(p)
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		// Behave like a serial port: a timeout is not an error
+		return n, nil
+	}
+	return n, err
+}
+
+func (t *TcpTransport) Write(p []byte) (int, error) {
+	return t.conn.Write(p)
+}
+
+func (t *TcpTransport) Close() error {
+	return t.conn.Close()
+}
+
+func (t *TcpTransport) SetReadTimeout(timeout time.Duration) error {
+	t.readTimeout = timeout
+	return nil
+}
+
+func (t *TcpTransport) ResetInputBuffer() error {
+	return t.drain(nil)
+}
+
+// drain reads the bytes pending in the socket and passes them to discard, that can be nil
+func (t *TcpTransport) drain(discard func(data []byte) error) error {
+	buffer := make([]byte, 256)
+	for {
+		t.conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		n, err := t.conn.Read(buffer)
+		if n > 0 && discard != nil {
+			if derr := discard(buffer[:n]); derr != nil {
+				return derr
+			}
+		}
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return nil
+			}
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+func (t *TcpTransport) SetRTS(rts bool) error {
+	return errControlLinesNotSupported
+}
+
+func (t *TcpTransport) SetDTR(dtr bool) error {
+	return errControlLinesNotSupported
+}
+
+func openTcpTransport(address string, _ int) (Transport, error) {
+	conn, err := net.DialTimeout("tcp", address, tcpDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &TcpTransport{conn: conn}, nil
+}
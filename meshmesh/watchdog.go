@@ -0,0 +1,249 @@
+package meshmesh
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"leguru.net/m/v2/logger"
+)
+
+const watchdogEchoTimeout = 2 * time.Second
+
+// Consecutive failed probes before the next recovery step
+const watchdogMaxFailures = 3
+
+const watchdogEcho = "WDOG"
+
+type HealthState int
+
+const (
+	HealthStarting HealthState = iota
+	HealthOk
+	HealthRecovering
+	HealthUnhealthy
+)
+
+func (s HealthState) String() string {
+	switch s {
+	case HealthStarting:
+		return "starting"
+	case HealthOk:
+		return "healthy"
+	case HealthRecovering:
+		return "recovering"
+	case HealthUnhealthy:
+		return "unhealthy"
+	}
+	return "unknown"
+}
+
+// Recovery steps executed when the coordinator stops answering
+const (
+	watchdogStepNone = iota
+	watchdogStepReopen
+	watchdogStepReset
+	watchdogStepUnhealthy
+)
+
+type WatchdogStatus struct {
+	State      HealthState
+	Connected  bool
+	Failures   int
+	Recoveries int
+	LastEcho   time.Time
+	LastError  string
+}
+
+// CoordinatorWatchdog periodically checks the coordinator with an echo request.
+// When the coordinator stops answering the port is reopened, then the coordinator
+// is reset and at last the hub is marked as unhealthy.
+type CoordinatorWatchdog struct {
+	serialConn *SerialConnection
+	interval   time.Duration
+	lock       sync.Mutex
+	state      HealthState
+	step       int
+	failures   int
+	recoveries int
+	lastEcho   time.Time
+	lastError  error
+	quit       chan struct{}
+	stopOnce   sync.Once
+	// Held while the port is reopened, paused counts the callers of Pause that didn't resume it
+	recoveryLock sync.Mutex
+	paused       int
+}
+
+func (w *CoordinatorWatchdog) probe() error {
+	if !w.serialConn.IsConnected() {
+		return ErrSerialClosed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), watchdogEchoTimeout)
+	defer cancel()
+	reply, err := w.serialConn.SendReceiveApiContext(ctx, EchoApiRequest{Echo: watchdogEcho})
+	if err != nil {
+		return err
+	}
+	echo, ok := reply.(EchoApiReply)
+	if !ok || echo.Echo != watchdogEcho {
+		return fmt.Errorf("%w: invalid echo reply", ErrDecode)
+	}
+	return nil
+}
+
+// check runs a probe and returns the recovery step to execute
+func (w *CoordinatorWatchdog) check() int {
+	err := w.probe()
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if err == nil {
+		if w.state != HealthOk && w.state != HealthStarting {
+			logger.Log().WithField("recoveries", w.recoveries).Info("CoordinatorWatchdog: coordinator is answering again")
+		}
+		w.state = HealthOk
+		w.step = watchdogStepNone
+		w.failures = 0
+		w.lastEcho = time.Now()
+		w.lastError = nil
+		return watchdogStepNone
+	}
+
+	w.failures++
+	w.lastError = err
+	logger.Log().WithFields(logrus.Fields{"failures": w.failures, "err": err}).Warn("CoordinatorWatchdog: coordinator check failed")
+	if w.failures < watchdogMaxFailures {
+		if !w.serialConn.IsConnected() {
+			// A closed port doesn't need to wait the other probes
+			if w.state != HealthUnhealthy {
+				w.state = HealthRecovering
+			}
+			return watchdogStepReopen
+		}
+		return watchdogStepNone
+	}
+
+	w.failures = 0
+	if w.step < watchdogStepUnhealthy {
+		w.step++
+	}
+	if w.step == watchdogStepUnhealthy {
+		w.state = HealthUnhealthy
+		// Keep trying to reset the coordinator
+		return watchdogStepReset
+	}
+	w.state = HealthRecovering
+	return w.step
+}
+
+func (w *CoordinatorWatchdog) recover(step int) {
+	w.recoveryLock.Lock()
+	defer w.recoveryLock.Unlock()
+
+	w.lock.Lock()
+	if w.paused > 0 {
+		w.lock.Unlock()
+		logger.Log().Debug("CoordinatorWatchdog: paused, the port is not reopened")
+		return
+	}
+	w.recoveries++
+	w.lock.Unlock()
+
+	pulseReset := step == watchdogStepReset
+	logger.Log().WithField("reset", pulseReset).Warn("CoordinatorWatchdog: reopening the coordinator port")
+	if err := w.serialConn.Reopen(pulseReset); err != nil {
+		logger.Log().WithError(err).Warn("CoordinatorWatchdog: reopen failed")
+	}
+}
+
+func (w *CoordinatorWatchdog) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if step := w.check(); step != watchdogStepNone {
+			w.recover(step)
+		}
+
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns a snapshot of the watchdog state
+func (w *CoordinatorWatchdog) Status() WatchdogStatus {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	status := WatchdogStatus{
+		State:      w.state,
+		Connected:  w.serialConn.IsConnected(),
+		Failures:   w.failures,
+		Recoveries: w.recoveries,
+		LastEcho:   w.lastEcho,
+	}
+	if w.lastError != nil {
+		status.LastError = w.lastError.Error()
+	}
+	return status
+}
+
+// Healthy is false only after all the recovery steps failed
+func (w *CoordinatorWatchdog) Healthy() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.state != HealthUnhealthy
+}
+
+// Ready is true when the last check of the coordinator succeeded
+func (w *CoordinatorWatchdog) Ready() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.state == HealthOk && w.serialConn.IsConnected()
+}
+
+// Pause stops the recovery of the port until Resume is called, it waits for a running recovery to
+// finish. The checks go on, so that the state of the port is still known.
+func (w *CoordinatorWatchdog) Pause() {
+	w.lock.Lock()
+	w.paused++
+	w.lock.Unlock()
+
+	// The recoveries that start from now on see the pause, wait for the running one
+	w.recoveryLock.Lock()
+	w.recoveryLock.Unlock()
+}
+
+// Resume lets the watchdog recover the port again
+func (w *CoordinatorWatchdog) Resume() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.paused > 0 {
+		w.paused--
+	}
+}
+
+func (w *CoordinatorWatchdog) Start() {
+	go w.run()
+}
+
+func (w *CoordinatorWatchdog) Stop() {
+	w.stopOnce.Do(func() { close(w.quit) })
+}
+
+func NewCoordinatorWatchdog(serialConn *SerialConnection, interval time.Duration) *CoordinatorWatchdog {
+	return &CoordinatorWatchdog{
+		serialConn: serialConn,
+		interval:   interval,
+		state:      HealthStarting,
+		quit:       make(chan struct{}),
+	}
+}
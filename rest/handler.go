@@ -5,14 +5,45 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"leguru.net/m/v2/graph"
 	mm "leguru.net/m/v2/meshmesh"
 )
 
 type Handler struct {
-	serialConn         *mm.SerialConnection
+	coordinators       *mm.Coordinators
 	discoveryProcedure *mm.DiscoveryProcedure
-	esphomeServers     *mm.MultiSocketServer
-	starPath           *mm.StarPath
+}
+
+// coordinator returns the coordinator with the given name, the default one if the name is empty.
+// It replies with an error when the coordinator doesn't exist.
+func (h *Handler) coordinator(c *gin.Context, name string) (*mm.Coordinator, bool) {
+	coordinator, err := h.coordinators.ByName(name)
+	if err != nil {
+		c.JSON(meshErrorStatus(err), gin.H{"message": err.Error()})
+		return nil, false
+	}
+	return coordinator, true
+}
+
+// findNode returns a node of the main networks, or of the star path networks, with the coordinator that owns it
+func (h *Handler) findNode(id int64, star bool) (*mm.Coordinator, *graph.Network, graph.NodeDevice, error) {
+	var err error = graph.ErrNodeNotFound
+	for _, coordinator := range h.coordinators.All() {
+		network := coordinator.Network()
+		if star {
+			network = coordinator.StarNetwork()
+		}
+		if network == nil {
+			continue
+		}
+
+		var dev graph.NodeDevice
+		dev, err = network.GetNodeDevice(id)
+		if err == nil {
+			return coordinator, network, dev, nil
+		}
+	}
+	return nil, nil, graph.NodeDevice{}, err
 }
 
 func smartInteger(v any) int64 {
@@ -37,11 +68,9 @@ func routeFrontend(c *gin.Context) {
 	c.Writer.Header().Set("Location", "/manager")
 }
 
-func NewHandler(serialConn *mm.SerialConnection, esphomeServers *mm.MultiSocketServer, starPath *mm.StarPath) *Handler {
+func NewHandler(coordinators *mm.Coordinators) *Handler {
 	return &Handler{
-		serialConn:         serialConn,
+		coordinators:       coordinators,
 		discoveryProcedure: nil,
-		esphomeServers:     esphomeServers,
-		starPath:           starPath,
 	}
 }
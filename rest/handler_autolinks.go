@@ -32,19 +32,25 @@ func (h *Handler) getAutoLinks(c *gin.Context) {
 	filter_from, _ := utils.ParseNodeId(p.Filter["from"])
 	filter_any := smartInteger(p.Filter["any"])
 
-	network := h.starPath.GetNetwork()
-	links := network.WeightedEdges()
-	jsonLinks := make([]MeshLink, 0, links.Len())
-	for links.Next() {
-		edge := links.WeightedEdge()
-		fromID := edge.From().ID()
-		toID := edge.To().ID()
-
-		if ((filter_to != -1 && filter_to != toID) && (filter_from != -1 && filter_from != fromID)) || (filter_any != -1 && (filter_any != fromID && filter_any != toID)) {
+	jsonLinks := make([]MeshLink, 0)
+	for _, coordinator := range h.coordinators.All() {
+		network := coordinator.StarNetwork()
+		if network == nil {
 			continue
 		}
 
-		jsonLinks = append(jsonLinks, fillLinkStruct(edge))
+		links := network.WeightedEdges()
+		for links.Next() {
+			edge := links.WeightedEdge()
+			fromID := edge.From().ID()
+			toID := edge.To().ID()
+
+			if ((filter_to != -1 && filter_to != toID) && (filter_from != -1 && filter_from != fromID)) || (filter_any != -1 && (filter_any != fromID && filter_any != toID)) {
+				continue
+			}
+
+			jsonLinks = append(jsonLinks, fillLinkStruct(edge))
+		}
 	}
 
 	// Sort array base on request fields
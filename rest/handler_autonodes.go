@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"leguru.net/m/v2/graph"
 	"leguru.net/m/v2/logger"
 	"leguru.net/m/v2/meshmesh"
 )
@@ -29,7 +30,12 @@ func (h *Handler) getAutoNodes(c *gin.Context) {
 	}
 
 	params := req.toGetListParams()
-	jsonNodes := h.fillNodesArrays(h.starPath.GetNetwork())
+	jsonNodes := make([]MeshNode, 0)
+	for _, coordinator := range h.coordinators.All() {
+		if star := coordinator.StarNetwork(); star != nil {
+			jsonNodes = append(jsonNodes, h.fillNodesArrays(coordinator, star)...)
+		}
+	}
 	sort.Slice(jsonNodes, func(i, j int) bool {
 		return jsonNodes[i].Sort(jsonNodes[j], params.SortType, params.SortBy)
 	})
@@ -64,14 +70,13 @@ func (h *Handler) getOneAutoNode(c *gin.Context) {
 		return
 	}
 
-	network := h.starPath.GetNetwork()
-	dev, err := network.GetNodeDevice(int64(id))
+	coordinator, network, dev, err := h.findNode(int64(id), true)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
 		return
 	}
 
-	jsonNode := h.fillNodeStruct(dev, true, network)
+	jsonNode := h.fillNodeStruct(c.Request.Context(), coordinator, dev, true, network)
 	c.JSON(http.StatusOK, jsonNode)
 }
 
@@ -92,17 +97,15 @@ func (h *Handler) deleteAutoNode(c *gin.Context) {
 		return
 	}
 
-	network := h.starPath.GetNetwork()
-	dev, err := network.GetNodeDevice(int64(id))
+	coordinator, network, dev, err := h.findNode(int64(id), true)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
 		return
 	}
 
-	jsonNode := h.fillNodeStruct(dev, false, network)
+	jsonNode := h.fillNodeStruct(c.Request.Context(), coordinator, dev, false, network)
 
 	network.RemoveNode(int64(id))
-	network.NotifyNetworkChanged(false)
 
 	c.JSON(http.StatusOK, jsonNode)
 }
@@ -132,23 +135,23 @@ func (h *Handler) updateAutoNode(c *gin.Context) {
 		return
 	}
 
-	network := h.starPath.GetNetwork()
-	dev, err := network.GetNodeDevice(int64(id))
+	coordinator, network, dev, err := h.findNode(int64(id), true)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
 		return
 	}
 
-	dev.Device().SetTag(req.Tag)
-	dev.Device().SetInUse(req.InUse)
-	network.NotifyNetworkChanged(false)
+	network.UpdateNode(dev.ID(), func(device *graph.Device) {
+		device.SetTag(req.Tag)
+		device.SetInUse(req.InUse)
+	})
 
-	jsonNode := h.fillNodeStruct(dev, true, network)
+	jsonNode := h.fillNodeStruct(c.Request.Context(), coordinator, dev, true, network)
 	errors := []error{}
 
 	if req.Channel != (int8)(jsonNode.Channel) {
 		protocol := meshmesh.FindBestProtocol(meshmesh.MeshNodeId(dev.ID()), network)
-		_, err := h.serialConn.SendReceiveApiProt(meshmesh.NodeSetChannelApiRequest{Channel: uint8(req.Channel)}, protocol, meshmesh.MeshNodeId(dev.ID()), network)
+		_, err := coordinator.Serial().SendReceiveApiProtContext(c.Request.Context(), meshmesh.NodeSetChannelApiRequest{Channel: uint8(req.Channel)}, protocol, meshmesh.MeshNodeId(dev.ID()), network)
 		if err != nil {
 			errors = append(errors, err)
 		} else {
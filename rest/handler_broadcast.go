@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"leguru.net/m/v2/meshmesh"
+)
+
+func toNodeIds(nodes []meshmesh.MeshNodeId) []uint {
+	ids := make([]uint, len(nodes))
+	for i, node := range nodes {
+		ids[i] = uint(node)
+	}
+	return ids
+}
+
+// @Id politeBroadcast
+// @Summary Send a command to all the nodes of the network with a polite broadcast
+// @Tags    Broadcast
+// @Accept  json
+// @Produce json
+// @Param   request body PoliteBroadcastRequest true "Command (echo, firmware, tag, reboot), reply window and coordinator"
+// @Success 200 {object} PoliteBroadcastResult
+// @Failure 400 {object} string
+// @Failure 403 {object} string
+// @Router /api/v1/broadcast [post]
+func (h *Handler) politeBroadcast(c *gin.Context) {
+	var req PoliteBroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	cmd, err := meshmesh.PoliteBroadcastCommand(req.Command)
+	if err != nil {
+		c.JSON(meshErrorStatus(err), gin.H{"message": err.Error(), "commands": meshmesh.PoliteBroadcastCommands()})
+		return
+	}
+
+	window := meshmesh.DefaultPoliteBroadcastWindow
+	if req.WindowMs > 0 {
+		window = time.Duration(req.WindowMs) * time.Millisecond
+	}
+
+	coordinator, ok := h.coordinator(c, req.Coordinator)
+	if !ok {
+		return
+	}
+
+	network := coordinator.Network()
+	result, err := coordinator.Serial().SendReceiveApiPoliteBroadcastContext(c.Request.Context(), cmd, window, network, coordinator.StarNetwork())
+	if err != nil {
+		c.JSON(meshErrorStatus(err), gin.H{"message": "Failed to send polite broadcast: " + err.Error()})
+		return
+	}
+
+	jsonResult := PoliteBroadcastResult{
+		Replies:     make([]PoliteBroadcastNodeReply, 0, len(result.Replies)),
+		MissingMain: toNodeIds(result.MissingMain),
+		MissingStar: toNodeIds(result.MissingStar),
+	}
+	for node, value := range result.Replies {
+		reply := PoliteBroadcastNodeReply{ID: uint(node), Value: meshmesh.PoliteBroadcastReplyText(value)}
+		if network != nil {
+			if dev, err := network.GetNodeDevice(int64(node)); err == nil {
+				reply.Tag = dev.Device().Tag()
+			}
+		}
+		jsonResult.Replies = append(jsonResult.Replies, reply)
+	}
+	sort.Slice(jsonResult.Replies, func(i, j int) bool {
+		return jsonResult.Replies[i].ID < jsonResult.Replies[j].ID
+	})
+
+	c.JSON(http.StatusOK, jsonResult)
+}
@@ -5,7 +5,6 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"leguru.net/m/v2/graph"
 	"leguru.net/m/v2/meshmesh"
 )
 
@@ -17,13 +16,9 @@ func (h *Handler) rebootNode(c *gin.Context) {
 		return
 	}
 
-	network := h.starPath.GetNetwork()
-	dev, err := network.GetNodeDevice(int64(id))
+	coordinator, network, dev, err := h.findNode(int64(id), true)
 	if err != nil {
-
-		network := graph.GetMainNetwork()
-
-		dev, err = network.GetNodeDevice(int64(id))
+		coordinator, network, dev, err = h.findNode(int64(id), false)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
 			return
@@ -31,9 +26,9 @@ func (h *Handler) rebootNode(c *gin.Context) {
 	}
 
 	protocol := meshmesh.FindBestProtocol(meshmesh.MeshNodeId(dev.ID()), network)
-	_, err = h.serialConn.SendReceiveApiProt(meshmesh.NodeRebootApiRequest{Id: uint8(dev.ID())}, protocol, meshmesh.MeshNodeId(dev.ID()), network)
+	_, err = coordinator.Serial().SendReceiveApiProtContext(c.Request.Context(), meshmesh.NodeRebootApiRequest{Id: uint8(dev.ID())}, protocol, meshmesh.MeshNodeId(dev.ID()), network)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to reboot node: " + err.Error()})
+		c.JSON(meshErrorStatus(err), gin.H{"message": "Failed to reboot node: " + err.Error()})
 		return
 	}
 
@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	mm "leguru.net/m/v2/meshmesh"
+)
+
+func coordinatorInfo(coordinator *mm.Coordinator) CoordinatorInfo {
+	active, standby := coordinator.Ports()
+	info := CoordinatorInfo{
+		Name:      coordinator.Name,
+		LocalNode: uint(active.Serial.LocalNode.Load()),
+		Connected: active.Serial.IsConnected(),
+		Nodes:     coordinator.Network().Nodes().Len(),
+	}
+	if star := coordinator.StarNetwork(); star != nil {
+		info.StarNodes = star.Nodes().Len()
+	}
+	if coordinator.Servers != nil {
+		info.Servers = len(coordinator.Servers.Servers)
+	}
+	if standby != nil {
+		info.StandbyNode = uint(standby.Serial.LastLocalNode())
+		info.StandbyConnected = standby.Serial.IsConnected()
+		failedOver, switchedAt := coordinator.FailedOver()
+		info.FailedOver = failedOver
+		info.SwitchedAt = formatTimeForJson(switchedAt)
+	}
+	return info
+}
+
+// @Id getCoordinators
+// @Summary Get the coordinators managed by the hub, the first one is the default
+// @Tags    Coordinators
+// @Produce json
+// @Success 200 {array} CoordinatorInfo
+// @Router /api/v1/coordinators [get]
+func (h *Handler) getCoordinators(c *gin.Context) {
+	jsonCoordinators := make([]CoordinatorInfo, 0)
+	for _, coordinator := range h.coordinators.All() {
+		jsonCoordinators = append(jsonCoordinators, coordinatorInfo(coordinator))
+	}
+	c.Header("Content-Range", fmt.Sprintf("%d-%d/%d", 0, len(jsonCoordinators), len(jsonCoordinators)))
+	c.JSON(http.StatusOK, jsonCoordinators)
+}
+
+// @Id failoverCoordinator
+// @Summary Swap the active port of a coordinator with its standby port
+// @Tags    Coordinators
+// @Produce json
+// @Param   name path string true "Coordinator name"
+// @Success 200 {object} CoordinatorInfo
+// @Failure 400 {object} string
+// @Failure 503 {object} string
+// @Router /api/v1/coordinators/{name}/failover [post]
+func (h *Handler) failoverCoordinator(c *gin.Context) {
+	coordinator, ok := h.coordinator(c, c.Param("name"))
+	if !ok {
+		return
+	}
+
+	if err := coordinator.Failover(); err != nil {
+		c.JSON(meshErrorStatus(err), gin.H{"message": "Failed to switch the coordinator port: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, coordinatorInfo(coordinator))
+}
+
+// @Id getCoordinatorConsole
+// @Summary Get the text lines printed by the coordinator outside the api frames, like the boot messages and the crash dumps
+// @Tags    Coordinators
+// @Produce json
+// @Param   name  path  string true  "Coordinator name"
+// @Param   level query string false "Most verbose level returned (name or number)"
+// @Param   since query string false "RFC3339 start time"
+// @Param   until query string false "RFC3339 end time"
+// @Param   limit query int    false "Return only the last lines"
+// @Success 200 {array} NodeLogLine
+// @Failure 400 {object} string
+// @Router /api/v1/coordinators/{name}/console [get]
+func (h *Handler) getCoordinatorConsole(c *gin.Context) {
+	coordinator, ok := h.coordinator(c, c.Param("name"))
+	if !ok {
+		return
+	}
+
+	var req NodeLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+	filter, err := req.toFilter()
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	entries := coordinator.ConsoleLines(filter)
+	if req.Limit > 0 && len(entries) > req.Limit {
+		entries = entries[len(entries)-req.Limit:]
+	}
+
+	jsonLines := make([]NodeLogLine, 0, len(entries))
+	for i := range entries {
+		jsonLines = append(jsonLines, toNodeLogLine(uint(i), &entries[i]))
+	}
+
+	c.Header("Content-Range", fmt.Sprintf("%d-%d/%d", 0, len(jsonLines), len(jsonLines)))
+	c.JSON(http.StatusOK, jsonLines)
+}
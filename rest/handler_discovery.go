@@ -30,10 +30,11 @@ func (h *Handler) getDiscoveryProcedureState(c *gin.Context) {
 		c.JSON(http.StatusOK, discoveryState)
 	} else {
 		discoveryState := MeshDiscoveryState{
-			ID:        0,
-			Status:    h.discoveryProcedure.StateString(),
-			CurrentId: utils.FmtNodeId(h.discoveryProcedure.CurrentDeviceId()),
-			Repeat:    h.discoveryProcedure.CurrentRepeat(),
+			ID:          0,
+			Status:      h.discoveryProcedure.StateString(),
+			CurrentId:   utils.FmtNodeId(h.discoveryProcedure.CurrentDeviceId()),
+			Repeat:      h.discoveryProcedure.CurrentRepeat(),
+			Coordinator: h.discoveryProcedure.Coordinator().Name,
 		}
 		c.JSON(http.StatusOK, discoveryState)
 	}
@@ -47,16 +48,21 @@ func (h *Handler) ctrlDiscoveryProcedure(c *gin.Context) {
 		return
 	}
 
+	coordinator, ok := h.coordinator(c, req.Coordinator)
+	if !ok {
+		return
+	}
+
 	var network *graph.Network = nil
 	if req.Mode == "refresh" {
-		network = graph.GetMainNetwork().CopyNetwork()
+		network = coordinator.Network().CopyNetwork()
 	}
 
 	if h.discoveryProcedure == nil {
-		h.discoveryProcedure = mm.NewDiscoveryProcedure(h.serialConn, network, graph.GetMainNetwork().LocalDeviceId())
+		h.discoveryProcedure = mm.NewDiscoveryProcedure(coordinator, network)
 	} else {
 		if h.discoveryProcedure.State() == mm.DiscoveryProcedureStateDone || h.discoveryProcedure.State() == mm.DiscoveryProcedureStateError {
-			h.discoveryProcedure = mm.NewDiscoveryProcedure(h.serialConn, network, graph.GetMainNetwork().LocalDeviceId())
+			h.discoveryProcedure = mm.NewDiscoveryProcedure(coordinator, network)
 		}
 	}
 
@@ -5,7 +5,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"leguru.net/m/v2/graph"
 	"leguru.net/m/v2/logger"
 	"leguru.net/m/v2/utils"
 )
@@ -20,12 +19,15 @@ import (
 // @Router /api/esphome/servers [get]
 func (h *Handler) getEsphomeServers(c *gin.Context) {
 	jsonServers := make([]EsphomeServer, 0)
-	for _, server := range h.esphomeServers.Servers {
-		jsonServers = append(jsonServers, EsphomeServer{
-			ID:      uint(server.Address),
-			Address: server.GetListenAddress(),
-			Clients: len(server.Clients),
-		})
+	for _, coordinator := range h.coordinators.All() {
+		for _, server := range coordinator.Servers.Servers {
+			jsonServers = append(jsonServers, EsphomeServer{
+				ID:          uint(server.Address),
+				Address:     server.GetListenAddress(),
+				Clients:     len(server.Clients),
+				Coordinator: coordinator.Name,
+			})
+		}
 	}
 	c.Header("Content-Range", fmt.Sprintf("%d-%d/%d", 0, len(jsonServers), len(jsonServers)))
 	c.JSON(http.StatusOK, jsonServers)
@@ -42,30 +44,34 @@ func (h *Handler) getEsphomeServers(c *gin.Context) {
 func (h *Handler) getEsphomeConnections(c *gin.Context) {
 	index := uint(1)
 
-	stats := h.esphomeServers.Stats()
-
 	jsonClients := make([]EsphomeClient, 0)
-	for nodeId, connection := range stats.Connections {
+	for _, coordinator := range h.coordinators.All() {
+		stats := coordinator.Servers.Stats()
+		for nodeId, connection := range stats.Connections {
+			dev, err := coordinator.Network().GetNodeDevice(int64(nodeId))
+			if err != nil {
+				if star := coordinator.StarNetwork(); star != nil {
+					dev, err = star.GetNodeDevice(int64(nodeId))
+				}
+			}
+			if err != nil {
+				logger.WithField("id", nodeId).Error("Node not found for esphome connection")
+				continue
+			}
 
-		network := graph.GetMainNetwork()
-		dev, err := network.GetNodeDevice(int64(nodeId))
-		if err != nil {
-			logger.WithField("id", nodeId).Error("Node not found for esphome connection")
-			continue
+			jsonClients = append(jsonClients, EsphomeClient{
+				ID:       index,
+				Node:     utils.FmtNodeId(int64(nodeId)),
+				Address:  utils.FmtNodeIdHass(int64(nodeId)),
+				Tag:      dev.Device().Tag(),
+				Active:   connection.IsActive(),
+				Handle:   int(connection.GetLastHandle()),
+				Sent:     connection.BytesOut(),
+				Received: connection.BytesIn(),
+				Duration: connection.TimeSinceLastConnection().String(),
+				Started:  connection.LastConnectionDuration().String(),
+			})
 		}
-
-		jsonClients = append(jsonClients, EsphomeClient{
-			ID:       index,
-			Node:     utils.FmtNodeId(int64(nodeId)),
-			Address:  utils.FmtNodeIdHass(int64(nodeId)),
-			Tag:      dev.Device().Tag(),
-			Active:   connection.IsActive(),
-			Handle:   int(connection.GetLastHandle()),
-			Sent:     connection.BytesOut(),
-			Received: connection.BytesIn(),
-			Duration: connection.TimeSinceLastConnection().String(),
-			Started:  connection.LastConnectionDuration().String(),
-		})
 	}
 
 	c.Header("Content-Range", fmt.Sprintf("%d-%d/%d", 0, len(jsonClients), len(jsonClients)))
@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	mm "leguru.net/m/v2/meshmesh"
+)
+
+func portHealthStatus(port mm.CoordinatorPort) HealthStatus {
+	if port.Watchdog == nil {
+		// Without the watchdog only the state of the port is known
+		status := HealthStatus{Status: "healthy", Connected: port.Serial.IsConnected()}
+		if !status.Connected {
+			status.Status = "disconnected"
+		}
+		return status
+	}
+
+	s := port.Watchdog.Status()
+	return HealthStatus{
+		Status:     s.State.String(),
+		Connected:  s.Connected,
+		Failures:   s.Failures,
+		Recoveries: s.Recoveries,
+		LastEcho:   formatTimeForJson(s.LastEcho),
+		Error:      s.LastError,
+	}
+}
+
+// coordinatorHealthStatus reports the state of the active port of a coordinator, and of the standby one if any
+func coordinatorHealthStatus(coordinator *mm.Coordinator) HealthStatus {
+	active, standby := coordinator.Ports()
+	status := portHealthStatus(active)
+	if standby != nil {
+		s := portHealthStatus(*standby)
+		status.Standby = &s
+	}
+	return status
+}
+
+// healthStatus reports the state of the coordinators, with more than one coordinator
+// the hub takes the state of the first one that is not healthy
+func (h *Handler) healthStatus() HealthStatus {
+	coordinators := h.coordinators.All()
+	if len(coordinators) == 1 {
+		return coordinatorHealthStatus(coordinators[0])
+	}
+
+	status := HealthStatus{Status: "healthy", Connected: true, Coordinators: make([]HealthStatus, 0, len(coordinators))}
+	for _, coordinator := range coordinators {
+		s := coordinatorHealthStatus(coordinator)
+		s.Coordinator = coordinator.Name
+		status.Coordinators = append(status.Coordinators, s)
+
+		status.Connected = status.Connected && s.Connected
+		status.Failures += s.Failures
+		status.Recoveries += s.Recoveries
+		if status.Status == "healthy" && s.Status != "healthy" {
+			status.Status = s.Status
+			status.Error = s.Error
+		}
+	}
+	return status
+}
+
+// @Id getHealthz
+// @Summary Liveness probe, fails when a coordinator can't be recovered
+// @Tags    Health
+// @Produce json
+// @Success 200 {object} HealthStatus
+// @Failure 503 {object} HealthStatus
+// @Router /healthz [get]
+func (h *Handler) getHealthz(c *gin.Context) {
+	healthy := true
+	for _, coordinator := range h.coordinators.All() {
+		if watchdog := coordinator.Watchdog(); watchdog != nil && !watchdog.Healthy() {
+			healthy = false
+		}
+	}
+
+	if healthy {
+		c.JSON(http.StatusOK, h.healthStatus())
+	} else {
+		c.JSON(http.StatusServiceUnavailable, h.healthStatus())
+	}
+}
+
+// @Id getReadyz
+// @Summary Readiness probe, succeeds when all the coordinators are answering
+// @Tags    Health
+// @Produce json
+// @Success 200 {object} HealthStatus
+// @Failure 503 {object} HealthStatus
+// @Router /readyz [get]
+func (h *Handler) getReadyz(c *gin.Context) {
+	ready := true
+	for _, coordinator := range h.coordinators.All() {
+		if watchdog := coordinator.Watchdog(); watchdog != nil {
+			ready = ready && watchdog.Ready()
+		} else {
+			ready = ready && coordinator.Serial().IsConnected()
+		}
+	}
+
+	if ready {
+		c.JSON(http.StatusOK, h.healthStatus())
+	} else {
+		c.JSON(http.StatusServiceUnavailable, h.healthStatus())
+	}
+}
@@ -38,6 +38,17 @@ func fillLinkStruct(edge gr.WeightedEdge) MeshLink {
 	}
 }
 
+// findLink returns a link of the main networks with the network that contains it
+func (h *Handler) findLink(fromID, toID int64) (*graph.Network, gr.WeightedEdge) {
+	for _, coordinator := range h.coordinators.All() {
+		network := coordinator.Network()
+		if edge := network.WeightedEdge(fromID, toID); edge != nil {
+			return network, edge
+		}
+	}
+	return nil, nil
+}
+
 // @Id getLinks
 // @Summary Get links
 // @Tags    Links
@@ -61,19 +72,20 @@ func (h *Handler) getLinks(c *gin.Context) {
 	filter_from, _ := utils.ParseNodeId(p.Filter["from"])
 	filter_any := smartInteger(p.Filter["any"])
 
-	network := graph.GetMainNetwork()
-	links := network.WeightedEdges()
-	jsonLinks := make([]MeshLink, 0, links.Len())
-	for links.Next() {
-		edge := links.WeightedEdge()
-		fromID := edge.From().ID()
-		toID := edge.To().ID()
+	jsonLinks := make([]MeshLink, 0)
+	for _, coordinator := range h.coordinators.All() {
+		links := coordinator.Network().WeightedEdges()
+		for links.Next() {
+			edge := links.WeightedEdge()
+			fromID := edge.From().ID()
+			toID := edge.To().ID()
 
-		if ((filter_to != -1 && filter_to != toID) && (filter_from != -1 && filter_from != fromID)) || (filter_any != -1 && (filter_any != fromID && filter_any != toID)) {
-			continue
-		}
+			if ((filter_to != -1 && filter_to != toID) && (filter_from != -1 && filter_from != fromID)) || (filter_any != -1 && (filter_any != fromID && filter_any != toID)) {
+				continue
+			}
 
-		jsonLinks = append(jsonLinks, fillLinkStruct(edge))
+			jsonLinks = append(jsonLinks, fillLinkStruct(edge))
+		}
 	}
 
 	// Sort array base on request fields
@@ -103,8 +115,7 @@ func (h *Handler) getOneLink(c *gin.Context) {
 	}
 
 	fromID, toID := parseFromToId(uint(fromToId))
-	network := graph.GetMainNetwork()
-	edge := network.WeightedEdge(int64(fromID), int64(toID))
+	_, edge := h.findLink(int64(fromID), int64(toID))
 	if edge == nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Link not found"})
 		return
@@ -131,8 +142,7 @@ func (h *Handler) createLink(c *gin.Context) {
 		return
 	}
 
-	network := graph.GetMainNetwork()
-	_, err = network.GetNodeDevice(req.From)
+	_, network, _, err := h.findNode(req.From, false)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "From node not found"})
 		return
@@ -144,7 +154,6 @@ func (h *Handler) createLink(c *gin.Context) {
 	}
 
 	network.ChangeEdgeWeight(req.From, req.To, float64(req.Weight), float64(req.Weight))
-	network.NotifyNetworkChanged(false)
 
 	edge := network.WeightedEdge(req.From, req.To)
 	if edge == nil {
@@ -182,15 +191,13 @@ func (h *Handler) updateLink(c *gin.Context) {
 	}
 
 	fromID, toID := parseFromToId(uint(fromToId))
-	network := graph.GetMainNetwork()
-	edge := network.WeightedEdge(int64(fromID), int64(toID))
+	network, edge := h.findLink(int64(fromID), int64(toID))
 	if edge == nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Link not found"})
 		return
 	}
 
 	network.ChangeEdgeWeight(int64(fromID), int64(toID), float64(req.Weight), float64(req.Weight))
-	network.NotifyNetworkChanged(false)
 
 	jsonLink := fillLinkStruct(edge)
 	c.JSON(http.StatusOK, jsonLink)
@@ -214,15 +221,13 @@ func (h *Handler) deleteLink(c *gin.Context) {
 	}
 
 	fromID, toID := parseFromToId(uint(fromToId))
-	network := graph.GetMainNetwork()
-	edge := network.WeightedEdge(int64(fromID), int64(toID))
+	network, edge := h.findLink(int64(fromID), int64(toID))
 	if edge == nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Link not found"})
 		return
 	}
 
 	network.RemoveEdge(int64(fromID), int64(toID))
-	network.NotifyNetworkChanged(false)
 
 	jsonLink := fillLinkStruct(edge)
 	c.JSON(http.StatusOK, jsonLink)
@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"leguru.net/m/v2/meshmesh"
+)
+
+// toFilter converts the query parameters in a log filter, the level can be a name or a number
+func (r NodeLogsRequest) toFilter() (meshmesh.NodeLogFilter, error) {
+	filter := meshmesh.NodeLogFilter{Level: meshmesh.NodeLogLevelVeryVerbose}
+
+	if r.Level != "" {
+		level, ok := meshmesh.NodeLogLevelFromName(strings.ToUpper(r.Level))
+		if !ok {
+			n, err := strconv.ParseUint(r.Level, 10, 16)
+			if err != nil {
+				return filter, errors.New("invalid log level: " + r.Level)
+			}
+			level = uint16(n)
+		}
+		filter.Level = level
+	}
+
+	var err error
+	if r.Since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, r.Since); err != nil {
+			return filter, err
+		}
+	}
+	if r.Until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, r.Until); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+func toNodeLogLine(index uint, entry *meshmesh.NodeLogEntry) NodeLogLine {
+	return NodeLogLine{
+		ID:    index,
+		Node:  uint(entry.Node),
+		Level: meshmesh.NodeLogLevelName(entry.Level),
+		Time:  entry.Time.Format(time.RFC3339Nano),
+		Line:  entry.Line,
+	}
+}
+
+func (h *Handler) parseNodeLogsRequest(c *gin.Context) (meshmesh.MeshNodeId, NodeLogsRequest, meshmesh.NodeLogFilter, error) {
+	var req NodeLogsRequest
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, req, meshmesh.NodeLogFilter{}, err
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		return 0, req, meshmesh.NodeLogFilter{}, err
+	}
+	filter, err := req.toFilter()
+	return meshmesh.MeshNodeId(id), req, filter, err
+}
+
+// @Id getNodeLogs
+// @Summary Get the log lines received from a node
+// @Tags    Nodes
+// @Accept  json
+// @Produce json
+// @Param   id    path  string true  "Node ID"
+// @Param   level query string false "Most verbose level returned (name or number)"
+// @Param   since query string false "RFC3339 start time"
+// @Param   until query string false "RFC3339 end time"
+// @Param   limit query int    false "Return only the last lines"
+// @Success 200 {array} NodeLogLine
+// @Failure 400 {object} string
+// @Router /api/nodes/{id}/logs [get]
+func (h *Handler) getNodeLogs(c *gin.Context) {
+	id, req, filter, err := h.parseNodeLogsRequest(c)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	entries := h.coordinators.QueryNodeLogs(id, filter)
+	if req.Limit > 0 && len(entries) > req.Limit {
+		entries = entries[len(entries)-req.Limit:]
+	}
+
+	jsonLines := make([]NodeLogLine, 0, len(entries))
+	for i := range entries {
+		jsonLines = append(jsonLines, toNodeLogLine(uint(i), &entries[i]))
+	}
+
+	c.Header("Content-Range", fmt.Sprintf("%d-%d/%d", 0, len(jsonLines), len(jsonLines)))
+	c.JSON(http.StatusOK, jsonLines)
+}
+
+// @Id streamNodeLogs
+// @Summary Stream the new log lines received from a node as server sent events, node 0 streams all the nodes
+// @Tags    Nodes
+// @Produce text/event-stream
+// @Param   id    path  string true  "Node ID"
+// @Param   level query string false "Most verbose level returned (name or number)"
+// @Success 200 {object} NodeLogLine
+// @Failure 400 {object} string
+// @Router /api/nodes/{id}/logs/stream [get]
+func (h *Handler) streamNodeLogs(c *gin.Context) {
+	id, _, filter, err := h.parseNodeLogsRequest(c)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	lines, cancel := h.coordinators.SubscribeNodeLogs(id, filter)
+	defer cancel()
+
+	index := uint(0)
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case entry := <-lines:
+			c.SSEvent("log", toNodeLogLine(index, &entry))
+			index++
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
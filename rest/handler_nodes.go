@@ -3,6 +3,7 @@ package rest
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"sort"
 	"strconv"
 
@@ -11,6 +12,7 @@ import (
 	"leguru.net/m/v2/graph"
 	"leguru.net/m/v2/logger"
 	"leguru.net/m/v2/meshmesh"
+	"leguru.net/m/v2/utils"
 )
 
 // @Id getNodes
@@ -32,19 +34,22 @@ func (h *Handler) getNodes(c *gin.Context) {
 
 	p := req.toGetListParams()
 
-	network := graph.GetMainNetwork()
-	nodes := network.Nodes()
-	jsonNodes := make([]MeshNode, 0, nodes.Len())
-	for nodes.Next() {
-		dev := nodes.Node().(graph.NodeDevice)
-		jsonNodes = append(jsonNodes, MeshNode{
-			ID:      uint(dev.ID()),
-			Tag:     string(dev.Device().Tag()),
-			InUse:   dev.Device().InUse(),
-			Path:    graph.FmtNodePath(network, dev),
-			IsLocal: dev.ID() == network.LocalDeviceId(),
-			FirmRev: dev.Device().Firmware(),
-		})
+	jsonNodes := make([]MeshNode, 0)
+	for _, coordinator := range h.coordinators.All() {
+		network := coordinator.Network().Snapshot()
+		nodes := network.Nodes()
+		for nodes.Next() {
+			dev := nodes.Node().(graph.NodeDevice)
+			jsonNodes = append(jsonNodes, MeshNode{
+				ID:          uint(dev.ID()),
+				Tag:         string(dev.Device().Tag()),
+				InUse:       dev.Device().InUse(),
+				Path:        graph.FmtNodePath(network, dev),
+				IsLocal:     dev.ID() == network.LocalDeviceId(),
+				FirmRev:     dev.Device().Firmware(),
+				Coordinator: coordinator.Name,
+			})
+		}
 	}
 
 	sort.Slice(jsonNodes, func(i, j int) bool {
@@ -81,18 +86,27 @@ func (h *Handler) createNode(c *gin.Context) {
 		return
 	}
 
-	network := graph.GetMainNetwork()
-	_, err = network.GetNodeDevice(int64(req.ID))
+	coordinator, ok := h.coordinator(c, req.Coordinator)
+	if !ok {
+		return
+	}
+
+	_, _, _, err = h.findNode(int64(req.ID), false)
 	if err == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Node already exists"})
 		return
 	}
 
+	network := coordinator.Network()
 	dev := graph.NewNodeDevice(int64(req.ID), req.InUse, req.Tag)
-	network.AddNode(dev)
-	network.NotifyNetworkChanged(false)
+	// Checked again, the node can be added by another request
+	err = network.AddNode(dev)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Node already exists"})
+		return
+	}
 
-	jsonNode := h.fillNodeStruct(dev, false, network)
+	jsonNode := h.fillNodeStruct(c.Request.Context(), coordinator, dev, false, network)
 
 	c.JSON(http.StatusOK, jsonNode)
 }
@@ -116,14 +130,13 @@ func (h *Handler) getOneNode(c *gin.Context) {
 		return
 	}
 
-	network := graph.GetMainNetwork()
-	dev, err := network.GetNodeDevice(int64(id))
+	coordinator, network, dev, err := h.findNode(int64(id), false)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
 		return
 	}
 
-	jsonNode := h.fillNodeStruct(dev, true, network)
+	jsonNode := h.fillNodeStruct(c.Request.Context(), coordinator, dev, true, network)
 	c.JSON(http.StatusOK, jsonNode)
 }
 
@@ -152,23 +165,35 @@ func (h *Handler) updateNode(c *gin.Context) {
 		return
 	}
 
-	network := graph.GetMainNetwork()
-	dev, err := network.GetNodeDevice(int64(id))
+	var routing graph.RoutingPolicy
+	if req.Routing != nil {
+		routing, err = graph.ParseRoutingPolicy(*req.Routing)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	coordinator, network, dev, err := h.findNode(int64(id), false)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
 		return
 	}
 
-	dev.Device().SetTag(req.Tag)
-	dev.Device().SetInUse(req.InUse)
-	network.NotifyNetworkChanged(false)
+	network.UpdateNode(dev.ID(), func(device *graph.Device) {
+		device.SetTag(req.Tag)
+		device.SetInUse(req.InUse)
+		if req.Routing != nil {
+			device.SetRoutingPolicy(routing)
+		}
+	})
 
-	jsonNode := h.fillNodeStruct(dev, true, network)
+	jsonNode := h.fillNodeStruct(c.Request.Context(), coordinator, dev, true, network)
 	errors := []error{}
 
 	if req.Channel != (int8)(jsonNode.Channel) {
 		protocol := meshmesh.FindBestProtocol(meshmesh.MeshNodeId(dev.ID()), network)
-		_, err := h.serialConn.SendReceiveApiProt(meshmesh.NodeSetChannelApiRequest{Channel: uint8(req.Channel)}, protocol, meshmesh.MeshNodeId(dev.ID()), network)
+		_, err := coordinator.Serial().SendReceiveApiProtContext(c.Request.Context(), meshmesh.NodeSetChannelApiRequest{Channel: uint8(req.Channel)}, protocol, meshmesh.MeshNodeId(dev.ID()), network)
 		if err != nil {
 			errors = append(errors, err)
 		} else {
@@ -197,17 +222,67 @@ func (h *Handler) deleteNode(c *gin.Context) {
 		return
 	}
 
-	network := graph.GetMainNetwork()
-	dev, err := network.GetNodeDevice(int64(id))
+	coordinator, network, dev, err := h.findNode(int64(id), false)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
 		return
 	}
 
-	jsonNode := h.fillNodeStruct(dev, false, network)
+	jsonNode := h.fillNodeStruct(c.Request.Context(), coordinator, dev, false, network)
 
 	network.RemoveNode(int64(id))
-	network.NotifyNetworkChanged(false)
 
 	c.JSON(http.StatusOK, jsonNode)
 }
+
+// @Id getNodePaths
+// @Summary Get the shortest path to a node and its backups
+// @Tags    Nodes
+// @Produce json
+// @Param   id path string true "Node ID"
+// @Success 200 {object} NodePaths
+// @Failure 404 {object} string
+// @Router /api/nodes/{id}/paths [get]
+func (h *Handler) getNodePaths(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	coordinator, network, dev, err := h.findNode(int64(id), false)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Node not found: " + err.Error()})
+		return
+	}
+
+	paths, err := network.GetPaths(dev, meshmesh.MaxConnectedPaths)
+	if err != nil {
+		c.JSON(meshErrorStatus(err), gin.H{"message": err.Error()})
+		return
+	}
+
+	var route meshmesh.ConnectedPathRoute
+	if coordinator.ConnectedPath != nil {
+		route, _ = coordinator.ConnectedPath.Routes.Get(meshmesh.MeshNodeId(id))
+	}
+
+	jsonPaths := NodePaths{ID: uint(id), Coordinator: coordinator.Name, Paths: make([]NodePath, 0, len(paths))}
+	for _, path := range paths {
+		nodes := make([]uint, len(path.Nodes))
+		for i, node := range path.Nodes {
+			nodes[i] = uint(node)
+		}
+		jsonPaths.Paths = append(jsonPaths.Paths, NodePath{
+			Path:      utils.FmtPath2Str(path.Nodes),
+			Nodes:     nodes,
+			Cost:      path.Weight,
+			Preferred: slices.Equal(path.Nodes, route.Path),
+		})
+	}
+	if !route.Time.IsZero() {
+		jsonPaths.LastSucceeded = formatTimeForJson(route.Time)
+	}
+	c.JSON(http.StatusOK, jsonPaths)
+}
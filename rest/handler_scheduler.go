@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Id getSchedulerStats
+// @Summary Requests waiting to be sent and airtime used by every traffic class
+// @Tags    Scheduler
+// @Produce json
+// @Param   coordinator query string false "Coordinator name, the default one if empty"
+// @Success 200 {object} SchedulerStats
+// @Router /api/v1/scheduler [get]
+func (h *Handler) getSchedulerStats(c *gin.Context) {
+	coordinator, ok := h.coordinator(c, c.Query("coordinator"))
+	if !ok {
+		return
+	}
+
+	stats := coordinator.Serial().SchedulerStats()
+	result := SchedulerStats{
+		Coordinator: coordinator.Name,
+		Queued:      stats.Queued,
+		Inflight:    stats.Inflight,
+		Classes:     make([]TrafficClassStats, 0, len(stats.Classes)),
+	}
+	for _, class := range stats.Classes {
+		result.Classes = append(result.Classes, TrafficClassStats{
+			Class:     class.Class.String(),
+			Budget:    class.Budget,
+			Queued:    class.Queued,
+			Sent:      class.Sent,
+			AirtimeMs: class.Airtime.Milliseconds(),
+			Usage:     math.Round(class.Usage*10) / 10,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -1,13 +1,42 @@
 package rest
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
 	"leguru.net/m/v2/graph"
 	"leguru.net/m/v2/meshmesh"
 	"leguru.net/m/v2/meshmesh/pb"
 	"leguru.net/m/v2/utils"
 )
 
-func (h *Handler) fillNodesArrays(network *graph.Network) []MeshNode {
+// meshErrorStatus returns the HTTP status of an error of the mesh operations
+func meshErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, meshmesh.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return http.StatusRequestTimeout
+	case errors.Is(err, meshmesh.ErrSerialClosed), errors.Is(err, meshmesh.ErrNoRoute), errors.Is(err, meshmesh.ErrConnectionNack):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, meshmesh.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, meshmesh.ErrNodeInactive):
+		return http.StatusConflict
+	case errors.Is(err, meshmesh.ErrPoliteBroadcastDisabled):
+		return http.StatusForbidden
+	case errors.Is(err, meshmesh.ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, meshmesh.ErrDecode):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) fillNodesArrays(coordinator *meshmesh.Coordinator, live *graph.Network) []MeshNode {
+	network := live.Snapshot()
 	nodes := network.Nodes()
 	nodesArray := make([]MeshNode, 0, nodes.Len())
 	for nodes.Next() {
@@ -20,12 +49,14 @@ func (h *Handler) fillNodesArrays(network *graph.Network) []MeshNode {
 			InUse:       d.InUse(),
 			DeepSleep:   d.DeepSleep(),
 			Path:        graph.FmtNodePath(network, dev),
+			Routing:     d.RoutingPolicy().String(),
 			IsLocal:     dev.ID() == network.LocalDeviceId(),
 			FirmRev:     d.Firmware(),
 			LibVersion:  d.LibVersion(),
 			CompileTime: formatTimeForJson(d.CompileTime()),
 			LastSeen:    formatTimeForJson(d.LastSeen()),
 			DevType:     d.NodeTypeString(),
+			Coordinator: coordinator.Name,
 			compileTime: d.CompileTime(),
 			lastSeen:    d.LastSeen(),
 		})
@@ -33,7 +64,7 @@ func (h *Handler) fillNodesArrays(network *graph.Network) []MeshNode {
 	return nodesArray
 }
 
-func (h *Handler) fillNodeStruct(dev graph.NodeDevice, withInfo bool, network *graph.Network) MeshNode {
+func (h *Handler) fillNodeStruct(ctx context.Context, coordinator *meshmesh.Coordinator, dev graph.NodeDevice, withInfo bool, network *graph.Network) MeshNode {
 
 	d := dev.Device()
 	jsonNode := MeshNode{
@@ -47,54 +78,57 @@ func (h *Handler) fillNodeStruct(dev graph.NodeDevice, withInfo bool, network *g
 		CompileTime: formatTimeForJson(d.CompileTime()),
 		DevType:     d.NodeTypeString(),
 		LastSeen:    formatTimeForJson(d.LastSeen()),
-		Path:        graph.FmtNodePath(network, dev),
+		Path:        graph.FmtNodePath(network.Snapshot(), dev),
+		Routing:     d.RoutingPolicy().String(),
+		Coordinator: coordinator.Name,
 	}
 
 	if withInfo {
-		err := h.nodeInfoGetCmd(network, &jsonNode)
+		err := h.nodeInfoGetCmd(ctx, coordinator, network, &jsonNode)
 		if err != nil {
 			jsonNode.Error = err.Error()
 		} else {
-			changed := false
-			if jsonNode.DevFriendlyName != "" && jsonNode.DevFriendlyName != d.FriendlyName() {
-				d.SetFriendlyName(jsonNode.DevFriendlyName)
-				changed = true
-			}
-			if jsonNode.LibVersion != "" && jsonNode.LibVersion != d.LibVersion() {
-				d.SetLibVersion(jsonNode.LibVersion)
-				changed = true
-			}
-			if jsonNode.DevRevision != "" && jsonNode.DevRevision != d.Firmware() {
-				d.SetFirmware(jsonNode.DevRevision)
-				changed = true
-			}
-			if jsonNode.CompileTime != "" && jsonNode.CompileTime != d.CompileTimeString() {
-				d.SetCompileTimeString(jsonNode.CompileTime)
-				changed = true
-			}
-			if changed {
-				network.NotifyNetworkChanged(false)
-			}
+			// The network is notified only if a field changed
+			network.UpdateNode(dev.ID(), func(d *graph.Device) {
+				if jsonNode.DevFriendlyName != "" {
+					d.SetFriendlyName(jsonNode.DevFriendlyName)
+				}
+				if jsonNode.LibVersion != "" {
+					d.SetLibVersion(jsonNode.LibVersion)
+				}
+				if jsonNode.DevRevision != "" {
+					d.SetFirmware(jsonNode.DevRevision)
+				}
+				if jsonNode.CompileTime != "" && jsonNode.CompileTime != d.CompileTimeString() {
+					d.SetCompileTimeString(jsonNode.CompileTime)
+				}
+			})
 		}
 	}
 
 	return jsonNode
 }
 
-func (h *Handler) nodeInfoGetCmd(network *graph.Network, m *MeshNode) error {
+func (h *Handler) nodeInfoGetCmd(ctx context.Context, coordinator *meshmesh.Coordinator, network *graph.Network, m *MeshNode) error {
 	protocol := meshmesh.FindBestProtocol(meshmesh.MeshNodeId(m.ID), network)
-	rep, err := h.serialConn.SendReceiveApiProt(meshmesh.FirmRevApiRequest{}, protocol, meshmesh.MeshNodeId(m.ID), network)
+	rep, err := coordinator.Serial().SendReceiveApiProtContext(ctx, meshmesh.FirmRevApiRequest{}, protocol, meshmesh.MeshNodeId(m.ID), network)
 	if err != nil {
 		return err
 	}
-	rev := rep.(meshmesh.FirmRevApiReply)
+	rev, ok := rep.(meshmesh.FirmRevApiReply)
+	if !ok {
+		return fmt.Errorf("%w: invalid firmware reply", meshmesh.ErrDecode)
+	}
 
 	if utils.RevisionToInteger(m.FirmRev) > 1004002 {
-		rep, err = h.serialConn.SendReceiveApiProt(meshmesh.ProtoNodeInfoApiRequest{}, protocol, meshmesh.MeshNodeId(m.ID), network)
+		rep, err = coordinator.Serial().SendReceiveApiProtContext(ctx, meshmesh.ProtoNodeInfoApiRequest{}, protocol, meshmesh.MeshNodeId(m.ID), network)
 		if err != nil {
 			return err
 		}
-		nodeInfo := rep.(*pb.NodeInfo)
+		nodeInfo, ok := rep.(*pb.NodeInfo)
+		if !ok {
+			return fmt.Errorf("%w: invalid node info reply", meshmesh.ErrDecode)
+		}
 		m.DevFriendlyName = nodeInfo.FriendlyName
 		m.CompileTime = nodeInfo.CompileTime
 		m.FirmRev = nodeInfo.FirmwareVersion
@@ -102,11 +136,14 @@ func (h *Handler) nodeInfoGetCmd(network *graph.Network, m *MeshNode) error {
 		m.DevType = graph.EnumNodeTypeToString(graph.NodeType(nodeInfo.NodeType))
 	}
 
-	rep, err = h.serialConn.SendReceiveApiProt(meshmesh.NodeConfigApiRequest{}, protocol, meshmesh.MeshNodeId(m.ID), network)
+	rep, err = coordinator.Serial().SendReceiveApiProtContext(ctx, meshmesh.NodeConfigApiRequest{}, protocol, meshmesh.MeshNodeId(m.ID), network)
 	if err != nil {
 		return err
 	}
-	cfg := rep.(meshmesh.NodeConfigApiReply)
+	cfg, ok := rep.(meshmesh.NodeConfigApiReply)
+	if !ok {
+		return fmt.Errorf("%w: invalid node config reply", meshmesh.ErrDecode)
+	}
 
 	m.DevRevision = utils.TruncateZeros(rev.Revision)
 	m.DevName = utils.TruncateZeros(cfg.Tag)
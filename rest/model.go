@@ -35,9 +35,10 @@ type GetListRequest struct {
 }
 
 type CreateNodeRequest struct {
-	ID    uint   `json:"id"`
-	Tag   string `json:"tag"`
-	InUse bool   `json:"in_use"`
+	ID          uint   `json:"id"`
+	Tag         string `json:"tag"`
+	InUse       bool   `json:"in_use"`
+	Coordinator string `json:"coordinator"`
 }
 
 type UpdateNodeRequest struct {
@@ -46,6 +47,8 @@ type UpdateNodeRequest struct {
 	DevTag  string `json:"dev_tag"`
 	Channel int8   `json:"channel"`
 	TxPower int8   `json:"tx_power"`
+	// The routing policy of the paths to the node, empty for the default one, unchanged when missing
+	Routing *string `json:"routing,omitempty"`
 }
 
 type MeshNodeFirmware struct {
@@ -64,6 +67,7 @@ type MeshNode struct {
 	LastSeen        string `json:"last_seen"`
 	LibVersion      string `json:"libvers"`
 	Path            string `json:"path"`
+	Routing         string `json:"routing"`
 	Error           string `json:"error"`
 	DevType         string `json:"dev_type"`
 	DevName         string `json:"dev_name"`
@@ -74,6 +78,7 @@ type MeshNode struct {
 	Groups          int    `json:"groups"`
 	Binded          int    `json:"binded"`
 	Flags           int    `json:"flags"`
+	Coordinator     string `json:"coordinator"`
 
 	compileTime time.Time
 	lastSeen    time.Time
@@ -178,7 +183,8 @@ func (l MeshLink) Sort(other MeshLink, sortType SortType, sortBy SortFieldType)
 }
 
 type CtrlDiscoveryRequest struct {
-	Mode string `json:"mode"`
+	Mode        string `json:"mode"`
+	Coordinator string `json:"coordinator"`
 }
 
 type MeshNeighbor struct {
@@ -191,10 +197,11 @@ type MeshNeighbor struct {
 }
 
 type MeshDiscoveryState struct {
-	ID        int64  `json:"id"`
-	Status    string `json:"status"`
-	CurrentId string `json:"current_id"`
-	Repeat    int    `json:"repeat"`
+	ID          int64  `json:"id"`
+	Status      string `json:"status"`
+	CurrentId   string `json:"current_id"`
+	Repeat      int    `json:"repeat"`
+	Coordinator string `json:"coordinator"`
 }
 
 type MeshFirmware struct {
@@ -217,9 +224,10 @@ var acceptFilters = map[string]struct{}{
 }
 
 type EsphomeServer struct {
-	ID      uint   `json:"id"`
-	Address string `json:"address"`
-	Clients int    `json:"clients"`
+	ID          uint   `json:"id"`
+	Address     string `json:"address"`
+	Clients     int    `json:"clients"`
+	Coordinator string `json:"coordinator"`
 }
 
 type EsphomeClient struct {
@@ -236,6 +244,95 @@ type EsphomeClient struct {
 	Started  string `json:"started"`
 }
 
+type HealthStatus struct {
+	Status       string         `json:"status"`
+	Connected    bool           `json:"connected"`
+	Failures     int            `json:"failures"`
+	Recoveries   int            `json:"recoveries"`
+	LastEcho     string         `json:"last_echo"`
+	Error        string         `json:"error"`
+	Coordinator  string         `json:"coordinator,omitempty"`
+	Coordinators []HealthStatus `json:"coordinators,omitempty"`
+	Standby      *HealthStatus  `json:"standby,omitempty"`
+}
+
+type NodeLogsRequest struct {
+	Level string `form:"level"`
+	Since string `form:"since"`
+	Until string `form:"until"`
+	Limit int    `form:"limit"`
+}
+
+// NodePath is a path from the coordinator to a node, Preferred marks the last one that opened a connection
+type NodePath struct {
+	Path      string  `json:"path"`
+	Nodes     []uint  `json:"nodes"`
+	Cost      float64 `json:"cost"`
+	Preferred bool    `json:"preferred"`
+}
+
+type NodePaths struct {
+	ID            uint       `json:"id"`
+	Coordinator   string     `json:"coordinator"`
+	Paths         []NodePath `json:"paths"`
+	LastSucceeded string     `json:"last_succeeded"`
+}
+
+type NodeLogLine struct {
+	ID    uint   `json:"id"`
+	Node  uint   `json:"node"`
+	Level string `json:"level"`
+	Time  string `json:"time"`
+	Line  string `json:"line"`
+}
+
+type PoliteBroadcastRequest struct {
+	Command     string `json:"command" binding:"required"`
+	WindowMs    int    `json:"window_ms"`
+	Coordinator string `json:"coordinator"`
+}
+
+type PoliteBroadcastNodeReply struct {
+	ID    uint   `json:"id"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+type PoliteBroadcastResult struct {
+	Replies     []PoliteBroadcastNodeReply `json:"replies"`
+	MissingMain []uint                     `json:"missing_main"`
+	MissingStar []uint                     `json:"missing_star"`
+}
+
+type TrafficClassStats struct {
+	Class     string  `json:"class"`
+	Budget    int     `json:"budget"`
+	Queued    int     `json:"queued"`
+	Sent      uint64  `json:"sent"`
+	AirtimeMs int64   `json:"airtime_ms"`
+	Usage     float64 `json:"usage"`
+}
+
+type SchedulerStats struct {
+	Coordinator string              `json:"coordinator"`
+	Queued      int                 `json:"queued"`
+	Inflight    int                 `json:"inflight"`
+	Classes     []TrafficClassStats `json:"classes"`
+}
+
+type CoordinatorInfo struct {
+	Name             string `json:"name"`
+	LocalNode        uint   `json:"local_node"`
+	Connected        bool   `json:"connected"`
+	Nodes            int    `json:"nodes"`
+	StarNodes        int    `json:"star_nodes"`
+	Servers          int    `json:"servers"`
+	StandbyNode      uint   `json:"standby_node,omitempty"`
+	StandbyConnected bool   `json:"standby_connected"`
+	FailedOver       bool   `json:"failed_over"`
+	SwitchedAt       string `json:"switched_at"`
+}
+
 type GetListParams struct {
 	Filter        map[string]interface{}
 	Limit, Offset int
@@ -43,6 +43,10 @@ func (s router) Register(g gin.IRouter) {
 	})
 	g.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Liveness and readiness probes for the container watchdogs
+	g.GET("/healthz", h.getHealthz)
+	g.GET("/readyz", h.getReadyz)
+
 	r := g.Group("/api/v1")
 
 	r.GET("/hello", h.getHello)
@@ -54,6 +58,9 @@ func (s router) Register(g gin.IRouter) {
 		nodesGroup.POST("", h.createNode)
 		nodesGroup.PUT("/:id", h.updateNode)
 		nodesGroup.DELETE("/:id", h.deleteNode)
+		nodesGroup.GET("/:id/paths", h.getNodePaths)
+		nodesGroup.GET("/:id/logs", h.getNodeLogs)
+		nodesGroup.GET("/:id/logs/stream", h.streamNodeLogs)
 	}
 
 	nodeCommandsGroup := r.Group("/nodeCommands")
@@ -61,6 +68,12 @@ func (s router) Register(g gin.IRouter) {
 		nodeCommandsGroup.GET("/:id/reboot", h.rebootNode)
 	}
 
+	r.GET("/coordinators", h.getCoordinators)
+	r.POST("/coordinators/:name/failover", h.failoverCoordinator)
+	r.GET("/coordinators/:name/console", h.getCoordinatorConsole)
+	r.POST("/broadcast", h.politeBroadcast)
+	r.GET("/scheduler", h.getSchedulerStats)
+
 	linksGroup := r.Group("/links")
 	{
 		linksGroup.GET("", h.getLinks)
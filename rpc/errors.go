@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	mm "leguru.net/m/v2/meshmesh"
+)
+
+// statusCode returns the gRPC code of an error of the mesh operations
+func statusCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, mm.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, mm.ErrSerialClosed), errors.Is(err, mm.ErrNoRoute), errors.Is(err, mm.ErrConnectionNack):
+		return codes.Unavailable
+	case errors.Is(err, mm.ErrNodeNotFound):
+		return codes.NotFound
+	case errors.Is(err, mm.ErrNodeInactive), errors.Is(err, mm.ErrPoliteBroadcastDisabled):
+		return codes.FailedPrecondition
+	case errors.Is(err, mm.ErrInvalidRequest):
+		return codes.InvalidArgument
+	}
+	return codes.Internal
+}
+
+// meshStatus converts an error of the mesh operations in a gRPC status with the matching code
+func meshStatus(err error, message string) error {
+	return status.Errorf(statusCode(err), "%s: %v", message, err)
+}
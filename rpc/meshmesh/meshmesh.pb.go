@@ -1705,6 +1705,320 @@ func (x *NetworkNodeDeleteReply) GetSuccess() bool {
 	return false
 }
 
+// Node log lines: the stored lines are sent first, with follow the new lines are streamed
+// until the call is cancelled. Id 0 follows all the nodes, level 0 returns all the levels.
+// Times are unix milliseconds.
+type NodeLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Level         uint32                 `protobuf:"varint,2,opt,name=level,proto3" json:"level,omitempty"`
+	Since         int64                  `protobuf:"varint,3,opt,name=since,proto3" json:"since,omitempty"`
+	Follow        bool                   `protobuf:"varint,4,opt,name=follow,proto3" json:"follow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeLogsRequest) Reset() {
+	*x = NodeLogsRequest{}
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeLogsRequest) ProtoMessage() {}
+
+func (x *NodeLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeLogsRequest.ProtoReflect.Descriptor instead.
+func (*NodeLogsRequest) Descriptor() ([]byte, []int) {
+	return file_meshmesh_meshmesh_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *NodeLogsRequest) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *NodeLogsRequest) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *NodeLogsRequest) GetSince() int64 {
+	if x != nil {
+		return x.Since
+	}
+	return 0
+}
+
+func (x *NodeLogsRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+type NodeLogLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Level         uint32                 `protobuf:"varint,2,opt,name=level,proto3" json:"level,omitempty"`
+	Time          int64                  `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	Line          string                 `protobuf:"bytes,4,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeLogLine) Reset() {
+	*x = NodeLogLine{}
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeLogLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeLogLine) ProtoMessage() {}
+
+func (x *NodeLogLine) ProtoReflect() protoreflect.Message {
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeLogLine.ProtoReflect.Descriptor instead.
+func (*NodeLogLine) Descriptor() ([]byte, []int) {
+	return file_meshmesh_meshmesh_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *NodeLogLine) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *NodeLogLine) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *NodeLogLine) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *NodeLogLine) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+// Polite broadcast of a command to the whole network, the hub must be started with
+// --enable_polite_broadcast. Commands: echo, firmware, tag, reboot. A zero window
+// uses the default of 3 seconds. An empty coordinator selects the default one.
+type PoliteBroadcastRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	WindowMs      uint32                 `protobuf:"varint,2,opt,name=window_ms,json=windowMs,proto3" json:"window_ms,omitempty"`
+	Coordinator   string                 `protobuf:"bytes,3,opt,name=coordinator,proto3" json:"coordinator,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PoliteBroadcastRequest) Reset() {
+	*x = PoliteBroadcastRequest{}
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoliteBroadcastRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoliteBroadcastRequest) ProtoMessage() {}
+
+func (x *PoliteBroadcastRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoliteBroadcastRequest.ProtoReflect.Descriptor instead.
+func (*PoliteBroadcastRequest) Descriptor() ([]byte, []int) {
+	return file_meshmesh_meshmesh_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *PoliteBroadcastRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *PoliteBroadcastRequest) GetWindowMs() uint32 {
+	if x != nil {
+		return x.WindowMs
+	}
+	return 0
+}
+
+func (x *PoliteBroadcastRequest) GetCoordinator() string {
+	if x != nil {
+		return x.Coordinator
+	}
+	return ""
+}
+
+type PoliteBroadcastNodeReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint32                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PoliteBroadcastNodeReply) Reset() {
+	*x = PoliteBroadcastNodeReply{}
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoliteBroadcastNodeReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoliteBroadcastNodeReply) ProtoMessage() {}
+
+func (x *PoliteBroadcastNodeReply) ProtoReflect() protoreflect.Message {
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoliteBroadcastNodeReply.ProtoReflect.Descriptor instead.
+func (*PoliteBroadcastNodeReply) Descriptor() ([]byte, []int) {
+	return file_meshmesh_meshmesh_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *PoliteBroadcastNodeReply) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PoliteBroadcastNodeReply) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type PoliteBroadcastReply struct {
+	state         protoimpl.MessageState      `protogen:"open.v1"`
+	Replies       []*PoliteBroadcastNodeReply `protobuf:"bytes,1,rep,name=replies,proto3" json:"replies,omitempty"`
+	MissingMain   []uint32                    `protobuf:"varint,2,rep,packed,name=missing_main,json=missingMain,proto3" json:"missing_main,omitempty"`
+	MissingStar   []uint32                    `protobuf:"varint,3,rep,packed,name=missing_star,json=missingStar,proto3" json:"missing_star,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PoliteBroadcastReply) Reset() {
+	*x = PoliteBroadcastReply{}
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoliteBroadcastReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoliteBroadcastReply) ProtoMessage() {}
+
+func (x *PoliteBroadcastReply) ProtoReflect() protoreflect.Message {
+	mi := &file_meshmesh_meshmesh_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoliteBroadcastReply.ProtoReflect.Descriptor instead.
+func (*PoliteBroadcastReply) Descriptor() ([]byte, []int) {
+	return file_meshmesh_meshmesh_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *PoliteBroadcastReply) GetReplies() []*PoliteBroadcastNodeReply {
+	if x != nil {
+		return x.Replies
+	}
+	return nil
+}
+
+func (x *PoliteBroadcastReply) GetMissingMain() []uint32 {
+	if x != nil {
+		return x.MissingMain
+	}
+	return nil
+}
+
+func (x *PoliteBroadcastReply) GetMissingStar() []uint32 {
+	if x != nil {
+		return x.MissingStar
+	}
+	return nil
+}
+
 var File_meshmesh_meshmesh_proto protoreflect.FileDescriptor
 
 var file_meshmesh_meshmesh_proto_rawDesc = string([]byte{
@@ -1842,91 +2156,134 @@ var file_meshmesh_meshmesh_proto_rawDesc = string([]byte{
 	0x0a, 0x16, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x65, 0x6c,
 	0x65, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x75, 0x63, 0x63,
 	0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x75, 0x63, 0x63, 0x65,
-	0x73, 0x73, 0x2a, 0x5c, 0x0a, 0x0a, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x07, 0x0a, 0x03, 0x41, 0x4c, 0x4c, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x45, 0x4e,
-	0x53, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x42, 0x49, 0x4e, 0x41, 0x52, 0x59, 0x5f,
-	0x53, 0x45, 0x4e, 0x53, 0x4f, 0x52, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x57, 0x49, 0x54,
-	0x43, 0x48, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x49, 0x47, 0x48, 0x54, 0x10, 0x04, 0x12,
-	0x0f, 0x0a, 0x0b, 0x54, 0x45, 0x58, 0x54, 0x5f, 0x53, 0x45, 0x4e, 0x53, 0x4f, 0x52, 0x10, 0x05,
-	0x32, 0x93, 0x09, 0x0a, 0x08, 0x4d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x12, 0x3a, 0x0a,
-	0x08, 0x53, 0x61, 0x79, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x16, 0x2e, 0x6d, 0x65, 0x73, 0x68,
-	0x6d, 0x65, 0x73, 0x68, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x1a, 0x14, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x48, 0x65, 0x6c,
-	0x6c, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x08, 0x4e, 0x6f, 0x64,
-	0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x19, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68,
-	0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x1a, 0x17, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65,
-	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0a, 0x4e,
-	0x6f, 0x64, 0x65, 0x52, 0x65, 0x62, 0x6f, 0x6f, 0x74, 0x12, 0x1b, 0x2e, 0x6d, 0x65, 0x73, 0x68,
-	0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x62, 0x6f, 0x6f, 0x74, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73,
-	0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x62, 0x6f, 0x6f, 0x74, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x09, 0x42, 0x69, 0x6e, 0x64, 0x43, 0x6c, 0x65, 0x61, 0x72,
-	0x12, 0x1a, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x42, 0x69, 0x6e, 0x64,
-	0x43, 0x6c, 0x65, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6d,
-	0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x42, 0x69, 0x6e, 0x64, 0x43, 0x6c, 0x65, 0x61,
-	0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3a, 0x0a, 0x06, 0x53, 0x65, 0x74, 0x54,
-	0x61, 0x67, 0x12, 0x17, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65,
-	0x74, 0x54, 0x61, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x6d, 0x65,
-	0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74, 0x54, 0x61, 0x67, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0a, 0x53, 0x65, 0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e,
-	0x65, 0x6c, 0x12, 0x1b, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65,
-	0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x19, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74, 0x43, 0x68,
-	0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4f, 0x0a, 0x0d,
-	0x45, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1e, 0x2e,
-	0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65,
-	0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e,
-	0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65,
-	0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x46, 0x0a,
-	0x0a, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1b, 0x2e, 0x6d, 0x65,
-	0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x48, 0x61, 0x73,
-	0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d,
-	0x65, 0x73, 0x68, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x48, 0x61, 0x73, 0x68, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69,
-	0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65,
-	0x73, 0x68, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74,
-	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d,
-	0x65, 0x73, 0x68, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61,
-	0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x0e, 0x53, 0x65, 0x74,
-	0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x2e, 0x6d, 0x65,
-	0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79,
-	0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d,
-	0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74,
-	0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x58, 0x0a,
-	0x10, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72,
-	0x79, 0x12, 0x21, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45, 0x78, 0x65,
-	0x63, 0x75, 0x74, 0x65, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e,
-	0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79,
-	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4c, 0x0a, 0x0c, 0x4e, 0x65, 0x74, 0x77, 0x6f,
-	0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x1d, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65,
-	0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73,
-	0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4c, 0x0a, 0x0c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
-	0x45, 0x64, 0x67, 0x65, 0x73, 0x12, 0x1d, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68,
-	0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x45, 0x64, 0x67, 0x65, 0x73, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e,
-	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x45, 0x64, 0x67, 0x65, 0x73, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x00, 0x12, 0x64, 0x0a, 0x14, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f,
-	0x64, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x12, 0x25, 0x2e, 0x6d, 0x65,
-	0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f,
-	0x64, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x23, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65,
-	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75,
-	0x72, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5b, 0x0a, 0x11, 0x4e, 0x65, 0x74,
-	0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x22,
-	0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72,
-	0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x20, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65,
-	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x44, 0x0a, 0x13, 0x6c, 0x65, 0x67, 0x75, 0x72, 0x75,
-	0x2e, 0x6e, 0x65, 0x74, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x42, 0x0d, 0x4d,
-	0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x1c,
-	0x6c, 0x65, 0x67, 0x75, 0x72, 0x75, 0x2e, 0x6e, 0x65, 0x74, 0x2f, 0x6d, 0x2f, 0x76, 0x32, 0x2f,
-	0x72, 0x70, 0x63, 0x2f, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x73, 0x22, 0x65, 0x0a, 0x0f, 0x4e, 0x6f, 0x64, 0x65, 0x4c, 0x6f, 0x67, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x69, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x73, 0x69, 0x6e, 0x63,
+	0x65, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x22, 0x5b, 0x0a, 0x0b, 0x4e, 0x6f, 0x64,
+	0x65, 0x4c, 0x6f, 0x67, 0x4c, 0x69, 0x6e, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65,
+	0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x12,
+	0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x74, 0x69,
+	0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x22, 0x71, 0x0a, 0x16, 0x50, 0x6f, 0x6c, 0x69, 0x74, 0x65,
+	0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x77, 0x69,
+	0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x77,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4d, 0x73, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6f, 0x72, 0x64,
+	0x69, 0x6e, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f,
+	0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x6f, 0x72, 0x22, 0x40, 0x0a, 0x18, 0x50, 0x6f, 0x6c,
+	0x69, 0x74, 0x65, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x4e, 0x6f, 0x64, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x9a, 0x01, 0x0a, 0x14,
+	0x50, 0x6f, 0x6c, 0x69, 0x74, 0x65, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x3c, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68,
+	0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x74, 0x65, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74,
+	0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69,
+	0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x5f, 0x6d, 0x61,
+	0x69, 0x6e, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e,
+	0x67, 0x4d, 0x61, 0x69, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67,
+	0x5f, 0x73, 0x74, 0x61, 0x72, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6e, 0x67, 0x53, 0x74, 0x61, 0x72, 0x2a, 0x5c, 0x0a, 0x0a, 0x45, 0x6e, 0x74, 0x69,
+	0x74, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x07, 0x0a, 0x03, 0x41, 0x4c, 0x4c, 0x10, 0x00, 0x12,
+	0x0a, 0x0a, 0x06, 0x53, 0x45, 0x4e, 0x53, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x42,
+	0x49, 0x4e, 0x41, 0x52, 0x59, 0x5f, 0x53, 0x45, 0x4e, 0x53, 0x4f, 0x52, 0x10, 0x02, 0x12, 0x0a,
+	0x0a, 0x06, 0x53, 0x57, 0x49, 0x54, 0x43, 0x48, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x49,
+	0x47, 0x48, 0x54, 0x10, 0x04, 0x12, 0x0f, 0x0a, 0x0b, 0x54, 0x45, 0x58, 0x54, 0x5f, 0x53, 0x45,
+	0x4e, 0x53, 0x4f, 0x52, 0x10, 0x05, 0x32, 0xac, 0x0a, 0x0a, 0x08, 0x4d, 0x65, 0x73, 0x68, 0x6d,
+	0x65, 0x73, 0x68, 0x12, 0x3a, 0x0a, 0x08, 0x53, 0x61, 0x79, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x12,
+	0x16, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65,
+	0x73, 0x68, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x40, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x19, 0x2e, 0x6d, 0x65,
+	0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73,
+	0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
+	0x00, 0x12, 0x46, 0x0a, 0x0a, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x62, 0x6f, 0x6f, 0x74, 0x12,
+	0x1b, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52,
+	0x65, 0x62, 0x6f, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6d,
+	0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x62, 0x6f,
+	0x6f, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x09, 0x42, 0x69, 0x6e,
+	0x64, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x12, 0x1a, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73,
+	0x68, 0x2e, 0x42, 0x69, 0x6e, 0x64, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x18, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x42, 0x69,
+	0x6e, 0x64, 0x43, 0x6c, 0x65, 0x61, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x3a,
+	0x0a, 0x06, 0x53, 0x65, 0x74, 0x54, 0x61, 0x67, 0x12, 0x17, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d,
+	0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74, 0x54, 0x61, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x15, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74,
+	0x54, 0x61, 0x67, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0a, 0x53, 0x65,
+	0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x1b, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d,
+	0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68,
+	0x2e, 0x53, 0x65, 0x74, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x52, 0x65, 0x70, 0x6c, 0x79,
+	0x22, 0x00, 0x12, 0x4f, 0x0a, 0x0d, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x43, 0x6f,
+	0x75, 0x6e, 0x74, 0x12, 0x1e, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45,
+	0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45,
+	0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0a, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x48, 0x61, 0x73,
+	0x68, 0x12, 0x1b, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x48, 0x61, 0x73, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19,
+	0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x48, 0x61, 0x73, 0x68, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x0e, 0x47,
+	0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x1f, 0x2e,
+	0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69,
+	0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d,
+	0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x47, 0x65, 0x74, 0x45, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x52, 0x0a, 0x0e, 0x53, 0x65, 0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x1f, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65, 0x74,
+	0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x53, 0x65,
+	0x74, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x58, 0x0a, 0x10, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x44, 0x69,
+	0x73, 0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x12, 0x21, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65,
+	0x73, 0x68, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x44, 0x69, 0x73, 0x63, 0x6f, 0x76,
+	0x65, 0x72, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6d, 0x65, 0x73,
+	0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x65, 0x44, 0x69, 0x73,
+	0x63, 0x6f, 0x76, 0x65, 0x72, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4c, 0x0a,
+	0x0c, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x1d, 0x2e,
+	0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b,
+	0x4e, 0x6f, 0x64, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6d,
+	0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e,
+	0x6f, 0x64, 0x65, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x4c, 0x0a, 0x0c, 0x4e,
+	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x45, 0x64, 0x67, 0x65, 0x73, 0x12, 0x1d, 0x2e, 0x6d, 0x65,
+	0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x45, 0x64,
+	0x67, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6d, 0x65, 0x73,
+	0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x45, 0x64, 0x67,
+	0x65, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x64, 0x0a, 0x14, 0x4e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72,
+	0x65, 0x12, 0x25, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d,
+	0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12,
+	0x5b, 0x0a, 0x11, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x12, 0x22, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e,
+	0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d,
+	0x65, 0x73, 0x68, 0x2e, 0x4e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x4e, 0x6f, 0x64, 0x65, 0x44,
+	0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x08,
+	0x4e, 0x6f, 0x64, 0x65, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x19, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d,
+	0x65, 0x73, 0x68, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x4c, 0x6f, 0x67, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x4e,
+	0x6f, 0x64, 0x65, 0x4c, 0x6f, 0x67, 0x4c, 0x69, 0x6e, 0x65, 0x22, 0x00, 0x30, 0x01, 0x12, 0x55,
+	0x0a, 0x0f, 0x50, 0x6f, 0x6c, 0x69, 0x74, 0x65, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73,
+	0x74, 0x12, 0x20, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x50, 0x6f, 0x6c,
+	0x69, 0x74, 0x65, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x2e, 0x50,
+	0x6f, 0x6c, 0x69, 0x74, 0x65, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x44, 0x0a, 0x13, 0x6c, 0x65, 0x67, 0x75, 0x72, 0x75, 0x2e,
+	0x6e, 0x65, 0x74, 0x2e, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x42, 0x0d, 0x4d, 0x65,
+	0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x1c, 0x6c,
+	0x65, 0x67, 0x75, 0x72, 0x75, 0x2e, 0x6e, 0x65, 0x74, 0x2f, 0x6d, 0x2f, 0x76, 0x32, 0x2f, 0x72,
+	0x70, 0x63, 0x2f, 0x6d, 0x65, 0x73, 0x68, 0x6d, 0x65, 0x73, 0x68, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 })
 
 var (
@@ -1942,7 +2299,7 @@ func file_meshmesh_meshmesh_proto_rawDescGZIP() []byte {
 }
 
 var file_meshmesh_meshmesh_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_meshmesh_meshmesh_proto_msgTypes = make([]protoimpl.MessageInfo, 32)
+var file_meshmesh_meshmesh_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_meshmesh_meshmesh_proto_goTypes = []any{
 	(EntityType)(0),                     // 0: meshmesh.EntityType
 	(*HelloRequest)(nil),                // 1: meshmesh.HelloRequest
@@ -1977,6 +2334,11 @@ var file_meshmesh_meshmesh_proto_goTypes = []any{
 	(*NetworkNodeConfigureReply)(nil),   // 30: meshmesh.NetworkNodeConfigureReply
 	(*NetworkNodeDeleteRequest)(nil),    // 31: meshmesh.NetworkNodeDeleteRequest
 	(*NetworkNodeDeleteReply)(nil),      // 32: meshmesh.NetworkNodeDeleteReply
+	(*NodeLogsRequest)(nil),             // 33: meshmesh.NodeLogsRequest
+	(*NodeLogLine)(nil),                 // 34: meshmesh.NodeLogLine
+	(*PoliteBroadcastRequest)(nil),      // 35: meshmesh.PoliteBroadcastRequest
+	(*PoliteBroadcastNodeReply)(nil),    // 36: meshmesh.PoliteBroadcastNodeReply
+	(*PoliteBroadcastReply)(nil),        // 37: meshmesh.PoliteBroadcastReply
 }
 var file_meshmesh_meshmesh_proto_depIdxs = []int32{
 	0,  // 0: meshmesh.EntityHashRequest.service:type_name -> meshmesh.EntityType
@@ -1984,41 +2346,46 @@ var file_meshmesh_meshmesh_proto_depIdxs = []int32{
 	0,  // 2: meshmesh.SetEntityStateRequest.service:type_name -> meshmesh.EntityType
 	27, // 3: meshmesh.NetworkNodesReply.nodes:type_name -> meshmesh.NetworkNode
 	28, // 4: meshmesh.NetworkEdgesReply.edges:type_name -> meshmesh.NetworkEdge
-	1,  // 5: meshmesh.Meshmesh.SayHello:input_type -> meshmesh.HelloRequest
-	3,  // 6: meshmesh.Meshmesh.NodeInfo:input_type -> meshmesh.NodeInfoRequest
-	5,  // 7: meshmesh.Meshmesh.NodeReboot:input_type -> meshmesh.NodeRebootRequest
-	7,  // 8: meshmesh.Meshmesh.BindClear:input_type -> meshmesh.BindClearRequest
-	9,  // 9: meshmesh.Meshmesh.SetTag:input_type -> meshmesh.SetTagRequest
-	11, // 10: meshmesh.Meshmesh.SetChannel:input_type -> meshmesh.SetChannelRequest
-	13, // 11: meshmesh.Meshmesh.EntitiesCount:input_type -> meshmesh.EntitiesCountRequest
-	15, // 12: meshmesh.Meshmesh.EntityHash:input_type -> meshmesh.EntityHashRequest
-	17, // 13: meshmesh.Meshmesh.GetEntityState:input_type -> meshmesh.GetEntityStateRequest
-	19, // 14: meshmesh.Meshmesh.SetEntityState:input_type -> meshmesh.SetEntityStateRequest
-	21, // 15: meshmesh.Meshmesh.ExecuteDiscovery:input_type -> meshmesh.ExecuteDiscoveryRequest
-	23, // 16: meshmesh.Meshmesh.NetworkNodes:input_type -> meshmesh.NetworkNodesRequest
-	25, // 17: meshmesh.Meshmesh.NetworkEdges:input_type -> meshmesh.NetworkEdgesRequest
-	29, // 18: meshmesh.Meshmesh.NetworkNodeConfigure:input_type -> meshmesh.NetworkNodeConfigureRequest
-	31, // 19: meshmesh.Meshmesh.NetworkNodeDelete:input_type -> meshmesh.NetworkNodeDeleteRequest
-	2,  // 20: meshmesh.Meshmesh.SayHello:output_type -> meshmesh.HelloReply
-	4,  // 21: meshmesh.Meshmesh.NodeInfo:output_type -> meshmesh.NodeInfoReply
-	6,  // 22: meshmesh.Meshmesh.NodeReboot:output_type -> meshmesh.NodeRebootReply
-	8,  // 23: meshmesh.Meshmesh.BindClear:output_type -> meshmesh.BindClearReply
-	10, // 24: meshmesh.Meshmesh.SetTag:output_type -> meshmesh.SetTagReply
-	12, // 25: meshmesh.Meshmesh.SetChannel:output_type -> meshmesh.SetChannelReply
-	14, // 26: meshmesh.Meshmesh.EntitiesCount:output_type -> meshmesh.EntitiesCountReply
-	16, // 27: meshmesh.Meshmesh.EntityHash:output_type -> meshmesh.EntityHashReply
-	18, // 28: meshmesh.Meshmesh.GetEntityState:output_type -> meshmesh.GetEntityStateReply
-	20, // 29: meshmesh.Meshmesh.SetEntityState:output_type -> meshmesh.SetEntityStateReply
-	22, // 30: meshmesh.Meshmesh.ExecuteDiscovery:output_type -> meshmesh.ExecuteDiscoveryReply
-	24, // 31: meshmesh.Meshmesh.NetworkNodes:output_type -> meshmesh.NetworkNodesReply
-	26, // 32: meshmesh.Meshmesh.NetworkEdges:output_type -> meshmesh.NetworkEdgesReply
-	30, // 33: meshmesh.Meshmesh.NetworkNodeConfigure:output_type -> meshmesh.NetworkNodeConfigureReply
-	32, // 34: meshmesh.Meshmesh.NetworkNodeDelete:output_type -> meshmesh.NetworkNodeDeleteReply
-	20, // [20:35] is the sub-list for method output_type
-	5,  // [5:20] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	36, // 5: meshmesh.PoliteBroadcastReply.replies:type_name -> meshmesh.PoliteBroadcastNodeReply
+	1,  // 6: meshmesh.Meshmesh.SayHello:input_type -> meshmesh.HelloRequest
+	3,  // 7: meshmesh.Meshmesh.NodeInfo:input_type -> meshmesh.NodeInfoRequest
+	5,  // 8: meshmesh.Meshmesh.NodeReboot:input_type -> meshmesh.NodeRebootRequest
+	7,  // 9: meshmesh.Meshmesh.BindClear:input_type -> meshmesh.BindClearRequest
+	9,  // 10: meshmesh.Meshmesh.SetTag:input_type -> meshmesh.SetTagRequest
+	11, // 11: meshmesh.Meshmesh.SetChannel:input_type -> meshmesh.SetChannelRequest
+	13, // 12: meshmesh.Meshmesh.EntitiesCount:input_type -> meshmesh.EntitiesCountRequest
+	15, // 13: meshmesh.Meshmesh.EntityHash:input_type -> meshmesh.EntityHashRequest
+	17, // 14: meshmesh.Meshmesh.GetEntityState:input_type -> meshmesh.GetEntityStateRequest
+	19, // 15: meshmesh.Meshmesh.SetEntityState:input_type -> meshmesh.SetEntityStateRequest
+	21, // 16: meshmesh.Meshmesh.ExecuteDiscovery:input_type -> meshmesh.ExecuteDiscoveryRequest
+	23, // 17: meshmesh.Meshmesh.NetworkNodes:input_type -> meshmesh.NetworkNodesRequest
+	25, // 18: meshmesh.Meshmesh.NetworkEdges:input_type -> meshmesh.NetworkEdgesRequest
+	29, // 19: meshmesh.Meshmesh.NetworkNodeConfigure:input_type -> meshmesh.NetworkNodeConfigureRequest
+	31, // 20: meshmesh.Meshmesh.NetworkNodeDelete:input_type -> meshmesh.NetworkNodeDeleteRequest
+	33, // 21: meshmesh.Meshmesh.NodeLogs:input_type -> meshmesh.NodeLogsRequest
+	35, // 22: meshmesh.Meshmesh.PoliteBroadcast:input_type -> meshmesh.PoliteBroadcastRequest
+	2,  // 23: meshmesh.Meshmesh.SayHello:output_type -> meshmesh.HelloReply
+	4,  // 24: meshmesh.Meshmesh.NodeInfo:output_type -> meshmesh.NodeInfoReply
+	6,  // 25: meshmesh.Meshmesh.NodeReboot:output_type -> meshmesh.NodeRebootReply
+	8,  // 26: meshmesh.Meshmesh.BindClear:output_type -> meshmesh.BindClearReply
+	10, // 27: meshmesh.Meshmesh.SetTag:output_type -> meshmesh.SetTagReply
+	12, // 28: meshmesh.Meshmesh.SetChannel:output_type -> meshmesh.SetChannelReply
+	14, // 29: meshmesh.Meshmesh.EntitiesCount:output_type -> meshmesh.EntitiesCountReply
+	16, // 30: meshmesh.Meshmesh.EntityHash:output_type -> meshmesh.EntityHashReply
+	18, // 31: meshmesh.Meshmesh.GetEntityState:output_type -> meshmesh.GetEntityStateReply
+	20, // 32: meshmesh.Meshmesh.SetEntityState:output_type -> meshmesh.SetEntityStateReply
+	22, // 33: meshmesh.Meshmesh.ExecuteDiscovery:output_type -> meshmesh.ExecuteDiscoveryReply
+	24, // 34: meshmesh.Meshmesh.NetworkNodes:output_type -> meshmesh.NetworkNodesReply
+	26, // 35: meshmesh.Meshmesh.NetworkEdges:output_type -> meshmesh.NetworkEdgesReply
+	30, // 36: meshmesh.Meshmesh.NetworkNodeConfigure:output_type -> meshmesh.NetworkNodeConfigureReply
+	32, // 37: meshmesh.Meshmesh.NetworkNodeDelete:output_type -> meshmesh.NetworkNodeDeleteReply
+	34, // 38: meshmesh.Meshmesh.NodeLogs:output_type -> meshmesh.NodeLogLine
+	37, // 39: meshmesh.Meshmesh.PoliteBroadcast:output_type -> meshmesh.PoliteBroadcastReply
+	23, // [23:40] is the sub-list for method output_type
+	6,  // [6:23] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_meshmesh_meshmesh_proto_init() }
@@ -2033,7 +2400,7 @@ func file_meshmesh_meshmesh_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_meshmesh_meshmesh_proto_rawDesc), len(file_meshmesh_meshmesh_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   32,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -34,6 +34,8 @@ const (
 	Meshmesh_NetworkEdges_FullMethodName         = "/meshmesh.Meshmesh/NetworkEdges"
 	Meshmesh_NetworkNodeConfigure_FullMethodName = "/meshmesh.Meshmesh/NetworkNodeConfigure"
 	Meshmesh_NetworkNodeDelete_FullMethodName    = "/meshmesh.Meshmesh/NetworkNodeDelete"
+	Meshmesh_NodeLogs_FullMethodName             = "/meshmesh.Meshmesh/NodeLogs"
+	Meshmesh_PoliteBroadcast_FullMethodName      = "/meshmesh.Meshmesh/PoliteBroadcast"
 )
 
 // MeshmeshClient is the client API for Meshmesh service.
@@ -58,6 +60,8 @@ type MeshmeshClient interface {
 	NetworkEdges(ctx context.Context, in *NetworkEdgesRequest, opts ...grpc.CallOption) (*NetworkEdgesReply, error)
 	NetworkNodeConfigure(ctx context.Context, in *NetworkNodeConfigureRequest, opts ...grpc.CallOption) (*NetworkNodeConfigureReply, error)
 	NetworkNodeDelete(ctx context.Context, in *NetworkNodeDeleteRequest, opts ...grpc.CallOption) (*NetworkNodeDeleteReply, error)
+	NodeLogs(ctx context.Context, in *NodeLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NodeLogLine], error)
+	PoliteBroadcast(ctx context.Context, in *PoliteBroadcastRequest, opts ...grpc.CallOption) (*PoliteBroadcastReply, error)
 }
 
 type meshmeshClient struct {
@@ -218,6 +222,35 @@ func (c *meshmeshClient) NetworkNodeDelete(ctx context.Context, in *NetworkNodeD
 	return out, nil
 }
 
+func (c *meshmeshClient) NodeLogs(ctx context.Context, in *NodeLogsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NodeLogLine], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Meshmesh_ServiceDesc.Streams[0], Meshmesh_NodeLogs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[NodeLogsRequest, NodeLogLine]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Meshmesh_NodeLogsClient = grpc.ServerStreamingClient[NodeLogLine]
+
+func (c *meshmeshClient) PoliteBroadcast(ctx context.Context, in *PoliteBroadcastRequest, opts ...grpc.CallOption) (*PoliteBroadcastReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PoliteBroadcastReply)
+	err := c.cc.Invoke(ctx, Meshmesh_PoliteBroadcast_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MeshmeshServer is the server API for Meshmesh service.
 // All implementations must embed UnimplementedMeshmeshServer
 // for forward compatibility.
@@ -240,6 +273,8 @@ type MeshmeshServer interface {
 	NetworkEdges(context.Context, *NetworkEdgesRequest) (*NetworkEdgesReply, error)
 	NetworkNodeConfigure(context.Context, *NetworkNodeConfigureRequest) (*NetworkNodeConfigureReply, error)
 	NetworkNodeDelete(context.Context, *NetworkNodeDeleteRequest) (*NetworkNodeDeleteReply, error)
+	NodeLogs(*NodeLogsRequest, grpc.ServerStreamingServer[NodeLogLine]) error
+	PoliteBroadcast(context.Context, *PoliteBroadcastRequest) (*PoliteBroadcastReply, error)
 	mustEmbedUnimplementedMeshmeshServer()
 }
 
@@ -295,6 +330,12 @@ func (UnimplementedMeshmeshServer) NetworkNodeConfigure(context.Context, *Networ
 func (UnimplementedMeshmeshServer) NetworkNodeDelete(context.Context, *NetworkNodeDeleteRequest) (*NetworkNodeDeleteReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NetworkNodeDelete not implemented")
 }
+func (UnimplementedMeshmeshServer) NodeLogs(*NodeLogsRequest, grpc.ServerStreamingServer[NodeLogLine]) error {
+	return status.Errorf(codes.Unimplemented, "method NodeLogs not implemented")
+}
+func (UnimplementedMeshmeshServer) PoliteBroadcast(context.Context, *PoliteBroadcastRequest) (*PoliteBroadcastReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PoliteBroadcast not implemented")
+}
 func (UnimplementedMeshmeshServer) mustEmbedUnimplementedMeshmeshServer() {}
 func (UnimplementedMeshmeshServer) testEmbeddedByValue()                  {}
 
@@ -586,6 +627,35 @@ func _Meshmesh_NetworkNodeDelete_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Meshmesh_NodeLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(NodeLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MeshmeshServer).NodeLogs(m, &grpc.GenericServerStream[NodeLogsRequest, NodeLogLine]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Meshmesh_NodeLogsServer = grpc.ServerStreamingServer[NodeLogLine]
+
+func _Meshmesh_PoliteBroadcast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PoliteBroadcastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MeshmeshServer).PoliteBroadcast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Meshmesh_PoliteBroadcast_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MeshmeshServer).PoliteBroadcast(ctx, req.(*PoliteBroadcastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Meshmesh_ServiceDesc is the grpc.ServiceDesc for Meshmesh service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -653,7 +723,17 @@ var Meshmesh_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "NetworkNodeDelete",
 			Handler:    _Meshmesh_NetworkNodeDelete_Handler,
 		},
+		{
+			MethodName: "PoliteBroadcast",
+			Handler:    _Meshmesh_PoliteBroadcast_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "NodeLogs",
+			Handler:       _Meshmesh_NodeLogs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "meshmesh/meshmesh.proto",
 }
@@ -6,6 +6,7 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	"leguru.net/m/v2/graph"
 	"leguru.net/m/v2/logger"
 	mm "leguru.net/m/v2/meshmesh"
 	"leguru.net/m/v2/rpc/meshmesh"
@@ -15,13 +16,19 @@ import (
 
 type Server struct {
 	meshmesh.UnimplementedMeshmeshServer
-	serialConn     *mm.SerialConnection
+	coordinators   *mm.Coordinators
 	programName    string
 	programVersion string
 }
 
-func NewServer(programName string, programVersion string, serialConn *mm.SerialConnection) *Server {
-	return &Server{programName: programName, programVersion: programVersion, serialConn: serialConn}
+// route returns the coordinator that owns the node together with its main network
+func (s *Server) route(id uint32) (*mm.Coordinator, *graph.Network) {
+	coordinator := s.coordinators.ForNode(mm.MeshNodeId(id))
+	return coordinator, coordinator.Network()
+}
+
+func NewServer(programName string, programVersion string, coordinators *mm.Coordinators) *Server {
+	return &Server{programName: programName, programVersion: programVersion, coordinators: coordinators}
 }
 
 func (s *Server) SayHello(_ context.Context, req *meshmesh.HelloRequest) (*meshmesh.HelloReply, error) {
@@ -44,7 +51,7 @@ func (s *RpcServer) serve() {
 	}
 }
 
-func (s *RpcServer) Start(programName string, programVersion string, serialConn *mm.SerialConnection) error {
+func (s *RpcServer) Start(programName string, programVersion string, coordinators *mm.Coordinators) error {
 	var err error
 	s.lis, err = net.Listen("tcp", s.port)
 	if err != nil {
@@ -52,7 +59,7 @@ func (s *RpcServer) Start(programName string, programVersion string, serialConn
 	}
 
 	s.grpcServer = grpc.NewServer()
-	meshmesh.RegisterMeshmeshServer(s.grpcServer, NewServer(programName, programVersion, serialConn))
+	meshmesh.RegisterMeshmeshServer(s.grpcServer, NewServer(programName, programVersion, coordinators))
 	logger.WithField("port", s.port).Info("Starting gRPC server")
 	reflection.Register(s.grpcServer)
 	go s.serve()
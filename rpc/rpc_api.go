@@ -5,24 +5,23 @@ import (
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"leguru.net/m/v2/graph"
 	mm "leguru.net/m/v2/meshmesh"
 	"leguru.net/m/v2/rpc/meshmesh"
 	"leguru.net/m/v2/utils"
 )
 
-func (s *Server) NodeInfo(_ context.Context, req *meshmesh.NodeInfoRequest) (*meshmesh.NodeInfoReply, error) {
+func (s *Server) NodeInfo(ctx context.Context, req *meshmesh.NodeInfoRequest) (*meshmesh.NodeInfoReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	rep, err := s.serialConn.SendReceiveApiProt(mm.FirmRevApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	rep, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.FirmRevApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to get firmware revision: %v", err)
+		return nil, meshStatus(err, "Failed to get firmware revision")
 	}
 	rev := rep.(mm.FirmRevApiReply)
 
-	rep, err = s.serialConn.SendReceiveApiProt(mm.NodeConfigApiRequest{}, mm.UnicastProtocol, mm.MeshNodeId(req.Id), network)
+	rep, err = coordinator.Serial().SendReceiveApiProtContext(ctx, mm.NodeConfigApiRequest{}, mm.UnicastProtocol, mm.MeshNodeId(req.Id), network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to get node configuration: %v", err)
+		return nil, meshStatus(err, "Failed to get node configuration")
 	}
 	cfg := rep.(mm.NodeConfigApiReply)
 
@@ -35,58 +34,58 @@ func (s *Server) NodeInfo(_ context.Context, req *meshmesh.NodeInfoRequest) (*me
 	}, nil
 }
 
-func (s *Server) NodeReboot(_ context.Context, req *meshmesh.NodeRebootRequest) (*meshmesh.NodeRebootReply, error) {
+func (s *Server) NodeReboot(ctx context.Context, req *meshmesh.NodeRebootRequest) (*meshmesh.NodeRebootReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	_, err := s.serialConn.SendReceiveApiProt(mm.NodeRebootApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	_, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.NodeRebootApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to reboot node: %v", err)
+		return nil, meshStatus(err, "Failed to reboot node")
 	}
 	return &meshmesh.NodeRebootReply{Success: true}, nil
 }
 
-func (s *Server) BindClear(_ context.Context, req *meshmesh.BindClearRequest) (*meshmesh.BindClearReply, error) {
+func (s *Server) BindClear(ctx context.Context, req *meshmesh.BindClearRequest) (*meshmesh.BindClearReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	_, err := s.serialConn.SendReceiveApiProt(mm.NodeBindClearApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	_, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.NodeBindClearApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to clear binded server: %v", err)
+		return nil, meshStatus(err, "Failed to clear binded server")
 	}
 	return &meshmesh.BindClearReply{Success: true}, nil
 }
 
-func (s *Server) SetTag(_ context.Context, req *meshmesh.SetTagRequest) (*meshmesh.SetTagReply, error) {
+func (s *Server) SetTag(ctx context.Context, req *meshmesh.SetTagRequest) (*meshmesh.SetTagReply, error) {
 	if len(req.Tag) > 30 {
 		return nil, status.Errorf(codes.InvalidArgument, "Tag must be less than 30 characters")
 	}
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	_, err := s.serialConn.SendReceiveApiProt(mm.NodeSetTagApiRequest{Tag: req.Tag}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	_, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.NodeSetTagApiRequest{Tag: req.Tag}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to set tag: %v", err)
+		return nil, meshStatus(err, "Failed to set tag")
 	}
 	return &meshmesh.SetTagReply{Success: true}, nil
 }
 
-func (s *Server) SetChannel(_ context.Context, req *meshmesh.SetChannelRequest) (*meshmesh.SetChannelReply, error) {
+func (s *Server) SetChannel(ctx context.Context, req *meshmesh.SetChannelRequest) (*meshmesh.SetChannelReply, error) {
 	if req.Channel < 1 || req.Channel > 13 {
 		return nil, status.Errorf(codes.InvalidArgument, "Channel must be between 1 and 13")
 	}
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	_, err := s.serialConn.SendReceiveApiProt(mm.NodeSetChannelApiRequest{Channel: uint8(req.Channel)}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	_, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.NodeSetChannelApiRequest{Channel: uint8(req.Channel)}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to set channel: %v", err)
+		return nil, meshStatus(err, "Failed to set channel")
 	}
 	return &meshmesh.SetChannelReply{Success: true}, nil
 }
 
-func (s *Server) EntitiesCount(_ context.Context, req *meshmesh.EntitiesCountRequest) (*meshmesh.EntitiesCountReply, error) {
+func (s *Server) EntitiesCount(ctx context.Context, req *meshmesh.EntitiesCountRequest) (*meshmesh.EntitiesCountReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	rep, err := s.serialConn.SendReceiveApiProt(mm.EntitiesCountApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	rep, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.EntitiesCountApiRequest{}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to get entities count: %v", err)
+		return nil, meshStatus(err, "Failed to get entities count")
 	}
 	cnt := rep.(mm.EntitiesCountApiReply)
 	return &meshmesh.EntitiesCountReply{
@@ -99,12 +98,12 @@ func (s *Server) EntitiesCount(_ context.Context, req *meshmesh.EntitiesCountReq
 	}, nil
 }
 
-func (s *Server) EntityHash(_ context.Context, req *meshmesh.EntityHashRequest) (*meshmesh.EntityHashReply, error) {
+func (s *Server) EntityHash(ctx context.Context, req *meshmesh.EntityHashRequest) (*meshmesh.EntityHashReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	rep, err := s.serialConn.SendReceiveApiProt(mm.EntityHashApiRequest{Service: uint8(req.Service), Index: uint8(req.Index)}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	rep, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.EntityHashApiRequest{Service: uint8(req.Service), Index: uint8(req.Index)}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to get entity hash: %v", err)
+		return nil, meshStatus(err, "Failed to get entity hash")
 	}
 	hash := rep.(mm.EntityHashApiReply)
 	if hash.Hash == 0 && hash.Info == "E!" {
@@ -113,41 +112,41 @@ func (s *Server) EntityHash(_ context.Context, req *meshmesh.EntityHashRequest)
 	return &meshmesh.EntityHashReply{Id: req.Id, Hash: uint32(hash.Hash), Info: hash.Info}, nil
 }
 
-func (s *Server) GetEntityState(_ context.Context, req *meshmesh.GetEntityStateRequest) (*meshmesh.GetEntityStateReply, error) {
+func (s *Server) GetEntityState(ctx context.Context, req *meshmesh.GetEntityStateRequest) (*meshmesh.GetEntityStateReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	rep, err := s.serialConn.SendReceiveApiProt(mm.GetEntityStateApiRequest{
+	coordinator, network := s.route(req.Id)
+	rep, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.GetEntityStateApiRequest{
 		Service: uint8(req.Service),
 		Hash:    uint16(req.Hash),
 	}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to get entity state: %v", err)
+		return nil, meshStatus(err, "Failed to get entity state")
 	}
 	state := rep.(mm.GetEntityStateApiReply)
 	return &meshmesh.GetEntityStateReply{State: uint32(state.State)}, nil
 }
 
-func (s *Server) SetEntityState(_ context.Context, req *meshmesh.SetEntityStateRequest) (*meshmesh.SetEntityStateReply, error) {
+func (s *Server) SetEntityState(ctx context.Context, req *meshmesh.SetEntityStateRequest) (*meshmesh.SetEntityStateReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	_, err := s.serialConn.SendReceiveApiProt(mm.SetEntityStateApiRequest{
+	coordinator, network := s.route(req.Id)
+	_, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.SetEntityStateApiRequest{
 		Service: uint8(req.Service),
 		Hash:    uint16(req.Hash),
 		State:   uint16(req.State),
 	}, mm.FindBestProtocol(mmid, network), mmid, network)
 
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to set entity state: %v", err)
+		return nil, meshStatus(err, "Failed to set entity state")
 	}
 	return &meshmesh.SetEntityStateReply{Success: true}, nil
 }
 
-func (s *Server) ExecuteDiscovery(_ context.Context, req *meshmesh.ExecuteDiscoveryRequest) (*meshmesh.ExecuteDiscoveryReply, error) {
+func (s *Server) ExecuteDiscovery(ctx context.Context, req *meshmesh.ExecuteDiscoveryRequest) (*meshmesh.ExecuteDiscoveryReply, error) {
 	mmid := mm.MeshNodeId(req.Id)
-	network := graph.GetMainNetwork()
-	_, err := s.serialConn.SendReceiveApiProt(mm.DiscStartDiscoverApiRequest{Mask: 0, Filter: 0, Slotnum: 100}, mm.FindBestProtocol(mmid, network), mmid, network)
+	coordinator, network := s.route(req.Id)
+	_, err := coordinator.Serial().SendReceiveApiProtContext(ctx, mm.DiscStartDiscoverApiRequest{Mask: 0, Filter: 0, Slotnum: 100}, mm.FindBestProtocol(mmid, network), mmid, network)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to set entity state: %v", err)
+		return nil, meshStatus(err, "Failed to set entity state")
 	}
 	return &meshmesh.ExecuteDiscoveryReply{Success: true}, nil
 }
@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	mm "leguru.net/m/v2/meshmesh"
+	"leguru.net/m/v2/rpc/meshmesh"
+)
+
+func toNodeIds(nodes []mm.MeshNodeId) []uint32 {
+	ids := make([]uint32, len(nodes))
+	for i, node := range nodes {
+		ids[i] = uint32(node)
+	}
+	return ids
+}
+
+func (s *Server) PoliteBroadcast(ctx context.Context, req *meshmesh.PoliteBroadcastRequest) (*meshmesh.PoliteBroadcastReply, error) {
+	cmd, err := mm.PoliteBroadcastCommand(req.Command)
+	if err != nil {
+		return nil, meshStatus(err, "Invalid command")
+	}
+
+	window := mm.DefaultPoliteBroadcastWindow
+	if req.WindowMs > 0 {
+		window = time.Duration(req.WindowMs) * time.Millisecond
+	}
+
+	coordinator, err := s.coordinators.ByName(req.Coordinator)
+	if err != nil {
+		return nil, meshStatus(err, "Invalid coordinator")
+	}
+
+	result, err := coordinator.Serial().SendReceiveApiPoliteBroadcastContext(ctx, cmd, window, coordinator.Network(), coordinator.StarNetwork())
+	if err != nil {
+		return nil, meshStatus(err, "Failed to send polite broadcast")
+	}
+
+	reply := &meshmesh.PoliteBroadcastReply{
+		Replies:     make([]*meshmesh.PoliteBroadcastNodeReply, 0, len(result.Replies)),
+		MissingMain: toNodeIds(result.MissingMain),
+		MissingStar: toNodeIds(result.MissingStar),
+	}
+	for node, value := range result.Replies {
+		reply.Replies = append(reply.Replies, &meshmesh.PoliteBroadcastNodeReply{Id: uint32(node), Value: mm.PoliteBroadcastReplyText(value)})
+	}
+	slices.SortFunc(reply.Replies, func(a, b *meshmesh.PoliteBroadcastNodeReply) int {
+		return int(a.Id) - int(b.Id)
+	})
+	return reply, nil
+}
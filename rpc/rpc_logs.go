@@ -0,0 +1,65 @@
+package rpc
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	mm "leguru.net/m/v2/meshmesh"
+	"leguru.net/m/v2/rpc/meshmesh"
+)
+
+func toNodeLogLine(entry *mm.NodeLogEntry) *meshmesh.NodeLogLine {
+	return &meshmesh.NodeLogLine{
+		Id:    uint32(entry.Node),
+		Level: uint32(entry.Level),
+		Time:  entry.Time.UnixMilli(),
+		Line:  entry.Line,
+	}
+}
+
+func (s *Server) NodeLogs(req *meshmesh.NodeLogsRequest, stream grpc.ServerStreamingServer[meshmesh.NodeLogLine]) error {
+	filter := mm.NodeLogFilter{Level: uint16(req.Level)}
+	if req.Level == 0 {
+		filter.Level = mm.NodeLogLevelVeryVerbose
+	}
+	if req.Since > 0 {
+		filter.Since = time.UnixMilli(req.Since)
+	}
+
+	// Subscribe before the query to not lose the lines received in between
+	var lines <-chan mm.NodeLogEntry
+	if req.Follow {
+		var cancel func()
+		lines, cancel = s.coordinators.SubscribeNodeLogs(mm.MeshNodeId(req.Id), filter)
+		defer cancel()
+	}
+
+	var last time.Time
+	if req.Id != 0 {
+		for _, entry := range s.coordinators.QueryNodeLogs(mm.MeshNodeId(req.Id), filter) {
+			if err := stream.Send(toNodeLogLine(&entry)); err != nil {
+				return err
+			}
+			last = entry.Time
+		}
+	}
+
+	if !req.Follow {
+		return nil
+	}
+
+	for {
+		select {
+		case entry := <-lines:
+			if !entry.Time.After(last) {
+				// Already sent by the query
+				continue
+			}
+			if err := stream.Send(toNodeLogLine(&entry)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
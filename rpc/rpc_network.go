@@ -10,58 +10,57 @@ import (
 )
 
 func (s *Server) NetworkNodes(_ context.Context, req *meshmesh.NetworkNodesRequest) (*meshmesh.NetworkNodesReply, error) {
-	nodes := graph.GetMainNetwork().Nodes()
-	device := make([]*meshmesh.NetworkNode, nodes.Len())
-	i := 0
-	for nodes.Next() {
-		dev := nodes.Node().(graph.NodeDevice)
-		device[i] = &meshmesh.NetworkNode{
-			Id:    uint32(dev.ID()),
-			Tag:   string(dev.Device().Tag()),
-			Inuse: dev.Device().InUse(),
+	device := make([]*meshmesh.NetworkNode, 0)
+	for _, coordinator := range s.coordinators.All() {
+		nodes := coordinator.Network().Nodes()
+		for nodes.Next() {
+			dev := nodes.Node().(graph.NodeDevice)
+			device = append(device, &meshmesh.NetworkNode{
+				Id:    uint32(dev.ID()),
+				Tag:   string(dev.Device().Tag()),
+				Inuse: dev.Device().InUse(),
+			})
 		}
-		i += 1
 	}
 	return &meshmesh.NetworkNodesReply{Nodes: device}, nil
 }
 
 func (s *Server) NetworkEdges(_ context.Context, req *meshmesh.NetworkEdgesRequest) (*meshmesh.NetworkEdgesReply, error) {
-	edges := graph.GetMainNetwork().WeightedEdges()
-	_edges := make([]*meshmesh.NetworkEdge, edges.Len())
-	i := 0
-	for edges.Next() {
-		edge := edges.WeightedEdge()
-		_edges[i] = &meshmesh.NetworkEdge{
-			From:   uint32(edge.From().ID()),
-			To:     uint32(edge.To().ID()),
-			Weight: float32(edge.Weight()),
+	_edges := make([]*meshmesh.NetworkEdge, 0)
+	for _, coordinator := range s.coordinators.All() {
+		edges := coordinator.Network().WeightedEdges()
+		for edges.Next() {
+			edge := edges.WeightedEdge()
+			_edges = append(_edges, &meshmesh.NetworkEdge{
+				From:   uint32(edge.From().ID()),
+				To:     uint32(edge.To().ID()),
+				Weight: float32(edge.Weight()),
+			})
 		}
-		i += 1
 	}
 	return &meshmesh.NetworkEdgesReply{Edges: _edges}, nil
 }
 
 func (s *Server) NetworkNodeConfigure(_ context.Context, req *meshmesh.NetworkNodeConfigureRequest) (*meshmesh.NetworkNodeConfigureReply, error) {
-	network := graph.GetMainNetwork()
+	_, network := s.route(req.Id)
 	node := network.Node(int64(req.Id))
 	if node == nil {
 		return nil, status.Errorf(codes.NotFound, "Node not found")
 	}
-	dev := node.(graph.NodeDevice)
-	dev.Device().SetTag(req.Tag)
-	dev.Device().SetInUse(req.Inuse)
-	network.NotifyNetworkChanged(false)
+	network.UpdateNode(node.ID(), func(device *graph.Device) {
+		device.SetTag(req.Tag)
+		device.SetInUse(req.Inuse)
+	})
 	return &meshmesh.NetworkNodeConfigureReply{Success: true}, nil
 }
 
 func (s *Server) NetworkNodeDelete(_ context.Context, req *meshmesh.NetworkNodeDeleteRequest) (*meshmesh.NetworkNodeDeleteReply, error) {
-	network := graph.GetMainNetwork()
+	_, network := s.route(req.Id)
 	node := network.Node(int64(req.Id))
 	if node == nil {
 		return nil, status.Errorf(codes.NotFound, "Node not found")
 	}
 
 	network.RemoveNode(int64(req.Id))
-	network.NotifyNetworkChanged(false)
 	return &meshmesh.NetworkNodeDeleteReply{Success: true}, nil
 }
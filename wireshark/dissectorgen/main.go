@@ -0,0 +1,385 @@
+// dissectorgen builds the Wireshark Lua dissector of the meshmesh api frames
+// from the message definitions of meshmesh/apiframe.go.
+//
+//	go run ./wireshark/dissectorgen -src meshmesh -o wireshark/meshmesh.lua
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Message families that use a second byte (ApiId) to select the message
+var families = map[string][2]string{
+	"Disc":  {"discoveryApiRequest", "discoveryApiReply"},
+	"Flash": {"flashOperationApiRequest", "flashOperationApiReply"},
+}
+
+// Frame types whose struct name doesn't follow the constant name
+var structOverrides = map[string]string{
+	"connectedUnicastRequest": "UnicastRequest",
+	"connectedUnicastReply":   "UnicastReply",
+	"multipathRequest":        "MultiPathRequest",
+	"multipathReply":          "MultiPathReply",
+}
+
+// Frame types that carry a protocol buffer message
+var protobufFrames = map[string]string{
+	"protoNodeInfoApiReply":       "NodeInfo",
+	"protoPresentationRxApiReply": "NodePresentationRx",
+}
+
+type field struct {
+	Name   string
+	GoType string
+	Tag    string
+}
+
+type source struct {
+	consts  map[string]uint64
+	order   []string
+	structs map[string][]field
+}
+
+func (s *source) parseFile(filename string) error {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			switch spec := spec.(type) {
+			case *ast.ValueSpec:
+				if gen.Tok != token.CONST || len(spec.Values) != 1 {
+					continue
+				}
+				lit, ok := spec.Values[0].(*ast.BasicLit)
+				if !ok || lit.Kind != token.INT {
+					continue
+				}
+				v, err := strconv.ParseUint(lit.Value, 0, 64)
+				if err != nil {
+					continue
+				}
+				s.consts[spec.Names[0].Name] = v
+				s.order = append(s.order, spec.Names[0].Name)
+			case *ast.TypeSpec:
+				st, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				var fields []field
+				for _, f := range st.Fields.List {
+					if f.Tag == nil || len(f.Names) == 0 {
+						continue
+					}
+					tag, _ := strconv.Unquote(f.Tag.Value)
+					fields = append(fields, field{
+						Name:   f.Names[0].Name,
+						GoType: exprString(f.Type),
+						Tag:    reflect.StructTag(tag).Get("struct"),
+					})
+				}
+				s.structs[spec.Name.Name] = fields
+			}
+		}
+	}
+	return nil
+}
+
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.ArrayType:
+		return "[]" + exprString(e.Elt)
+	default:
+		return ""
+	}
+}
+
+func lowerFirst(s string) string {
+	return string(unicode.ToLower(rune(s[0]))) + s[1:]
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(s[i-1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var arrayTag = regexp.MustCompile(`^\[(\d*)\](u?int(?:8|16|32)|byte)(?:,sizefrom=(\w+))?$`)
+
+type generator struct {
+	src   *source
+	decls bytes.Buffer
+}
+
+func (g *generator) isNodeId(f field) bool {
+	return strings.HasSuffix(f.GoType, "MeshNodeId") || f.Name == "Path" || f.Name == "NodeId"
+}
+
+// protoKind returns the ProtoField type of a number, node ids are shown as unsigned hex numbers
+func (g *generator) protoKind(f field, kind string) string {
+	if g.isNodeId(f) && strings.HasPrefix(kind, "int") {
+		return "u" + kind
+	}
+	return kind
+}
+
+// fieldSpec declares the ProtoField of a struct field and returns its lua description
+func (g *generator) fieldSpec(structName string, f field) string {
+	abbrev := fmt.Sprintf("meshmesh.%s.%s", snakeCase(structName), snakeCase(f.Name))
+	// Fields are kept in a table, lua limits the number of local variables
+	varName := fmt.Sprintf("f.%s_%s", snakeCase(structName), snakeCase(f.Name))
+
+	protoField := func(kind string, extra string) {
+		fmt.Fprintf(&g.decls, "%s = ProtoField.%s(%q, %q%s)\n", varName, kind, abbrev, f.Name, extra)
+	}
+	numberBase := func() string {
+		if g.isNodeId(f) {
+			return ", base.HEX"
+		}
+		if f.Name == "Command" && strings.HasPrefix(structName, "ConnectedPath") {
+			return ", base.DEC, connected_path_commands"
+		}
+		return ", base.DEC"
+	}
+
+	switch f.Tag {
+	case "uint8", "int8", "uint16", "int16", "uint32", "int32":
+		protoField(g.protoKind(f, f.Tag), numberBase())
+		return fmt.Sprintf("{ field = %s, kind = %q, name = %q }", varName, f.Tag, f.Name)
+	case "bool":
+		protoField("uint8", ", base.DEC")
+		return fmt.Sprintf("{ field = %s, kind = \"uint8\", name = %q }", varName, f.Name)
+	case "string":
+		protoField("string", "")
+		return fmt.Sprintf("{ field = %s, kind = \"string\", name = %q }", varName, f.Name)
+	case "[]byte":
+		if f.Name == "Payload" {
+			return fmt.Sprintf("{ kind = \"payload\", name = %q }", f.Name)
+		}
+		protoField("bytes", "")
+		return fmt.Sprintf("{ field = %s, kind = \"bytes\", name = %q }", varName, f.Name)
+	}
+
+	m := arrayTag.FindStringSubmatch(f.Tag)
+	if m == nil {
+		log.Fatalf("%s.%s: unsupported struct tag %q", structName, f.Name, f.Tag)
+	}
+	count, elem, sizeFrom := m[1], m[2], m[3]
+	if count == "" && sizeFrom == "" {
+		log.Fatalf("%s.%s: array without size %q", structName, f.Name, f.Tag)
+	}
+
+	size := count
+	if sizeFrom != "" {
+		size = strconv.Quote(sizeFrom)
+	}
+	if elem == "byte" {
+		if f.GoType == "string" || f.Name == "Tag" {
+			protoField("stringz", "")
+			return fmt.Sprintf("{ field = %s, kind = \"string\", size = %s, name = %q }", varName, size, f.Name)
+		}
+		protoField("bytes", "")
+		return fmt.Sprintf("{ field = %s, kind = \"bytes\", size = %s, name = %q }", varName, size, f.Name)
+	}
+	protoField(g.protoKind(f, elem), numberBase())
+	return fmt.Sprintf("{ field = %s, kind = %q, count = %s, name = %q }", varName, elem, size, f.Name)
+}
+
+// message returns the lua description of a struct. Id and ApiId are decoded by the frame header.
+func (g *generator) message(structName string, extra string) string {
+	fields, ok := g.src.structs[structName]
+	if !ok {
+		log.Fatalf("struct %s not found", structName)
+	}
+	specs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Name == "Id" || f.Name == "ApiId" {
+			continue
+		}
+		specs = append(specs, "\t"+g.fieldSpec(structName, f)+",")
+	}
+	return fmt.Sprintf("{ name = %q,%s fields = {\n%s\n} }", structName, extra, strings.Join(specs, "\n"))
+}
+
+// fieldOffset returns the position of a field after the Id byte, only fixed size fields can precede it
+func (g *generator) fieldOffset(structName string, name string) int {
+	sizes := map[string]int{"uint8": 1, "int8": 1, "bool": 1, "uint16": 2, "int16": 2, "uint32": 4, "int32": 4}
+	offset := 0
+	for _, f := range g.src.structs[structName] {
+		if f.Name == name {
+			return offset
+		}
+		if f.Name == "Id" {
+			continue
+		}
+		size, ok := sizes[f.Tag]
+		if !ok {
+			log.Fatalf("%s.%s: can't compute the offset of %s", structName, f.Name, name)
+		}
+		offset += size
+	}
+	log.Fatalf("%s: field %s not found", structName, name)
+	return 0
+}
+
+func (g *generator) subtypeConst(structName string) (uint64, bool) {
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(structName, "Request"), "Reply")
+	for _, name := range []string{lowerFirst(structName), lowerFirst(trimmed), lowerFirst(trimmed) + "Request"} {
+		if v, ok := g.src.consts[name]; ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func (g *generator) isFamilyConst(name string) bool {
+	for prefix, ids := range families {
+		if strings.HasPrefix(name, lowerFirst(prefix)) && name != ids[0] && name != ids[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *generator) isConnectedPathCommand(name string) bool {
+	return strings.HasPrefix(name, "connectedPath") && name != "connectedPathApiRequest" && name != "connectedPathApiReply"
+}
+
+func (g *generator) generate() []byte {
+	src := g.src
+
+	// Top level messages
+	var types []string
+	var messages []string
+	for _, name := range src.order {
+		if !strings.HasSuffix(name, "Request") && !strings.HasSuffix(name, "Reply") {
+			continue
+		}
+		if g.isFamilyConst(name) || g.isConnectedPathCommand(name) {
+			continue
+		}
+		id := src.consts[name]
+		structName := structOverrides[name]
+		if structName == "" {
+			structName = string(unicode.ToUpper(rune(name[0]))) + name[1:]
+		}
+		types = append(types, fmt.Sprintf("\t[%d] = %q,", id, structName))
+
+		if pbName, ok := protobufFrames[name]; ok {
+			messages = append(messages, fmt.Sprintf("messages[%d] = { name = %q, protobuf = %q, fields = {} }", id, structName, pbName))
+		} else if _, ok := src.structs[structName]; ok {
+			extra := ""
+			if _, ok := src.structs[structName+"2"]; ok {
+				// The open connection command carries the path instead of the data
+				extra = fmt.Sprintf(" variant = { offset = %d, value = %d, message = %s },",
+					g.fieldOffset(structName, "Command"), src.consts["connectedPathOpenConnectionRequest"], g.message(structName+"2", ""))
+			}
+			messages = append(messages, fmt.Sprintf("messages[%d] = %s", id, g.message(structName, extra)))
+		}
+	}
+
+	// Messages selected by the ApiId byte
+	var structNames []string
+	for name := range src.structs {
+		structNames = append(structNames, name)
+	}
+	sort.Strings(structNames)
+	var prefixes []string
+	for prefix := range families {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		ids := families[prefix]
+		requestId, replyId := src.consts[ids[0]], src.consts[ids[1]]
+		for i, id := range []uint64{requestId, replyId} {
+			name := string(unicode.ToUpper(rune(ids[i][0]))) + ids[i][1:]
+			messages = append(messages, fmt.Sprintf("messages[%d] = messages[%d] or { name = %q, fields = {} }", id, id, name))
+			messages = append(messages, fmt.Sprintf("messages[%d].family = {}", id))
+		}
+		for _, name := range structNames {
+			if !strings.HasPrefix(name, prefix) || lowerFirst(name) == ids[0] || lowerFirst(name) == ids[1] {
+				continue
+			}
+			subtype, ok := g.subtypeConst(name)
+			if !ok {
+				continue
+			}
+			id := requestId
+			if strings.HasSuffix(name, "Reply") {
+				id = replyId
+			}
+			messages = append(messages, fmt.Sprintf("messages[%d].family[%d] = %s", id, subtype, g.message(name, "")))
+		}
+	}
+
+	// Connected path commands
+	var commands []string
+	for _, name := range src.order {
+		if g.isConnectedPathCommand(name) {
+			commands = append(commands, fmt.Sprintf("\t[%d] = %q,", src.consts[name], strings.TrimPrefix(name, "connectedPath")))
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("-- Code generated by dissectorgen from meshmesh/apiframe.go. DO NOT EDIT.\n\n")
+	out.WriteString(luaHeader)
+	fmt.Fprintf(&out, "\nlocal frame_types = {\n%s\n}\n", strings.Join(types, "\n"))
+	fmt.Fprintf(&out, "\nlocal connected_path_commands = {\n%s\n}\n\n", strings.Join(commands, "\n"))
+	out.Write(g.decls.Bytes())
+	out.WriteString("\nlocal messages = {}\n")
+	for _, m := range messages {
+		out.WriteString(m + "\n")
+	}
+	out.WriteString(luaDissector)
+	return out.Bytes()
+}
+
+func main() {
+	srcDir := flag.String("src", ".", "folder of the meshmesh package")
+	output := flag.String("o", "meshmesh.lua", "output file")
+	flag.Parse()
+
+	src := &source{consts: make(map[string]uint64), structs: make(map[string][]field)}
+	for _, name := range []string{"apiframe.go", "connectedpath.go"} {
+		if err := src.parseFile(filepath.Join(*srcDir, name)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	g := &generator{src: src}
+	if err := os.WriteFile(*output, g.generate(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
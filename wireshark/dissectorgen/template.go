@@ -0,0 +1,163 @@
+package main
+
+const luaHeader = `-- Wireshark dissector of the meshmeshgo captures (--capture option).
+-- The frames are stored unescaped and without crc16 with the LINKTYPE_USER0 link type.
+-- Copy this file in the Wireshark personal plugins folder.
+
+local meshmesh = Proto("meshmesh", "MeshMesh API")
+local f = {}
+`
+
+const luaDissector = `
+f.type = ProtoField.uint8("meshmesh.type", "Type", base.DEC, frame_types)
+f.subtype = ProtoField.uint8("meshmesh.subtype", "Subtype", base.DEC)
+f.protobuf = ProtoField.bytes("meshmesh.protobuf", "Protocol buffer")
+f.data = ProtoField.bytes("meshmesh.data", "Data")
+f.trailer = ProtoField.bytes("meshmesh.trailer", "Trailing data")
+
+local field_list = {}
+for _, field in pairs(f) do
+	table.insert(field_list, field)
+end
+meshmesh.fields = field_list
+
+local ef_truncated = ProtoExpert.new("meshmesh.truncated", "Truncated frame", expert.group.MALFORMED, expert.severity.ERROR)
+local ef_crc = ProtoExpert.new("meshmesh.crc_error", "Frame received with a crc16 error", expert.group.CHECKSUM, expert.severity.ERROR)
+meshmesh.experts = { ef_truncated, ef_crc }
+
+local crc_error_field = nil
+pcall(function() crc_error_field = Field.new("frame.packet_flags_crc_error") end)
+
+local sizes = { uint8 = 1, int8 = 1, uint16 = 2, int16 = 2, uint32 = 4, int32 = 4 }
+
+local dissect_frame
+
+-- Adds the fields of a message to the tree and returns the offset after them
+local function dissect_fields(buf, pinfo, tree, offset, msg, depth)
+	local values = {}
+	for _, spec in ipairs(msg.fields) do
+		local remaining = buf:len() - offset
+		if spec.kind == "payload" then
+			if remaining > 0 then
+				dissect_frame(buf(offset):tvb(), pinfo, tree, depth + 1)
+			end
+			return buf:len()
+		elseif sizes[spec.kind] then
+			local size = sizes[spec.kind]
+			local count = spec.count or 1
+			if type(count) == "string" then
+				count = values[count] or 0
+			end
+			for _ = 1, count do
+				if remaining < size then
+					tree:add_proto_expert_info(ef_truncated)
+					return buf:len()
+				end
+				local range = buf(offset, size)
+				tree:add_le(spec.field, range)
+				if spec.kind:sub(1, 1) == "u" then
+					values[spec.name] = range:le_uint()
+				else
+					values[spec.name] = range:le_int()
+				end
+				offset = offset + size
+				remaining = remaining - size
+			end
+		else
+			local size = spec.size or remaining
+			if type(size) == "string" then
+				size = values[size] or 0
+			end
+			if remaining < size then
+				tree:add_proto_expert_info(ef_truncated)
+				return buf:len()
+			end
+			if size > 0 then
+				tree:add(spec.field, buf(offset, size))
+			end
+			offset = offset + size
+		end
+	end
+	return offset
+end
+
+dissect_frame = function(buf, pinfo, tree, depth)
+	if buf:len() == 0 then
+		return
+	end
+
+	local id = buf(0, 1):uint()
+	local msg = messages[id]
+	local name = frame_types[id] or string.format("Unknown 0x%02X", id)
+	local subtree = tree:add(meshmesh, buf(), name)
+	subtree:add(f.type, buf(0, 1))
+
+	local offset = 1
+	if msg and msg.family then
+		if buf:len() < 2 then
+			subtree:add_proto_expert_info(ef_truncated)
+			return
+		end
+		local subtype = buf(1, 1):uint()
+		subtree:add(f.subtype, buf(1, 1))
+		offset = 2
+		if msg.family[subtype] then
+			msg = msg.family[subtype]
+			name = msg.name
+		else
+			name = string.format("%s/%d", name, subtype)
+		end
+		subtree:set_text(name)
+	end
+
+	if msg and msg.variant and buf:len() > msg.variant.offset + 1 and buf(msg.variant.offset + 1, 1):uint() == msg.variant.value then
+		msg = msg.variant.message
+		name = msg.name
+		subtree:set_text(name)
+	end
+
+	if depth == 0 then
+		pinfo.cols.info:set(name)
+	else
+		pinfo.cols.info:append(" > " .. name)
+	end
+
+	if msg == nil then
+		if buf:len() > offset then
+			subtree:add(f.data, buf(offset))
+		end
+	elseif msg.protobuf then
+		if buf:len() > offset then
+			subtree:add(f.protobuf, buf(offset)):append_text(" (" .. msg.protobuf .. ")")
+		end
+	else
+		offset = dissect_fields(buf, pinfo, subtree, offset, msg, depth)
+		if buf:len() > offset then
+			subtree:add(f.trailer, buf(offset))
+		end
+	end
+end
+
+function meshmesh.dissector(buf, pinfo, tree)
+	pinfo.cols.protocol = "MESHMESH"
+	if pinfo.p2p_dir == 0 then
+		pinfo.cols.src = "hub"
+		pinfo.cols.dst = "coordinator"
+	elseif pinfo.p2p_dir == 1 then
+		pinfo.cols.src = "coordinator"
+		pinfo.cols.dst = "hub"
+	end
+
+	dissect_frame(buf, pinfo, tree, 0)
+
+	if crc_error_field then
+		local crc_error = crc_error_field()
+		if crc_error and crc_error.value then
+			tree:add_proto_expert_info(ef_crc)
+		end
+	end
+end
+
+local encap = wtap_encaps and wtap_encaps.USER0 or wtap.USER0
+DissectorTable.get("wtap_encap"):add(encap, meshmesh)
+`
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 
 	"github.com/brutella/dnssd"
 	"leguru.net/m/v2/graph"
@@ -15,7 +16,10 @@ type ZeroconfResponder struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	rp       dnssd.Responder
+	lock     sync.Mutex
 	services map[string]dnssd.ServiceHandle
+	// Name of the service announced for every node
+	nodes map[int64]string
 }
 
 func (z *ZeroconfResponder) setupZeroconf() error {
@@ -70,36 +74,74 @@ func (z *ZeroconfResponder) removeService(name string) error {
 	return nil
 }
 
-func (z *ZeroconfResponder) networkChangedCallback(network *graph.Network, noBackup bool) {
-	logger.WithFields(logger.Fields{"network": network.NetworkId()}).Info("ZeroconfResponder.networkChangedCallback")
-	nodes := network.Nodes()
-	for nodes.Next() {
-		node := nodes.Node().(graph.NodeDevice)
-		wantService := node.Device().InUse() && !network.IsLocalDevice(node) && !node.Device().DeepSleep() && node.Device().Name() != ""
-		hasService := z.services[node.Device().Name()] != nil
-
-		//logger.WithFields(logger.Fields{"node": node.Device().Name(), "wantService": wantService, "hasService": hasService}).Info("ZeroconfResponder.networkChangedCallback")
-		if wantService && !hasService {
-			port := utils.ComputeNodePort(node.ID(), 6053, 20000, 10000)
-			z.addService(node.Device().Name(), node.Device().FriendlyName(), int32(node.ID()), port, node.Device().Firmware())
-			logger.WithFields(logger.Fields{"node": node.Device().Name(), "port": port}).Info("ZeroconfResponder.networkChangedCallback: Adding Zeroconf service")
-		} else if !wantService && hasService {
-			logger.WithFields(logger.Fields{"node": node.Device().Name()}).Info("ZeroconfResponder.networkChangedCallback: Removing Zeroconf service")
-			z.removeService(node.Device().Name())
+// updateNodeService announces, removes or refreshes the service of a node after a change of the network
+func (z *ZeroconfResponder) updateNodeService(network *graph.Network, nodeId int64, refresh bool) {
+	name, hasService := z.nodes[nodeId]
+	node, err := network.GetNodeDevice(nodeId)
+	wantService := err == nil && node.Device().InUse() && !network.IsLocalDevice(node) && !node.Device().DeepSleep() && node.Device().Name() != ""
+
+	if hasService && (!wantService || refresh || name != node.Device().Name()) {
+		logger.WithFields(logger.Fields{"node": name}).Info("ZeroconfResponder.updateNodeService: Removing Zeroconf service")
+		z.removeService(name)
+		delete(z.nodes, nodeId)
+		hasService = false
+	}
+	if wantService && !hasService {
+		port := utils.ComputeNodePort(node.ID(), 6053, 20000, 10000)
+		if err := z.addService(node.Device().Name(), node.Device().FriendlyName(), int32(node.ID()), port, node.Device().Firmware()); err != nil {
+			logger.WithFields(logger.Fields{"node": node.Device().Name(), "err": err}).Error("ZeroconfResponder.updateNodeService: Can't add Zeroconf service")
+			return
 		}
+		z.nodes[nodeId] = node.Device().Name()
+		logger.WithFields(logger.Fields{"node": node.Device().Name(), "port": port}).Info("ZeroconfResponder.updateNodeService: Adding Zeroconf service")
 	}
+}
 
-	// TODO: Check if there are any services that are not in the network and remove them
+// networkEvent updates only the service of the node changed
+func (z *ZeroconfResponder) networkEvent(network *graph.Network, event graph.NetworkEvent) {
+	// The networks of the coordinators change from different goroutines
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	switch event.Type {
+	case graph.NodeAdded, graph.NodeRemoved:
+		z.updateNodeService(network, event.Node, false)
+	case graph.NodeUpdated:
+		if event.Changed("inuse", "deepsleep", "name") {
+			z.updateNodeService(network, event.Node, false)
+		} else if event.Changed("friendlyname", "firmware") {
+			// The text record of the service is announced again
+			z.updateNodeService(network, event.Node, true)
+		}
+	case graph.LocalNodeChanged:
+		z.updateNodeService(network, event.Previous, false)
+		z.updateNodeService(network, event.Node, false)
+	}
 }
 
-func (z *ZeroconfResponder) Start(network *graph.Network) error {
+// syncServices announces the services of all the nodes of the network
+func (z *ZeroconfResponder) syncServices(network *graph.Network) {
+	logger.WithFields(logger.Fields{"network": network.NetworkId()}).Info("ZeroconfResponder.syncServices")
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	nodes := network.Nodes()
+	for nodes.Next() {
+		z.updateNodeService(network, nodes.Node().ID(), false)
+	}
+}
+
+// Start announces the nodes of the given networks, one for every coordinator
+func (z *ZeroconfResponder) Start(networks ...*graph.Network) error {
 	err := z.setupZeroconf()
 	if err != nil {
 		return err
 	}
 
-	network.AddNetworkChangedCallback(z.networkChangedCallback)
-	z.networkChangedCallback(network, false)
+	for _, network := range networks {
+		network.AddNetworkEventCallback(z.networkEvent)
+		z.syncServices(network)
+	}
 
 	z.ctx, z.cancel = context.WithCancel(context.Background())
 	go z.rp.Respond(z.ctx)
@@ -107,13 +149,16 @@ func (z *ZeroconfResponder) Start(network *graph.Network) error {
 }
 
 func (z *ZeroconfResponder) Stop() {
+	z.lock.Lock()
+	defer z.lock.Unlock()
 	for _, service := range z.services {
 		z.rp.Remove(service)
 	}
 	z.services = make(map[string]dnssd.ServiceHandle)
+	z.nodes = make(map[int64]string)
 	z.cancel()
 }
 
-func NewZeroconfResponder() ZeroconfResponder {
-	return ZeroconfResponder{rp: nil, services: make(map[string]dnssd.ServiceHandle)}
+func NewZeroconfResponder() *ZeroconfResponder {
+	return &ZeroconfResponder{rp: nil, services: make(map[string]dnssd.ServiceHandle), nodes: make(map[int64]string)}
 }